@@ -0,0 +1,342 @@
+// Command jobctl runs maintenance tasks against the job tracker database.
+//
+// Usage:
+//
+//	jobctl backup  -email user@example.com -out backup.zip
+//	jobctl restore -email user@example.com -in backup.zip
+//	jobctl migrate-blobs -from database -to filesystem
+//	jobctl analyze-jobs
+//	jobctl sanitize-descriptions
+//	jobctl train-models [-email user@example.com]
+//	jobctl poll-watches
+//	jobctl check-postings
+//	jobctl promote-admin -email user@example.com
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"job-tracker-backend/internal/config"
+	"job-tracker-backend/internal/database"
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+	"job-tracker-backend/internal/scan"
+	"job-tracker-backend/internal/service"
+	"job-tracker-backend/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(db *gorm.DB, cfg *config.Config, args []string) error
+}
+
+var commands = []command{
+	{"backup", "write a backup archive of a user's data", runBackup},
+	{"restore", "restore a backup archive into a user's account", runRestore},
+	{"migrate-blobs", "move attachment and document contents between storage backends", runMigrateBlobs},
+	{"analyze-jobs", "re-extract skills and requirements from every job description", runAnalyzeJobs},
+	{"sanitize-descriptions", "sanitize job descriptions saved before sanitizing, keeping the originals", runSanitizeDescriptions},
+	{"train-models", "retrain the preference models of users whose jobs changed", runTrainModels},
+	{"poll-watches", "poll every watched company's job board now", runPollWatches},
+	{"check-postings", "check whether saved jobs' postings are still open", runCheckPostings},
+	{"promote-admin", "make a user an admin", runPromoteAdmin},
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == os.Args[1] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		usage()
+		os.Exit(2)
+	}
+
+	cfg := config.Load()
+	db, err := database.Open(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	if err := cmd.run(db, cfg, os.Args[2:]); err != nil {
+		log.Fatalf("%s: %v", cmd.name, err)
+	}
+}
+
+func usage() {
+	var b strings.Builder
+	b.WriteString("usage: jobctl <command> [flags]\n\ncommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "  %-22s %s\n", c.name, c.summary)
+	}
+	fmt.Fprint(os.Stderr, b.String())
+}
+
+func runBackup(db *gorm.DB, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	email := fs.String("email", "", "email of the account to back up")
+	out := fs.String("out", "", "path of the archive to write")
+	fs.Parse(args)
+	if *email == "" || *out == "" {
+		fs.Usage()
+		return fmt.Errorf("-email and -out are required")
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	user, err := userRepo.GetByEmail(strings.ToLower(*email))
+	if err != nil {
+		return fmt.Errorf("find user %s: %w", *email, err)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	blobs, err := storage.NewManagerFromConfig(cfg, db)
+	if err != nil {
+		return err
+	}
+	svc := service.NewBackupService(repository.NewBackupRepository(db), repository.NewJobRepository(db), userRepo, repository.NewDocumentRepository(db), repository.NewWatchRepository(db), blobs, scan.Nop{})
+	if err := svc.WriteArchive(user.ID, f); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Printf("Wrote backup of %s to %s", user.Email, *out)
+	return nil
+}
+
+func runRestore(db *gorm.DB, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	email := fs.String("email", "", "email of the account to restore into")
+	in := fs.String("in", "", "path of the archive to read")
+	fs.Parse(args)
+	if *email == "" || *in == "" {
+		fs.Usage()
+		return fmt.Errorf("-email and -in are required")
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	user, err := userRepo.GetByEmail(strings.ToLower(*email))
+	if err != nil {
+		return fmt.Errorf("find user %s: %w", *email, err)
+	}
+
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	blobs, err := storage.NewManagerFromConfig(cfg, db)
+	if err != nil {
+		return err
+	}
+	// Restored files are checked like uploads, by the configured scanner
+	scanner, err := scan.NewFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	svc := service.NewBackupService(repository.NewBackupRepository(db), repository.NewJobRepository(db), userRepo, repository.NewDocumentRepository(db), repository.NewWatchRepository(db), blobs, scanner)
+	result, err := svc.RestoreArchive(user.ID, f, info.Size())
+	if err != nil {
+		return err
+	}
+	log.Printf("Restored into %s: %d jobs created, %d skipped as duplicates, %d attachments, %d status changes, %d documents, %d document versions, %d watches, %d notifications",
+		user.Email, result.JobsCreated, result.JobsSkipped, result.AttachmentsCreated, result.StatusChangesCreated,
+		result.DocumentsCreated, result.DocumentVersionsCreated, result.WatchesCreated, result.NotificationsCreated)
+	return nil
+}
+
+func runMigrateBlobs(db *gorm.DB, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("migrate-blobs", flag.ExitOnError)
+	from := fs.String("from", storage.BackendDatabase, "backend to move attachments out of")
+	to := fs.String("to", cfg.StorageBackend, "backend to move attachments into")
+	fs.Parse(args)
+
+	blobs, err := storage.NewManagerFromConfig(cfg, db)
+	if err != nil {
+		return err
+	}
+	jobRepo := repository.NewJobRepository(db)
+	svc := service.NewJobService(jobRepo, blobs, scan.Nop{})
+	moved, err := svc.MigrateAttachments(*from, *to, func(moved, total int) {
+		if moved%50 == 0 || moved == total {
+			log.Printf("Moved %d/%d attachments", moved, total)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("stopped after %d attachments: %w", moved, err)
+	}
+	log.Printf("Moved %d attachments from %s to %s", moved, *from, *to)
+
+	docs := service.NewDocumentService(repository.NewDocumentRepository(db), jobRepo, blobs, scan.Nop{})
+	moved, err = docs.MigrateVersions(*from, *to, func(moved, total int) {
+		if moved%50 == 0 || moved == total {
+			log.Printf("Moved %d/%d document versions", moved, total)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("stopped after %d document versions: %w", moved, err)
+	}
+	log.Printf("Moved %d document versions from %s to %s", moved, *from, *to)
+	return nil
+}
+
+func runAnalyzeJobs(db *gorm.DB, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("analyze-jobs", flag.ExitOnError)
+	fs.Parse(args)
+
+	blobs, err := storage.NewManagerFromConfig(cfg, db)
+	if err != nil {
+		return err
+	}
+	svc := service.NewJobService(repository.NewJobRepository(db), blobs, scan.Nop{})
+	done, err := svc.AnalyzeJobs(func(done int) {
+		if done%500 == 0 {
+			log.Printf("Analyzed %d jobs", done)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("stopped after %d jobs: %w", done, err)
+	}
+	log.Printf("Analyzed %d jobs", done)
+	return nil
+}
+
+func runSanitizeDescriptions(db *gorm.DB, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("sanitize-descriptions", flag.ExitOnError)
+	fs.Parse(args)
+
+	blobs, err := storage.NewManagerFromConfig(cfg, db)
+	if err != nil {
+		return err
+	}
+	svc := service.NewJobService(repository.NewJobRepository(db), blobs, scan.Nop{})
+	done, err := svc.SanitizeDescriptions(func(done int) {
+		if done%500 == 0 {
+			log.Printf("Sanitized %d descriptions", done)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("stopped after %d jobs: %w", done, err)
+	}
+	log.Printf("Sanitized %d descriptions", done)
+	return nil
+}
+
+func runTrainModels(db *gorm.DB, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("train-models", flag.ExitOnError)
+	email := fs.String("email", "", "email of a single account to retrain (default: every account with changed jobs)")
+	fs.Parse(args)
+
+	userRepo := repository.NewUserRepository(db)
+	svc := service.NewPreferenceService(repository.NewJobRepository(db), userRepo)
+	if *email == "" {
+		done, err := svc.RetrainChanged()
+		if err != nil {
+			return err
+		}
+		log.Printf("Retrained %d preference models", done)
+		return nil
+	}
+
+	user, err := userRepo.GetByEmail(strings.ToLower(*email))
+	if err != nil {
+		return fmt.Errorf("find user %s: %w", *email, err)
+	}
+	summary, err := svc.Train(user.ID)
+	if err != nil {
+		return err
+	}
+	if !summary.Trained {
+		log.Printf("%s has %d liked and %d disliked jobs; at least %d of each are needed", user.Email, summary.Liked, summary.Disliked, summary.MinExamples)
+		return nil
+	}
+	log.Printf("Trained preference model of %s from %d liked and %d disliked jobs", user.Email, summary.Liked, summary.Disliked)
+	return nil
+}
+
+func runPollWatches(db *gorm.DB, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("poll-watches", flag.ExitOnError)
+	fs.Parse(args)
+
+	blobs, err := storage.NewManagerFromConfig(cfg, db)
+	if err != nil {
+		return err
+	}
+	jobRepo := repository.NewJobRepository(db)
+	ats := service.NewATSClientFromConfig(cfg)
+	svc := service.NewWatchService(repository.NewWatchRepository(db), repository.NewNotificationRepository(db),
+		service.NewJobService(jobRepo, blobs, scan.Nop{}), ats)
+	found, err := svc.PollDue(0)
+	if err != nil {
+		return err
+	}
+	log.Printf("Found %d new openings", found)
+	return nil
+}
+
+func runCheckPostings(db *gorm.DB, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("check-postings", flag.ExitOnError)
+	fs.Parse(args)
+
+	svc := service.NewLivenessService(repository.NewJobRepository(db), repository.NewNotificationRepository(db),
+		cfg.LivenessUserAgent, cfg.LivenessHostDelay)
+	closed, err := svc.CheckDue(0)
+	if err != nil {
+		return err
+	}
+	log.Printf("Found %d postings closed", closed)
+	return nil
+}
+
+func runPromoteAdmin(db *gorm.DB, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("promote-admin", flag.ExitOnError)
+	email := fs.String("email", "", "email of the account to make an admin")
+	fs.Parse(args)
+	if *email == "" {
+		fs.Usage()
+		return fmt.Errorf("-email is required")
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	user, err := userRepo.GetByEmail(strings.ToLower(strings.TrimSpace(*email)))
+	if err != nil {
+		return fmt.Errorf("find user %s: %w", *email, err)
+	}
+	if user.Role == domain.RoleAdmin {
+		log.Printf("%s is already an admin", user.Email)
+		return nil
+	}
+	if err := userRepo.SetRole(user.ID, domain.RoleAdmin); err != nil {
+		return err
+	}
+	log.Printf("%s is now an admin", user.Email)
+	return nil
+}
@@ -1,22 +1,28 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"job-tracker-backend/internal/auth"
 	"job-tracker-backend/internal/config"
+	"job-tracker-backend/internal/database"
 	"job-tracker-backend/internal/domain"
 	"job-tracker-backend/internal/handler"
+	"job-tracker-backend/internal/mail"
 	appMiddleware "job-tracker-backend/internal/middleware"
 	"job-tracker-backend/internal/repository"
+	"job-tracker-backend/internal/scan"
 	"job-tracker-backend/internal/service"
+	"job-tracker-backend/internal/storage"
+	"job-tracker-backend/pkg/ratelimit"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
@@ -27,21 +33,17 @@ func main() {
 		log.Fatal("JWT_SECRET env var must be set")
 	}
 
-	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBSSLMode,
-	)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := database.Open(cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
 	// Migrate with user_id nullable first to allow backfill
-	if err := db.AutoMigrate(&domain.User{}, &domain.Job{}, &domain.Attachment{}); err != nil {
+	if err := database.Migrate(db); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
-	// Seed nuttello@gmail.com and backfill existing jobs to that user
+	// Seed the first admin and backfill existing jobs to that user
 	if err := seedAndBackfill(db, cfg); err != nil {
 		log.Fatalf("Failed to seed user and backfill jobs: %v", err)
 	}
@@ -51,17 +53,89 @@ func main() {
 		log.Fatalf("Failed to add NOT NULL constraint to jobs.user_id: %v", err)
 	}
 
+	blobs, err := storage.NewManagerFromConfig(cfg, db)
+	if err != nil {
+		log.Fatalf("Failed to configure attachment storage: %v", err)
+	}
+	scanner, err := scan.NewFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure upload scanner: %v", err)
+	}
+
 	jobRepo := repository.NewJobRepository(db)
 	userRepo := repository.NewUserRepository(db)
-	jobService := service.NewJobService(jobRepo, cfg.MCPServerURL)
-	authService := service.NewAuthService(userRepo, cfg.JWTSecret, cfg.JWTExpiration)
-	jobHandler := handler.NewJobHandler(jobService)
+	docRepo := repository.NewDocumentRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	watchRepo := repository.NewWatchRepository(db)
+	atsClient := service.NewATSClientFromConfig(cfg)
+	searchProviders, err := service.NewSearchProviders(cfg, atsClient)
+	if err != nil {
+		log.Fatalf("Failed to configure search providers: %v", err)
+	}
+	jobService := service.NewJobService(jobRepo, blobs, scanner, searchProviders...)
+	mailer, err := mail.NewFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure mailer: %v", err)
+	}
+	authService := service.NewAuthService(cfg, userRepo, repository.NewSessionRepository(db), repository.NewAccountTokenRepository(db), repository.NewLoginAttemptRepository(db), repository.NewRecoveryCodeRepository(db), mailer)
+	ssoService, err := service.NewSSOService(cfg, userRepo, repository.NewExternalIdentityRepository(db), authService)
+	if err != nil {
+		log.Fatalf("Failed to configure single sign-on: %v", err)
+	}
+	apiTokenService := service.NewAPITokenService(repository.NewAPITokenRepository(db), userRepo)
+	adminService := service.NewAdminService(userRepo, jobRepo, docRepo, blobs, authService)
+	calendarService := service.NewCalendarService(jobRepo, userRepo, cfg.PublicBaseURL)
+	exportService := service.NewExportService(jobRepo)
+	importService := service.NewImportService(jobRepo)
+	backupService := service.NewBackupService(repository.NewBackupRepository(db), jobRepo, userRepo, docRepo, watchRepo, blobs, scanner)
+	documentService := service.NewDocumentService(docRepo, jobRepo, blobs, scanner)
+	rankingService := service.NewRankingService(userRepo)
+	preferenceService := service.NewPreferenceService(jobRepo, userRepo)
+	watchService := service.NewWatchService(watchRepo, notificationRepo, jobService, atsClient)
+	notificationService := service.NewNotificationService(notificationRepo)
+	livenessService := service.NewLivenessService(jobRepo, notificationRepo, cfg.LivenessUserAgent, cfg.LivenessHostDelay)
+	urlImportService := service.NewURLImportService(jobService, cfg.LivenessUserAgent, time.Duration(cfg.SearchTimeoutSeconds)*time.Second)
+	jobHandler := handler.NewJobHandler(jobService, rankingService, preferenceService, livenessService, urlImportService)
 	attachmentHandler := handler.NewAttachmentHandler(jobService)
 	authHandler := handler.NewAuthHandler(authService)
+	ssoHandler := handler.NewSSOHandler(ssoService, authService)
+	apiTokenHandler := handler.NewAPITokenHandler(apiTokenService)
+	adminHandler := handler.NewAdminHandler(adminService)
+	calendarHandler := handler.NewCalendarHandler(calendarService)
+	exportHandler := handler.NewExportHandler(exportService)
+	importHandler := handler.NewImportHandler(importService)
+	backupHandler := handler.NewBackupHandler(backupService)
+	documentHandler := handler.NewDocumentHandler(documentService)
+	rankingHandler := handler.NewRankingHandler(rankingService)
+	preferenceHandler := handler.NewPreferenceHandler(preferenceService)
+	watchHandler := handler.NewWatchHandler(watchService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+
+	if cfg.PreferenceRetrainInterval > 0 {
+		go preferenceService.RetrainEvery(cfg.PreferenceRetrainInterval)
+	}
+	if cfg.WatchPollInterval > 0 {
+		go watchService.PollEvery(cfg.WatchPollInterval)
+	}
+	if cfg.LivenessCheckInterval > 0 {
+		go livenessService.CheckEvery(cfg.LivenessCheckInterval)
+	}
+
+	authMW := appMiddleware.Authenticate(cfg.JWTSecret, authService, apiTokenService)
 
-	authMW := appMiddleware.Authenticate(cfg.JWTSecret)
+	trustedProxies, err := appMiddleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Failed to configure trusted proxies: %v", err)
+	}
+	limits := ratelimit.NewMemory()
+	authThrottle := []func(http.Handler) http.Handler{
+		appMiddleware.RateLimit(limits, "auth-ip", ratelimit.PerMinute(cfg.AuthRateLimitPerMinute), appMiddleware.ByIP),
+		appMiddleware.RateLimit(limits, "auth-email", ratelimit.PerMinute(cfg.AccountRateLimitPerMinute), appMiddleware.ByEmail),
+	}
+	searchThrottle := appMiddleware.RateLimit(limits, "search", ratelimit.PerMinute(cfg.SearchRateLimitPerMinute), appMiddleware.ByUser)
 
 	r := chi.NewRouter()
+	r.Use(appMiddleware.RealIP(trustedProxies))
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(cors.Handler(cors.Options{
@@ -78,14 +152,35 @@ func main() {
 	})
 
 	// Public auth routes
-	r.Mount("/api/auth", authHandler.PublicRoutes())
+	r.Mount("/api/auth", authHandler.PublicRoutes(authThrottle...))
+	r.Mount("/api/auth/sso", ssoHandler.Routes(authThrottle...))
+	r.Mount("/api/calendar", calendarHandler.PublicRoutes())
 
-	// Protected routes
+	// Protected routes. Personal API tokens reach only the endpoints their
+	// scopes grant; see middleware.RequiredScope.
 	r.Group(func(r chi.Router) {
 		r.Use(authMW)
 		r.Post("/api/auth/change-password", authHandler.ChangePassword)
-		r.Mount("/api/jobs", jobHandler.Routes())
+		r.Post("/api/auth/logout-all", authHandler.LogoutAll)
+		r.Get("/api/auth/sessions", authHandler.ListSessions)
+		r.Get("/api/auth/login-attempts", authHandler.ListLoginAttempts)
+		r.Mount("/api/auth/2fa", authHandler.TwoFactorRoutes())
+		r.Delete("/api/auth/sessions/{id}", authHandler.RevokeSession)
+		r.Mount("/api/jobs", jobHandler.Routes(searchThrottle))
 		r.Mount("/api/jobs/{id}/attachments", attachmentHandler.Routes())
+		r.Post("/api/jobs/{id}/attachments/link", documentHandler.LinkToJob)
+		r.Get("/api/jobs/{id}/calendar.ics", calendarHandler.JobCalendar)
+		r.Mount("/api/me/calendar", calendarHandler.Routes())
+		r.Mount("/api/me/tokens", apiTokenHandler.Routes())
+		r.Mount("/api/me/ranking-profile", rankingHandler.Routes())
+		r.Mount("/api/me/preference-model", preferenceHandler.Routes())
+		r.Mount("/api/me/notifications", notificationHandler.Routes())
+		r.Mount("/api/watches", watchHandler.Routes())
+		r.Mount("/api/export", exportHandler.Routes())
+		r.Mount("/api/import", importHandler.Routes())
+		r.Mount("/api/backup", backupHandler.Routes())
+		r.Mount("/api/documents", documentHandler.Routes())
+		r.With(appMiddleware.RequireAdmin(adminService)).Mount("/api/admin", adminHandler.Routes())
 	})
 
 	addr := fmt.Sprintf(":%s", cfg.ServerPort)
@@ -95,38 +190,53 @@ func main() {
 	}
 }
 
+// seedAndBackfill creates the admin account SEED_USER_EMAIL names if it
+// doesn't exist yet, and gives it the jobs saved before accounts existed.
+// Further admins are made with jobctl promote-admin.
 func seedAndBackfill(db *gorm.DB, cfg *config.Config) error {
-	const seedEmail = "nuttello@gmail.com"
-
-	// Check if seed user already exists
-	var existing domain.User
-	err := db.Where("email = ?", seedEmail).First(&existing).Error
-	if err == nil {
-		// User already exists — backfill any jobs that may still have empty user_id
-		db.Exec("UPDATE jobs SET user_id = ? WHERE user_id IS NULL OR user_id = ''", existing.ID)
-		return nil
+	var orphans int64
+	if err := db.Model(&domain.Job{}).Where("user_id IS NULL OR user_id = ''").Count(&orphans).Error; err != nil {
+		return fmt.Errorf("failed to count jobs without a user: %w", err)
 	}
-
-	// First boot: require seed password
-	if cfg.SeedUserPassword == "" {
-		log.Fatal("SEED_USER_PASSWORD env var must be set on first boot to create the initial user account")
+	seedEmail := strings.ToLower(strings.TrimSpace(cfg.SeedUserEmail))
+	if seedEmail == "" {
+		if orphans > 0 {
+			return fmt.Errorf("%d jobs have no user; set SEED_USER_EMAIL to give them to that account", orphans)
+		}
+		return nil
 	}
 
-	hash, err := auth.HashPassword(cfg.SeedUserPassword)
-	if err != nil {
-		return fmt.Errorf("failed to hash seed password: %w", err)
+	var seedUser domain.User
+	err := db.Where("email = ?", seedEmail).First(&seedUser).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// First boot: require seed password
+		if cfg.SeedUserPassword == "" {
+			log.Fatal("SEED_USER_PASSWORD env var must be set on first boot to create the account of SEED_USER_EMAIL")
+		}
+		hash, err := auth.HashPassword(cfg.SeedUserPassword)
+		if err != nil {
+			return fmt.Errorf("failed to hash seed password: %w", err)
+		}
+		now := time.Now()
+		seedUser = domain.User{
+			Email:           seedEmail,
+			PasswordHash:    hash,
+			Role:            domain.RoleAdmin,
+			EmailVerifiedAt: &now,
+		}
+		if err := db.Create(&seedUser).Error; err != nil {
+			return fmt.Errorf("failed to create seed user: %w", err)
+		}
+		log.Printf("Seeded admin %s (id: %s)", seedEmail, seedUser.ID)
+	} else if err != nil {
+		return fmt.Errorf("failed to look up seed user: %w", err)
 	}
 
-	seedUser := &domain.User{
-		Email:        seedEmail,
-		PasswordHash: hash,
-	}
-	if err := db.Create(seedUser).Error; err != nil {
-		return fmt.Errorf("failed to create seed user: %w", err)
+	if orphans > 0 {
+		if err := db.Exec("UPDATE jobs SET user_id = ? WHERE user_id IS NULL OR user_id = ''", seedUser.ID).Error; err != nil {
+			return fmt.Errorf("failed to backfill jobs: %w", err)
+		}
+		log.Printf("Backfilled %d existing jobs to %s", orphans, seedEmail)
 	}
-
-	// Backfill all existing jobs to this user
-	db.Exec("UPDATE jobs SET user_id = ? WHERE user_id IS NULL OR user_id = ''", seedUser.ID)
-	log.Printf("Seeded user %s (id: %s) and backfilled existing jobs", seedEmail, seedUser.ID)
 	return nil
 }
@@ -1,6 +1,9 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -10,9 +13,16 @@ import (
 
 var ErrInvalidToken = errors.New("invalid or expired token")
 
+// APITokenPrefix starts every personal API token, telling them apart from
+// access tokens and making leaked ones easy to search for.
+const APITokenPrefix = "jtp_"
+
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
+	// SessionID is the session the token was issued to; the token stops
+	// working when the session is revoked
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
@@ -25,10 +35,28 @@ func CheckPasswordHash(password, hash string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
 }
 
-func GenerateToken(userID, email, secret string, expiration time.Duration) (string, error) {
+// GenerateRandomToken returns a hex-encoded random string of n bytes of entropy.
+func GenerateRandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken returns the hex-encoded SHA-256 of a random token, for storing
+// tokens without keeping them usable. Unlike passwords they have enough
+// entropy not to need a slow hash.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func GenerateToken(userID, email, sessionID, secret string, expiration time.Duration) (string, error) {
 	claims := Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
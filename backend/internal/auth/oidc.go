@@ -0,0 +1,299 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// oidcMetadataTTL is how long discovered provider metadata is reused.
+	oidcMetadataTTL = time.Hour
+	// oidcResponseLimit bounds the size of discovery, key and token
+	// responses.
+	oidcResponseLimit = 1 << 20
+)
+
+// signingMethods are the ID token algorithms accepted. Symmetric ones are
+// left out: they'd make the client secret a signing key.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// OIDCConfig is the registration of this application with an OpenID
+// Connect provider.
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string // empty for a public client
+	RedirectURL  string
+	Scopes       []string // openid is always requested
+}
+
+// OIDCProvider signs users in with an OpenID Connect provider using the
+// authorization code flow with PKCE. The provider's endpoints are
+// discovered from its issuer, and its signing keys are cached.
+type OIDCProvider struct {
+	cfg    OIDCConfig
+	client *http.Client
+	keys   *jwks
+
+	mu          sync.Mutex
+	metadata    *oidcMetadata
+	refreshedAt time.Time
+}
+
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// IDToken is the verified identity an ID token asserts.
+type IDToken struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type idTokenClaims struct {
+	Email string `json:"email"`
+	// EmailVerified is a boolean, or a string at some providers
+	EmailVerified   interface{} `json:"email_verified"`
+	Name            string      `json:"name"`
+	Nonce           string      `json:"nonce"`
+	AuthorizedParty string      `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+func NewOIDCProvider(cfg OIDCConfig, client *http.Client) *OIDCProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &OIDCProvider{cfg: cfg, client: client, keys: &jwks{client: client}}
+}
+
+// AuthCodeURL returns the provider's sign-in page to send the user to.
+// state and nonce tie the callback and the ID token to this attempt, and the
+// PKCE verifier is kept to redeem the code.
+func (p *OIDCProvider) AuthCodeURL(state, nonce, verifier string) (string, error) {
+	meta, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("oidc: invalid authorization endpoint: %w", err)
+	}
+	scopes := []string{"openid"}
+	for _, s := range p.cfg.Scopes {
+		if s != "openid" {
+			scopes = append(scopes, s)
+		}
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", PKCEChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Exchange redeems an authorization code and returns the verified identity
+// of its ID token, which must carry nonce.
+func (p *OIDCProvider) Exchange(code, verifier, nonce string) (*IDToken, error) {
+	meta, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	if p.cfg.ClientSecret == "" {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+	req, err := http.NewRequest(http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		// RFC 6749 §2.3.1 form-encodes the credentials before Basic encoding
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request: %w", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, oidcResponseLimit)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("oidc: token response (status %d): %w", resp.StatusCode, err)
+	}
+	if body.Error != "" {
+		return nil, fmt.Errorf("oidc: token request refused: %s %s", body.Error, body.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK || body.IDToken == "" {
+		return nil, fmt.Errorf("oidc: token response without an ID token (status %d)", resp.StatusCode)
+	}
+	return p.VerifyIDToken(body.IDToken, nonce)
+}
+
+// VerifyIDToken checks an ID token's signature against the provider's keys,
+// its issuer, audience, lifetime and nonce, and returns its identity.
+func (p *OIDCProvider) VerifyIDToken(raw, nonce string) (*IDToken, error) {
+	meta, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+	var claims idTokenClaims
+	_, err = jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.keys.key(meta.JWKSURI, kid)
+	},
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: invalid ID token: %w", err)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, errors.New("oidc: invalid ID token: issued to another party")
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, errors.New("oidc: invalid ID token: nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("oidc: invalid ID token: no subject")
+	}
+	verified := false
+	switch v := claims.EmailVerified.(type) {
+	case bool:
+		verified = v
+	case string:
+		verified, _ = strconv.ParseBool(v)
+	}
+	return &IDToken{Subject: claims.Subject, Email: claims.Email, EmailVerified: verified, Name: claims.Name}, nil
+}
+
+// discover returns the provider's metadata from its discovery document.
+func (p *OIDCProvider) discover() (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil && time.Since(p.refreshedAt) < oidcMetadataTTL {
+		return p.metadata, nil
+	}
+	var meta oidcMetadata
+	wellKnown := strings.TrimRight(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(p.client, wellKnown, &meta); err != nil {
+		if p.metadata != nil {
+			// Keep using what was discovered while the provider is down
+			return p.metadata, nil
+		}
+		return nil, fmt.Errorf("oidc: discovery: %w", err)
+	}
+	if meta.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc: discovery: issuer is %q, expected %q", meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery: missing endpoints")
+	}
+	p.metadata = &meta
+	p.refreshedAt = time.Now()
+	return p.metadata, nil
+}
+
+// NewPKCEVerifier returns a random PKCE code verifier (RFC 7636).
+func NewPKCEVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// PKCEChallenge returns the S256 code challenge of a verifier.
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func getJSON(client *http.Client, u string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, oidcResponseLimit)).Decode(v)
+}
+
+// OIDCState is what a sign-in remembers between sending the user to the
+// provider and their return. It travels signed in a cookie, so any server
+// instance can finish the sign-in.
+type OIDCState struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	jwt.RegisteredClaims
+}
+
+// SignOIDCState signs st to expire after ttl. The key is derived from
+// secret, so the result can't pass for an access token.
+func SignOIDCState(st *OIDCState, secret string, ttl time.Duration) (string, error) {
+	st.ExpiresAt = jwt.NewNumericDate(time.Now().Add(ttl))
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, st).SignedString(oidcStateKey(secret))
+}
+
+func ParseOIDCState(token, secret string) (*OIDCState, error) {
+	var st OIDCState
+	_, err := jwt.ParseWithClaims(token, &st, func(t *jwt.Token) (interface{}, error) {
+		return oidcStateKey(secret), nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return &st, nil
+}
+
+func oidcStateKey(secret string) []byte {
+	sum := sha256.Sum256([]byte("oidc-state:" + secret))
+	return sum[:]
+}
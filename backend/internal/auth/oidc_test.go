@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"job-tracker-backend/internal/auth/oidctest"
+)
+
+const testNonce = "nonce-123"
+
+// testProvider returns the relying party for the test client of idp.
+func testProvider(idp *oidctest.Provider) *OIDCProvider {
+	return NewOIDCProvider(OIDCConfig{
+		Issuer:       idp.Issuer(),
+		ClientID:     oidctest.ClientID,
+		ClientSecret: oidctest.ClientSecret,
+		RedirectURL:  "http://localhost/callback",
+	}, idp.Server.Client())
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	idp := oidctest.New(t)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token func() string
+		nonce string
+		want  *IDToken
+		err   string
+	}{
+		{
+			name:  "valid",
+			token: func() string { return idp.Sign(t, idp.Claims(testNonce)) },
+			nonce: testNonce,
+			want:  &IDToken{Subject: "user-1", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe"},
+		},
+		{
+			name: "email_verified as a string",
+			token: func() string {
+				c := idp.Claims(testNonce)
+				c["email_verified"] = "true"
+				return idp.Sign(t, c)
+			},
+			nonce: testNonce,
+			want:  &IDToken{Subject: "user-1", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe"},
+		},
+		{
+			name: "unverified email",
+			token: func() string {
+				c := idp.Claims(testNonce)
+				c["email_verified"] = false
+				return idp.Sign(t, c)
+			},
+			nonce: testNonce,
+			want:  &IDToken{Subject: "user-1", Email: "jane@example.com", Name: "Jane Doe"},
+		},
+		{
+			name: "several audiences with us as authorized party",
+			token: func() string {
+				c := idp.Claims(testNonce)
+				c["aud"] = []string{oidctest.ClientID, "other-client"}
+				c["azp"] = oidctest.ClientID
+				return idp.Sign(t, c)
+			},
+			nonce: testNonce,
+			want:  &IDToken{Subject: "user-1", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe"},
+		},
+		{
+			name: "wrong audience",
+			token: func() string {
+				c := idp.Claims(testNonce)
+				c["aud"] = "other-client"
+				return idp.Sign(t, c)
+			},
+			nonce: testNonce,
+			err:   "invalid ID token",
+		},
+		{
+			name: "several audiences issued to another party",
+			token: func() string {
+				c := idp.Claims(testNonce)
+				c["aud"] = []string{oidctest.ClientID, "other-client"}
+				c["azp"] = "other-client"
+				return idp.Sign(t, c)
+			},
+			nonce: testNonce,
+			err:   "issued to another party",
+		},
+		{
+			name: "wrong issuer",
+			token: func() string {
+				c := idp.Claims(testNonce)
+				c["iss"] = "https://evil.example"
+				return idp.Sign(t, c)
+			},
+			nonce: testNonce,
+			err:   "invalid ID token",
+		},
+		{
+			name:  "nonce mismatch",
+			token: func() string { return idp.Sign(t, idp.Claims(testNonce)) },
+			nonce: "another-nonce",
+			err:   "nonce mismatch",
+		},
+		{
+			name:  "no nonce expected",
+			token: func() string { return idp.Sign(t, idp.Claims(testNonce)) },
+			nonce: "",
+			err:   "nonce mismatch",
+		},
+		{
+			name: "no nonce in token",
+			token: func() string {
+				c := idp.Claims(testNonce)
+				delete(c, "nonce")
+				return idp.Sign(t, c)
+			},
+			nonce: testNonce,
+			err:   "nonce mismatch",
+		},
+		{
+			name: "expired",
+			token: func() string {
+				c := idp.Claims(testNonce)
+				c["iat"] = time.Now().Add(-2 * time.Hour).Unix()
+				c["exp"] = time.Now().Add(-time.Hour).Unix()
+				return idp.Sign(t, c)
+			},
+			nonce: testNonce,
+			err:   "token is expired",
+		},
+		{
+			name: "no expiry",
+			token: func() string {
+				c := idp.Claims(testNonce)
+				delete(c, "exp")
+				return idp.Sign(t, c)
+			},
+			nonce: testNonce,
+			err:   "invalid ID token",
+		},
+		{
+			name: "issued in the future",
+			token: func() string {
+				c := idp.Claims(testNonce)
+				c["iat"] = time.Now().Add(time.Hour).Unix()
+				return idp.Sign(t, c)
+			},
+			nonce: testNonce,
+			err:   "invalid ID token",
+		},
+		{
+			name: "no subject",
+			token: func() string {
+				c := idp.Claims(testNonce)
+				delete(c, "sub")
+				return idp.Sign(t, c)
+			},
+			nonce: testNonce,
+			err:   "no subject",
+		},
+		{
+			name: "HS256 signed with the client secret",
+			token: func() string {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, idp.Claims(testNonce))
+				token.Header["kid"] = oidctest.KeyID
+				raw, err := token.SignedString([]byte(oidctest.ClientSecret))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return raw
+			},
+			nonce: testNonce,
+			err:   "signing method HS256 is invalid",
+		},
+		{
+			name: "unsigned",
+			token: func() string {
+				raw, err := jwt.NewWithClaims(jwt.SigningMethodNone, idp.Claims(testNonce)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return raw
+			},
+			nonce: testNonce,
+			err:   "invalid ID token",
+		},
+		{
+			name: "signed by another key",
+			token: func() string {
+				token := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.Claims(testNonce))
+				token.Header["kid"] = oidctest.KeyID
+				raw, err := token.SignedString(other)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return raw
+			},
+			nonce: testNonce,
+			err:   "invalid ID token",
+		},
+		{
+			name: "unknown key",
+			token: func() string {
+				token := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.Claims(testNonce))
+				token.Header["kid"] = "key-2"
+				raw, err := token.SignedString(other)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return raw
+			},
+			nonce: testNonce,
+			err:   "unknown signing key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testProvider(idp).VerifyIDToken(tt.token(), tt.nonce)
+			if tt.err != "" {
+				if err == nil {
+					t.Fatalf("VerifyIDToken() = %+v, want error containing %q", got, tt.err)
+				}
+				if !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("VerifyIDToken() error = %q, want it to contain %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyIDToken() error = %v", err)
+			}
+			if *got != *tt.want {
+				t.Errorf("VerifyIDToken() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenDiscoveryIssuerMismatch(t *testing.T) {
+	idp := oidctest.New(t)
+	p := NewOIDCProvider(OIDCConfig{
+		Issuer:   idp.Issuer() + "/tenant",
+		ClientID: oidctest.ClientID,
+	}, idp.Server.Client())
+
+	_, err := p.VerifyIDToken(idp.Sign(t, idp.Claims(testNonce)), testNonce)
+	if err == nil || !strings.Contains(err.Error(), "discovery") {
+		t.Fatalf("VerifyIDToken() error = %v, want a discovery error", err)
+	}
+}
@@ -0,0 +1,113 @@
+// Package oidctest runs an OpenID Connect provider for tests of the relying
+// party and the sign-in flows built on it.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	ClientID     = "job-tracker"
+	ClientSecret = "client-secret"
+	KeyID        = "key-1"
+)
+
+// Provider serves discovery, its signing keys, and a token endpoint that
+// answers every code with the ID token last set with SetIDToken.
+type Provider struct {
+	Server *httptest.Server
+	Key    *rsa.PrivateKey
+
+	mu      sync.Mutex
+	idToken string
+}
+
+// New starts a provider, stopped when the test ends.
+func New(t testing.TB) *Provider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Provider{Key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.Issuer(),
+			"authorization_endpoint": p.Issuer() + "/authorize",
+			"token_endpoint":         p.Issuer() + "/token",
+			"jwks_uri":               p.Issuer() + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		pub := p.Key.PublicKey
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": KeyID,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]string{"id_token": p.idToken})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Server.Close)
+	return p
+}
+
+func (p *Provider) Issuer() string {
+	return p.Server.URL
+}
+
+// Claims returns the claims of a valid ID token for ClientID, answering a
+// sign-in that sent nonce.
+func (p *Provider) Claims(nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            p.Issuer(),
+		"aud":            ClientID,
+		"sub":            "user-1",
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          nonce,
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"name":           "Jane Doe",
+	}
+}
+
+// Sign returns claims as an ID token signed with the provider's key.
+func (p *Provider) Sign(t testing.TB, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = KeyID
+	raw, err := token.SignedString(p.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// SetIDToken sets the ID token the token endpoint answers with.
+func (p *Provider) SetIDToken(raw string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idToken = raw
+}
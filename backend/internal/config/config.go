@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -18,7 +19,94 @@ type Config struct {
 	MCPServerURL     string
 	JWTSecret        string
 	JWTExpiration    time.Duration
+	SeedUserEmail    string // first admin, created on boot if missing
 	SeedUserPassword string
+	PublicBaseURL    string
+
+	// RefreshTokenExpiration is how long a session lasts without being
+	// refreshed
+	RefreshTokenExpiration time.Duration
+
+	// AppBaseURL is the frontend's address, used in links sent by email
+	AppBaseURL string
+	// RequireVerifiedEmail refuses sign-in until the email is verified
+	RequireVerifiedEmail bool
+
+	// Requests allowed per minute: to the sign-in and email-sending auth
+	// endpoints per client address and per account email, and to job search
+	// per user. 0 disables a limit.
+	AuthRateLimitPerMinute    int
+	AccountRateLimitPerMinute int
+	SearchRateLimitPerMinute  int
+	// TrustedProxies lists the addresses or CIDR ranges of reverse proxies
+	// whose X-Forwarded-For and X-Real-IP headers name the client
+	TrustedProxies string
+
+	// LoginLockoutThreshold wrong passwords in a row lock sign-in for
+	// LoginLockoutBase, doubling with each further one up to LoginLockoutMax.
+	// 0 disables lockout.
+	LoginLockoutThreshold int
+	LoginLockoutBase      time.Duration
+	LoginLockoutMax       time.Duration
+
+	// OIDCProviders are the identity providers users can sign in with,
+	// named in OIDC_PROVIDERS
+	OIDCProviders []OIDCProviderConfig
+
+	Mailer             string
+	MailFrom           string
+	SMTPHost           string
+	SMTPPort           int
+	SMTPUsername       string
+	SMTPPassword       string
+	SMTPTimeoutSeconds int
+
+	StorageBackend    string
+	StorageFSRoot     string
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3Prefix          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3UsePathStyle    bool
+
+	Scanner              string
+	ClamAVAddress        string
+	ClamAVTimeoutSeconds int
+
+	// PreferenceRetrainInterval is how often changed preference models are
+	// retrained; zero disables retraining in the background
+	PreferenceRetrainInterval time.Duration
+
+	// Search providers besides the MCP server; all but the static file are
+	// comma-separated lists
+	SearchFeeds            string
+	SearchGreenhouseBoards string
+	SearchLeverCompanies   string
+	SearchAshbyBoards      string
+	SearchWorkableAccounts string
+	SearchStaticFile       string
+	SearchTimeoutSeconds   int
+
+	// Base URLs replacing the public job board APIs, e.g. for a local
+	// fixture server
+	GreenhouseAPIURL string
+	LeverAPIURL      string
+	AshbyAPIURL      string
+	WorkableAPIURL   string
+
+	// WatchPollInterval is how often watched companies' boards are polled;
+	// zero disables polling in the background
+	WatchPollInterval time.Duration
+
+	// LivenessCheckInterval is how often saved postings are rechecked for
+	// being closed; zero disables checking in the background
+	LivenessCheckInterval time.Duration
+	LivenessUserAgent     string
+	// LivenessHostDelay is the least time between two requests to one host,
+	// raised by a longer robots.txt crawl-delay
+	LivenessHostDelay time.Duration
 }
 
 func Load() *Config {
@@ -33,11 +121,103 @@ func Load() *Config {
 		AllowedOrigins:   getEnv("ALLOWED_ORIGINS", "http://localhost:5173"),
 		MCPServerURL:     getEnv("MCP_SERVER_URL", "http://localhost:9423"),
 		JWTSecret:        getEnv("JWT_SECRET", ""),
-		JWTExpiration:    24 * time.Hour,
+		JWTExpiration:    time.Duration(getEnvInt("ACCESS_TOKEN_MINUTES", 15)) * time.Minute,
+		SeedUserEmail:    getEnv("SEED_USER_EMAIL", ""),
 		SeedUserPassword: getEnv("SEED_USER_PASSWORD", ""),
+		PublicBaseURL:    getEnv("PUBLIC_BASE_URL", "http://localhost:8080"),
+
+		RefreshTokenExpiration: time.Duration(getEnvInt("REFRESH_TOKEN_DAYS", 30)) * 24 * time.Hour,
+
+		AppBaseURL:           getEnv("APP_BASE_URL", "http://localhost:5173"),
+		RequireVerifiedEmail: getEnvBool("REQUIRE_EMAIL_VERIFICATION", false),
+
+		AuthRateLimitPerMinute:    getEnvInt("RATE_LIMIT_AUTH_PER_MINUTE", 20),
+		AccountRateLimitPerMinute: getEnvInt("RATE_LIMIT_ACCOUNT_PER_MINUTE", 5),
+		SearchRateLimitPerMinute:  getEnvInt("RATE_LIMIT_SEARCH_PER_MINUTE", 6),
+		TrustedProxies:            getEnv("TRUSTED_PROXIES", ""),
+
+		LoginLockoutThreshold: getEnvInt("LOGIN_LOCKOUT_THRESHOLD", 5),
+		LoginLockoutBase:      time.Duration(getEnvInt("LOGIN_LOCKOUT_SECONDS", 60)) * time.Second,
+		LoginLockoutMax:       time.Duration(getEnvInt("LOGIN_LOCKOUT_MAX_MINUTES", 60)) * time.Minute,
+
+		OIDCProviders: loadOIDCProviders(),
+
+		Mailer:             getEnv("MAILER", "log"),
+		MailFrom:           getEnv("MAIL_FROM", "Job Tracker <no-reply@localhost>"),
+		SMTPHost:           getEnv("SMTP_HOST", ""),
+		SMTPPort:           getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:       getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:       getEnv("SMTP_PASSWORD", ""),
+		SMTPTimeoutSeconds: getEnvInt("SMTP_TIMEOUT_SECONDS", 30),
+
+		StorageBackend:    getEnv("STORAGE_BACKEND", "database"),
+		StorageFSRoot:     getEnv("STORAGE_FS_ROOT", "data/attachments"),
+		S3Endpoint:        getEnv("S3_ENDPOINT", ""),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:          getEnv("S3_BUCKET", ""),
+		S3Prefix:          getEnv("S3_PREFIX", ""),
+		S3AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+		S3UsePathStyle:    getEnvBool("S3_USE_PATH_STYLE", false),
+
+		Scanner:              getEnv("SCANNER", "none"),
+		ClamAVAddress:        getEnv("CLAMAV_ADDRESS", "tcp://127.0.0.1:3310"),
+		ClamAVTimeoutSeconds: getEnvInt("CLAMAV_TIMEOUT_SECONDS", 60),
+
+		PreferenceRetrainInterval: time.Duration(getEnvInt("PREFERENCE_RETRAIN_MINUTES", 60)) * time.Minute,
+
+		SearchFeeds:            getEnv("SEARCH_FEEDS", ""),
+		SearchGreenhouseBoards: getEnv("SEARCH_GREENHOUSE_BOARDS", ""),
+		SearchLeverCompanies:   getEnv("SEARCH_LEVER_COMPANIES", ""),
+		SearchAshbyBoards:      getEnv("SEARCH_ASHBY_BOARDS", ""),
+		SearchWorkableAccounts: getEnv("SEARCH_WORKABLE_ACCOUNTS", ""),
+		SearchStaticFile:       getEnv("SEARCH_STATIC_FILE", ""),
+		SearchTimeoutSeconds:   getEnvInt("SEARCH_TIMEOUT_SECONDS", 120),
+
+		GreenhouseAPIURL: getEnv("GREENHOUSE_API_URL", ""),
+		LeverAPIURL:      getEnv("LEVER_API_URL", ""),
+		AshbyAPIURL:      getEnv("ASHBY_API_URL", ""),
+		WorkableAPIURL:   getEnv("WORKABLE_API_URL", ""),
+
+		WatchPollInterval: time.Duration(getEnvInt("WATCH_POLL_MINUTES", 60)) * time.Minute,
+
+		LivenessCheckInterval: time.Duration(getEnvInt("LIVENESS_CHECK_HOURS", 24)) * time.Hour,
+		LivenessUserAgent:     getEnv("LIVENESS_USER_AGENT", "JobTrackerBot/1.0"),
+		LivenessHostDelay:     time.Duration(getEnvInt("LIVENESS_HOST_DELAY_SECONDS", 5)) * time.Second,
 	}
 }
 
+// OIDCProviderConfig is an OpenID Connect provider, configured by the
+// OIDC_<NAME>_* variables of its name.
+type OIDCProviderConfig struct {
+	Name         string
+	DisplayName  string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       string
+}
+
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         name,
+			DisplayName:  getEnv(prefix+"DISPLAY_NAME", name),
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       getEnv(prefix+"SCOPES", "openid,email,profile"),
+		})
+	}
+	return providers
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -53,3 +233,12 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"fmt"
+
+	"job-tracker-backend/internal/config"
+	"job-tracker-backend/internal/domain"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Open connects to the PostgreSQL database described by cfg.
+func Open(cfg *config.Config) (*gorm.DB, error) {
+	dsn := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBSSLMode,
+	)
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+}
+
+// Migrate creates or updates the tables for every persisted model.
+func Migrate(db *gorm.DB) error {
+	err := db.AutoMigrate(
+		&domain.User{},
+		&domain.Session{},
+		&domain.AccountToken{},
+		&domain.LoginAttempt{},
+		&domain.RecoveryCode{},
+		&domain.ExternalIdentity{},
+		&domain.APIToken{},
+		&domain.Job{},
+		&domain.Attachment{},
+		&domain.StatusChange{},
+		&domain.Blob{},
+		&domain.Document{},
+		&domain.DocumentVersion{},
+		&domain.RankingProfile{},
+		&domain.PreferenceModel{},
+		&domain.CompanyWatch{},
+		&domain.WatchedPosting{},
+		&domain.Notification{},
+	)
+	if err != nil {
+		return err
+	}
+	return createSearchIndexes(db)
+}
+
+// createSearchIndexes adds the GIN indexes behind full-text job search. The
+// expressions must match the ones the job repository queries with.
+func createSearchIndexes(db *gorm.DB) error {
+	statements := []string{
+		`CREATE INDEX IF NOT EXISTS idx_jobs_search ON jobs USING GIN (to_tsvector('english',
+			coalesce(job_title, '') || ' ' || coalesce(company_name, '') || ' ' || coalesce(location, '') || ' ' ||
+			coalesce(description, '') || ' ' || coalesce(notes, '')))`,
+		`CREATE INDEX IF NOT EXISTS idx_attachments_text_search ON attachments USING GIN (to_tsvector('english', coalesce(extracted_text, '')))`,
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("create search index: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Scopes of personal API tokens. Each grants a set of endpoints; signed-in
+// sessions have them all.
+const (
+	ScopeJobsRead    = "jobs:read"
+	ScopeJobsWrite   = "jobs:write"
+	ScopeAttachments = "attachments"
+	ScopeSearch      = "search"
+)
+
+// APITokenScopes lists every scope a token can have.
+var APITokenScopes = []string{ScopeJobsRead, ScopeJobsWrite, ScopeAttachments, ScopeSearch}
+
+// APIToken is a personal access token for scripts. It is shown once when
+// created and stored hashed; Prefix identifies it in listings.
+type APIToken struct {
+	ID         string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID     string     `json:"-" gorm:"type:varchar(36);index;not null"`
+	Name       string     `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(20)"`
+	TokenHash  string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	Scopes     StringList `json:"scopes" gorm:"type:jsonb"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip" gorm:"type:varchar(64)"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (t *APIToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// HasScope reports whether the token grants scope.
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type APITokenCreateInput struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// ExpiresInDays is the token's lifetime; 0 means it never expires
+	ExpiresInDays int `json:"expires_in_days"`
+}
+
+// APITokenCreated is a new token with its secret, which is never shown
+// again.
+type APITokenCreated struct {
+	APIToken
+	Token string `json:"token"`
+}
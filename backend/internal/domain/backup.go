@@ -0,0 +1,160 @@
+package domain
+
+import "time"
+
+// BackupFormatVersion is bumped whenever the archive layout changes in a way
+// older restore code cannot read.
+const BackupFormatVersion = 2
+
+// BackupManifest is stored as manifest.json at the root of a backup archive.
+// File contents live next to it at the paths in BackupAttachment.Path and
+// BackupDocumentVersion.Path.
+//
+// Archive layout (version 2):
+//
+//	manifest.json
+//	attachments/<attachment id>   raw file bytes
+//	documents/<version id>        raw bytes of a document version
+//
+// Attachments linking to a document version have no file of their own.
+// Version 1 archives only hold jobs, attachments and status history.
+type BackupManifest struct {
+	FormatVersion    int                     `json:"format_version"`
+	CreatedAt        time.Time               `json:"created_at"`
+	Owner            BackupOwner             `json:"owner"`
+	Jobs             []BackupJob             `json:"jobs"`
+	Attachments      []BackupAttachment      `json:"attachments"`
+	StatusHistory    []StatusChange          `json:"status_history"`
+	Documents        []BackupDocument        `json:"documents"`
+	DocumentVersions []BackupDocumentVersion `json:"document_versions"`
+	RankingProfile   *RankingProfile         `json:"ranking_profile,omitempty"`
+	PreferenceModel  *BackupPreferenceModel  `json:"preference_model,omitempty"`
+	Watches          []BackupWatch           `json:"watches"`
+	Notifications    []Notification          `json:"notifications"`
+}
+
+type BackupOwner struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+// BackupJob is a job without its attachments, which are listed separately.
+type BackupJob struct {
+	ID           string     `json:"id"`
+	JobTitle     string     `json:"job_title"`
+	CompanyName  string     `json:"company_name"`
+	Location     string     `json:"location"`
+	JobURL       string     `json:"job_url"`
+	Description  string     `json:"description"`
+	Salary       string     `json:"salary"`
+	JobType      string     `json:"job_type"`
+	IsRemote     bool       `json:"is_remote"`
+	EasyApply    bool       `json:"easy_apply"`
+	ViaRecruiter bool       `json:"via_recruiter"`
+	Source       string     `json:"source"`
+	Status       string     `json:"status"`
+	Notes        string     `json:"notes"`
+	InterviewAt  *time.Time `json:"interview_at"`
+	FollowUpAt   *time.Time `json:"follow_up_at"`
+	DeadlineAt   *time.Time `json:"deadline_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	// DescriptionRaw is the description as originally received; absent
+	// from archives written before descriptions were sanitized
+	DescriptionRaw string `json:"description_raw,omitempty"`
+}
+
+type BackupAttachment struct {
+	ID        string    `json:"id"`
+	JobID     string    `json:"job_id"`
+	FileName  string    `json:"file_name"`
+	FileType  string    `json:"file_type"`
+	MIMEType  string    `json:"mime_type"`
+	FileSize  int64     `json:"file_size"`
+	Path      string    `json:"path,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// DocumentVersionID is the linked document version, whose file is used
+	// instead of one at Path
+	DocumentVersionID *string `json:"document_version_id,omitempty"`
+}
+
+type BackupDocument struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	FileType  string    `json:"file_type"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type BackupDocumentVersion struct {
+	ID          string    `json:"id"`
+	DocumentID  string    `json:"document_id"`
+	Version     int       `json:"version"`
+	Label       string    `json:"label"`
+	FileName    string    `json:"file_name"`
+	MIMEType    string    `json:"mime_type"`
+	FileSize    int64     `json:"file_size"`
+	ContentHash string    `json:"content_hash"`
+	Path        string    `json:"path"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// BackupPreferenceModel is a PreferenceModel including the serialized model.
+type BackupPreferenceModel struct {
+	Model     string    `json:"model"`
+	Liked     int       `json:"liked"`
+	Disliked  int       `json:"disliked"`
+	TrainedAt time.Time `json:"trained_at"`
+}
+
+// BackupWatch is a company watch with the postings it has already seen, so
+// a restored watch doesn't announce them again.
+type BackupWatch struct {
+	ID           string              `json:"id"`
+	Company      string              `json:"company"`
+	ATS          string              `json:"ats"`
+	BoardID      string              `json:"board_id"`
+	AutoSave     bool                `json:"auto_save"`
+	LastPolledAt *time.Time          `json:"last_polled_at"`
+	LastError    string              `json:"last_error"`
+	OpenPostings int                 `json:"open_postings"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+	Seen         []BackupSeenPosting `json:"seen"`
+}
+
+type BackupSeenPosting struct {
+	Key         string    `json:"key"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+}
+
+type RestoreResult struct {
+	JobsCreated             int  `json:"jobs_created"`
+	JobsSkipped             int  `json:"jobs_skipped"`
+	AttachmentsCreated      int  `json:"attachments_created"`
+	StatusChangesCreated    int  `json:"status_changes_created"`
+	DocumentsCreated        int  `json:"documents_created"`
+	DocumentVersionsCreated int  `json:"document_versions_created"`
+	DocumentVersionsSkipped int  `json:"document_versions_skipped"`
+	WatchesCreated          int  `json:"watches_created"`
+	WatchesSkipped          int  `json:"watches_skipped"`
+	NotificationsCreated    int  `json:"notifications_created"`
+	RankingProfileRestored  bool `json:"ranking_profile_restored"`
+	PreferenceModelRestored bool `json:"preference_model_restored"`
+}
+
+// RestoreSet is everything a restore creates, inserted together.
+type RestoreSet struct {
+	Documents        []Document
+	DocumentVersions []DocumentVersion
+	Jobs             []Job
+	Attachments      []Attachment
+	StatusChanges    []StatusChange
+	Watches          []CompanyWatch
+	WatchedPostings  []WatchedPosting
+	Notifications    []Notification
+	// RankingProfile and PreferenceModel are only set when the account has
+	// none of its own
+	RankingProfile  *RankingProfile
+	PreferenceModel *PreferenceModel
+}
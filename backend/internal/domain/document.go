@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Document is a named entry in a user's document library, e.g. "Backend
+// resume". Its contents live in one or more versions.
+type Document struct {
+	ID        string            `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID    string            `json:"user_id" gorm:"type:varchar(36);index;not null"`
+	Name      string            `json:"name" gorm:"type:varchar(255);not null"`
+	FileType  string            `json:"file_type" gorm:"type:varchar(50)"` // "resume" | "cover_letter"
+	Versions  []DocumentVersion `json:"versions" gorm:"foreignKey:DocumentID"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+func (d *Document) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// DocumentVersion is one immutable upload of a document. Versions are
+// deduplicated per user by ContentHash, and their contents are stored once
+// no matter how many jobs link to them.
+type DocumentVersion struct {
+	ID             string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	DocumentID     string    `json:"document_id" gorm:"type:varchar(36);index;not null"`
+	UserID         string    `json:"user_id" gorm:"type:varchar(36);index;not null"`
+	Version        int       `json:"version" gorm:"not null"`
+	Label          string    `json:"label" gorm:"type:varchar(255)"`
+	FileName       string    `json:"file_name" gorm:"type:varchar(255);not null"`
+	MIMEType       string    `json:"mime_type" gorm:"type:varchar(100)"`
+	FileSize       int64     `json:"file_size"`
+	ContentHash    string    `json:"content_hash" gorm:"type:varchar(64);index"`
+	StorageBackend string    `json:"-" gorm:"type:varchar(20);not null;default:'database'"`
+	ExtractedText  string    `json:"-" gorm:"type:text"`
+	LinkCount      int64     `json:"link_count" gorm:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (v *DocumentVersion) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == "" {
+		v.ID = uuid.New().String()
+	}
+	return nil
+}
+
+type LinkDocumentInput struct {
+	DocumentVersionID string `json:"document_version_id"`
+}
+
+// DocumentVersionUsage is a job a document version was attached to.
+type DocumentVersionUsage struct {
+	AttachmentID string    `json:"attachment_id"`
+	JobID        string    `json:"job_id"`
+	JobTitle     string    `json:"job_title"`
+	CompanyName  string    `json:"company_name"`
+	Status       string    `json:"status"`
+	AttachedAt   time.Time `json:"attached_at"`
+}
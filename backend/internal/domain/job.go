@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,6 +28,10 @@ type Job struct {
 	Location    string       `json:"location" gorm:"type:varchar(500)"`
 	JobURL      string       `json:"job_url" gorm:"type:varchar(2000)"`
 	Description string       `json:"description" gorm:"type:text"`
+	// DescriptionRaw is the description as received; Description holds it
+	// as sanitized HTML. DescriptionFormat is the format it was received in.
+	DescriptionRaw    string `json:"-" gorm:"type:text"`
+	DescriptionFormat string `json:"description_format" gorm:"type:varchar(20)"`
 	Salary      string       `json:"salary" gorm:"type:varchar(200)"`
 	JobType     string       `json:"job_type" gorm:"type:varchar(100)"`
 	IsRemote     bool         `json:"is_remote" gorm:"default:false"`
@@ -33,6 +40,15 @@ type Job struct {
 	Source      string       `json:"source" gorm:"type:varchar(100)"`
 	Status      string       `json:"status" gorm:"default:'new';type:varchar(50);index"`
 	Notes       string       `json:"notes" gorm:"type:text"`
+	InterviewAt *time.Time   `json:"interview_at"`
+	FollowUpAt  *time.Time   `json:"follow_up_at"`
+	DeadlineAt  *time.Time   `json:"deadline_at"`
+	Requirements JobRequirements `json:"requirements" gorm:"embedded;embeddedPrefix:req_"`
+	// LastCheckedAt is when the posting at JobURL was last fetched to see if
+	// it is still open, and ClosedAt when it was found closed
+	LastCheckedAt   *time.Time `json:"last_checked_at"`
+	ClosedAt        *time.Time `json:"closed_at" gorm:"index"`
+	LastCheckResult string     `json:"last_check_result" gorm:"type:varchar(500)"`
 	Attachments []Attachment `json:"attachments" gorm:"foreignKey:JobID"`
 	CreatedAt   time.Time    `json:"created_at"`
 	UpdatedAt   time.Time    `json:"updated"`
@@ -48,6 +64,54 @@ func (j *Job) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// JobRequirements are what a posting asks of a candidate, extracted from its
+// title and description. Empty fields mean the posting doesn't say.
+type JobRequirements struct {
+	Skills             StringList `json:"skills" gorm:"type:jsonb"`
+	MinYearsExperience *int       `json:"min_years_experience"`
+	// Seniority is intern, junior, mid, senior, lead, staff or principal
+	Seniority string `json:"seniority" gorm:"type:varchar(20);index"`
+	// VisaSponsorship is "available" or "unavailable"
+	VisaSponsorship     string `json:"visa_sponsorship" gorm:"type:varchar(20)"`
+	CitizenshipRequired bool   `json:"citizenship_required" gorm:"default:false"`
+	// Clearance is public_trust, secret, top_secret, ts_sci or required
+	Clearance string `json:"clearance" gorm:"type:varchar(20)"`
+	// Education is the lowest accepted degree: high_school, associate,
+	// bachelor, master or doctorate
+	Education  string     `json:"education" gorm:"type:varchar(20)"`
+	AnalyzedAt *time.Time `json:"analyzed_at"`
+}
+
+// StringList is a list of strings stored as a JSON array.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		l = StringList{}
+	}
+	b, err := json.Marshal([]string(l))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (l *StringList) Scan(src any) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", src)
+	}
+	return json.Unmarshal(b, (*[]string)(l))
+}
+
 type JobCreateInput struct {
 	JobTitle    string `json:"job_title" binding:"required"`
 	CompanyName string `json:"company_name"`
@@ -61,6 +125,9 @@ type JobCreateInput struct {
 	ViaRecruiter *bool `json:"via_recruiter"`
 	Source       string `json:"source"`
 	Notes        string `json:"notes"`
+	InterviewAt  *time.Time `json:"interview_at"`
+	FollowUpAt   *time.Time `json:"follow_up_at"`
+	DeadlineAt   *time.Time `json:"deadline_at"`
 }
 
 type JobUpdateInput struct {
@@ -77,6 +144,31 @@ type JobUpdateInput struct {
 	Source       string `json:"source"`
 	Status       string `json:"status"`
 	Notes        string `json:"notes"`
+	// Dates left out stay as they are; null clears them
+	InterviewAt NullableTime `json:"interview_at"`
+	FollowUpAt  NullableTime `json:"follow_up_at"`
+	DeadlineAt  NullableTime `json:"deadline_at"`
+}
+
+// NullableTime is a time in an update that tells a missing value, which
+// leaves the field unchanged, from null, which clears it.
+type NullableTime struct {
+	Set  bool
+	Time *time.Time
+}
+
+func (t *NullableTime) UnmarshalJSON(b []byte) error {
+	t.Set = true
+	if string(b) == "null" {
+		t.Time = nil
+		return nil
+	}
+	var v time.Time
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	t.Time = &v
+	return nil
 }
 
 type JobStatusUpdate struct {
@@ -86,7 +178,32 @@ type JobStatusUpdate struct {
 type JobFilter struct {
 	Status string `query:"status"`
 	Source string `query:"source"`
-	UserID string
+	Query  string `query:"q"` // full-text search over the job and its attachments
+	// MaxYears excludes jobs asking for more years of experience
+	MaxYears  *int     `query:"max_years"`
+	Seniority []string `query:"seniority"` // any of
+	Skills    []string `query:"skills"`    // all of
+	// Exclude drops jobs by requirement: citizenship, clearance, no_sponsorship
+	Exclude []string `query:"exclude"`
+	UserID  string
+
+	// Closed keeps only jobs whose posting was (true) or wasn't (false)
+	// found closed
+	Closed *bool `query:"closed"`
+}
+
+// Values of JobFilter.Exclude.
+const (
+	ExcludeCitizenship   = "citizenship"
+	ExcludeClearance     = "clearance"
+	ExcludeNoSponsorship = "no_sponsorship"
+)
+
+// ExportJob is a job as written by the export endpoint, optionally carrying
+// its status history.
+type ExportJob struct {
+	Job
+	StatusHistory []StatusChange `json:"status_history,omitempty"`
 }
 
 // Attachment represents a file attachment (resume/cover letter) for a job
@@ -99,6 +216,24 @@ type Attachment struct {
 	Data      []byte    `json:"-" gorm:"type:bytea"` // Don't serialize to JSON
 	FileSize  int64     `json:"file_size"`
 	CreatedAt time.Time `json:"created_at"`
+	// StorageBackend names the storage.BlobStore holding the file contents
+	StorageBackend string `json:"-" gorm:"type:varchar(20);not null;default:'database'"`
+	// ContentHash is the hex SHA-256 of the contents, used as the download ETag
+	ContentHash string `json:"content_hash" gorm:"type:varchar(64);index"`
+	// DocumentVersionID is set when the attachment links to a library
+	// document version instead of holding its own copy of the file
+	DocumentVersionID *string `json:"document_version_id" gorm:"type:varchar(36);index"`
+	// ExtractedText is the plain text of PDF and DOCX files, used by search
+	ExtractedText   string     `json:"-" gorm:"type:text"`
+	TextExtractedAt *time.Time `json:"-"`
+}
+
+// BlobKey is the storage key of the attachment's contents.
+func (a *Attachment) BlobKey() string {
+	if a.DocumentVersionID != nil {
+		return *a.DocumentVersionID
+	}
+	return a.ID
 }
 
 func (a *Attachment) BeforeCreate(tx *gorm.DB) error {
@@ -107,3 +242,75 @@ func (a *Attachment) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// StatusChange records a transition of a job's status
+type StatusChange struct {
+	ID         string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	JobID      string    `json:"job_id" gorm:"type:varchar(36);index;not null"`
+	UserID     string    `json:"user_id" gorm:"type:varchar(36);index;not null"`
+	FromStatus string    `json:"from_status" gorm:"type:varchar(50)"`
+	ToStatus   string    `json:"to_status" gorm:"type:varchar(50);not null"`
+	ChangedAt  time.Time `json:"changed_at" gorm:"index"`
+}
+
+func (c *StatusChange) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	if c.ChangedAt.IsZero() {
+		c.ChangedAt = time.Now()
+	}
+	return nil
+}
+
+// ImportRow is one parsed row of an import file and what happened to it
+type ImportRow struct {
+	Row       int    `json:"row"`
+	Job       *Job   `json:"job,omitempty"`
+	Duplicate bool   `json:"duplicate"`
+	Created   bool   `json:"created"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ImportResult struct {
+	Mode       string            `json:"mode"`
+	Mapping    map[string]string `json:"mapping"`
+	Total      int               `json:"total"`
+	Valid      int               `json:"valid"`
+	Duplicates int               `json:"duplicates"`
+	Created    int               `json:"created"`
+	Rows       []ImportRow       `json:"rows"`
+}
+
+// JobFromURLInput asks to import the posting at URL. Mode is "preview" (the
+// default), which only extracts the job, or "commit", which saves it.
+type JobFromURLInput struct {
+	URL  string `json:"url"`
+	Mode string `json:"mode"`
+}
+
+// JobFromURLResult is the job read from a posting's page. Extractors names
+// the sources its fields came from; Job is set once it is saved.
+type JobFromURLResult struct {
+	Mode       string          `json:"mode"`
+	Input      *JobCreateInput `json:"input"`
+	Extractors []string        `json:"extractors"`
+	Duplicate  bool            `json:"duplicate"`
+	Job        *Job            `json:"job,omitempty"`
+}
+
+// AttachmentText is the text extracted from an attachment
+type AttachmentText struct {
+	AttachmentID string     `json:"attachment_id"`
+	FileName     string     `json:"file_name"`
+	Text         string     `json:"text"`
+	ExtractedAt  *time.Time `json:"extracted_at"`
+}
+
+// Blob holds attachment and document contents for the database storage
+// backend.
+type Blob struct {
+	Key       string `gorm:"primaryKey;type:varchar(36)"`
+	Data      []byte `gorm:"type:bytea"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// PreferenceModel is a user's preference model, learned from the jobs they
+// shortlisted, applied to or rejected and retrained as they decide on more.
+type PreferenceModel struct {
+	UserID string `json:"-" gorm:"primaryKey;type:varchar(36)"`
+	// Model is the serialized model, empty while there are too few decisions
+	// to train one
+	Model string `json:"-" gorm:"type:text"`
+	// Liked and Disliked count the jobs it was trained from
+	Liked     int       `json:"liked"`
+	Disliked  int       `json:"disliked"`
+	TrainedAt time.Time `json:"trained_at"`
+}
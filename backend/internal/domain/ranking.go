@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Remote preferences of a RankingProfile.
+const (
+	RemoteAny       = ""
+	RemotePreferred = "preferred"
+	RemoteRequired  = "required"
+)
+
+// RankingProfile describes what makes a search result relevant to a user.
+// Search results are scored against it and sorted best first.
+type RankingProfile struct {
+	UserID string `json:"-" gorm:"primaryKey;type:varchar(36)"`
+	// Keywords add their weight when found in a result (double in the title)
+	Keywords KeywordWeights `json:"keywords" gorm:"type:jsonb"`
+	// ExcludedKeywords mark results the user doesn't want to see
+	ExcludedKeywords   StringList `json:"excluded_keywords" gorm:"type:jsonb"`
+	PreferredCompanies StringList `json:"preferred_companies" gorm:"type:jsonb"`
+	// MinSalary is the annual salary floor, 0 for none
+	MinSalary        int    `json:"min_salary"`
+	RemotePreference string `json:"remote_preference" gorm:"type:varchar(20)"`
+	// MaxAgeDays penalizes postings older than this many days, 0 for none
+	MaxAgeDays int `json:"max_age_days"`
+	// MinScore drops results scoring below it when set
+	MinScore  *int      `json:"min_score"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// KeywordWeight is a preferred keyword and how much it counts.
+type KeywordWeight struct {
+	Term   string `json:"term"`
+	Weight int    `json:"weight"`
+}
+
+// KeywordWeights is a list of weighted keywords stored as a JSON array.
+type KeywordWeights []KeywordWeight
+
+func (k KeywordWeights) Value() (driver.Value, error) {
+	if k == nil {
+		k = KeywordWeights{}
+	}
+	b, err := json.Marshal([]KeywordWeight(k))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (k *KeywordWeights) Scan(src any) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		*k = nil
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into KeywordWeights", src)
+	}
+	return json.Unmarshal(b, (*[]KeywordWeight)(k))
+}
+
+type RankingProfileInput struct {
+	Keywords           []KeywordWeight `json:"keywords"`
+	ExcludedKeywords   []string        `json:"excluded_keywords"`
+	PreferredCompanies []string        `json:"preferred_companies"`
+	MinSalary          int             `json:"min_salary"`
+	RemotePreference   string          `json:"remote_preference"`
+	MaxAgeDays         int             `json:"max_age_days"`
+	MinScore           *int            `json:"min_score"`
+}
+
+// RankReason explains part of a search result's relevance score.
+type RankReason struct {
+	Points int    `json:"points"`
+	Reason string `json:"reason"`
+}
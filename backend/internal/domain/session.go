@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session is a signed-in device. Its refresh token, stored hashed, buys new
+// short-lived access tokens and is replaced by a new one on every use.
+// Revoking a session deletes it.
+type Session struct {
+	ID               string `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID           string `json:"-" gorm:"type:varchar(36);index;not null"`
+	RefreshTokenHash string `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	// PreviousTokenHash is the refresh token the last rotation replaced.
+	// Seeing it again means the token was copied, and ends the session.
+	PreviousTokenHash string    `json:"-" gorm:"type:varchar(64);index"`
+	Device            string    `json:"device" gorm:"type:varchar(100)"`
+	UserAgent         string    `json:"user_agent" gorm:"type:varchar(500)"`
+	IP                string    `json:"ip" gorm:"type:varchar(64)"`
+	CreatedAt         time.Time `json:"created_at"`
+	LastUsedAt        time.Time `json:"last_used_at"`
+	ExpiresAt         time.Time `json:"expires_at" gorm:"index"`
+
+	// Current marks the session of the request listing sessions
+	Current bool `json:"current" gorm:"-"`
+}
+
+func (s *Session) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// Purposes of account tokens.
+const (
+	TokenPasswordReset     = "password_reset"
+	TokenEmailVerification = "email_verification"
+	// TokenLoginChallenge stands for a correct password while sign-in waits
+	// for the second factor
+	TokenLoginChallenge = "login_challenge"
+	// TokenSSOLogin hands a sign-in through an identity provider over to
+	// the frontend
+	TokenSSOLogin = "sso_login"
+)
+
+// AccountToken is a single-use secret emailed to a user to reset their
+// password or verify their email. Only its hash is stored.
+type AccountToken struct {
+	ID        string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID    string     `json:"user_id" gorm:"type:varchar(36);index;not null"`
+	Purpose   string     `json:"purpose" gorm:"type:varchar(32);not null"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (t *AccountToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// RecoveryCode is a one-time code that stands in for an authenticator app
+// code, for when the device is lost. Only its hash is stored.
+type RecoveryCode struct {
+	ID        string     `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID    string     `json:"-" gorm:"type:varchar(36);index;not null"`
+	CodeHash  string     `json:"-" gorm:"type:varchar(64);not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (c *RecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+type TwoFactorLoginInput struct {
+	ChallengeToken string `json:"challenge_token"`
+	// Code is an authenticator app code or a recovery code
+	Code string `json:"code"`
+}
+
+type TwoFactorCodeInput struct {
+	Code string `json:"code"`
+}
+
+// PasswordInput confirms a sensitive change with the current password.
+type PasswordInput struct {
+	Password string `json:"password"`
+}
+
+// TwoFactorSetup is a new authenticator secret, to be added to an app by
+// scanning URI as a QR code or typing Secret, then confirmed with a code.
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// RecoveryCodes are shown once, when generated.
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// ExternalIdentity links a user to their account at an OpenID Connect
+// provider, identified by the provider's subject.
+type ExternalIdentity struct {
+	ID          string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID      string    `json:"-" gorm:"type:varchar(36);index;not null"`
+	Provider    string    `json:"provider" gorm:"type:varchar(64);not null;uniqueIndex:idx_external_identity"`
+	Subject     string    `json:"-" gorm:"type:varchar(255);not null;uniqueIndex:idx_external_identity"`
+	Email       string    `json:"email" gorm:"type:varchar(255)"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+func (e *ExternalIdentity) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// SSOProvider is an identity provider offered on the sign-in page.
+type SSOProvider struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// SSOExchangeInput redeems the code the frontend receives after signing in
+// with an identity provider.
+type SSOExchangeInput struct {
+	Code string `json:"code"`
+}
+
+// Outcomes of a sign-in attempt.
+const (
+	LoginSucceeded   = "success"
+	LoginBadPassword = "bad_password"
+	LoginUnknownUser = "unknown_email"
+	LoginLocked      = "locked"
+	LoginUnverified  = "unverified"
+	LoginChallenged  = "two_factor_required"
+	LoginBadCode     = "bad_code"
+	LoginSSO         = "sso"
+	LoginDisabled    = "disabled"
+	LoginMustReset   = "password_reset_required"
+)
+
+// LoginAttempt records a sign-in attempt for auditing. UserID is empty when
+// the email has no account.
+type LoginAttempt struct {
+	ID        string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID    string    `json:"-" gorm:"type:varchar(36);index"`
+	Email     string    `json:"email" gorm:"type:varchar(255);index"`
+	IP        string    `json:"ip" gorm:"type:varchar(64)"`
+	UserAgent string    `json:"user_agent" gorm:"type:varchar(500)"`
+	Outcome   string    `json:"outcome" gorm:"type:varchar(32);not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+func (a *LoginAttempt) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// ClientInfo describes the device a request came from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
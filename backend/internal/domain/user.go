@@ -7,18 +7,52 @@ import (
 	"gorm.io/gorm"
 )
 
+// Roles of users. Admins can manage every account at /api/admin.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID           string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
-	Email        string    `json:"email" gorm:"uniqueIndex;not null;type:varchar(255)"`
-	PasswordHash string    `json:"-" gorm:"not null;type:varchar(255)"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                string    `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	Email             string    `json:"email" gorm:"uniqueIndex;not null;type:varchar(255)"`
+	PasswordHash      string    `json:"-" gorm:"not null;type:varchar(255)"`
+	CalendarTokenHash string    `json:"-" gorm:"type:varchar(64);index"` // hash of the secret in the public .ics feed URL
+	Role              string    `json:"role" gorm:"type:varchar(20);not null;default:'user'"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+
+	// DisabledAt is when an admin disabled the account; a disabled account
+	// can't sign in or use its API tokens
+	DisabledAt *time.Time `json:"disabled_at"`
+	// PasswordResetRequired is set by an admin to make the user choose a new
+	// password, through a reset link, before signing in with one again
+	PasswordResetRequired bool `json:"password_reset_required" gorm:"not null;default:false"`
+
+	// EmailVerifiedAt is when the user proved they own Email
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// FailedLogins counts wrong passwords since the last successful sign-in;
+	// past a threshold each one locks sign-in until LockedUntil
+	FailedLogins int        `json:"-" gorm:"not null;default:0"`
+	LockedUntil  *time.Time `json:"-"`
+
+	// TOTPSecret is the authenticator app secret. Two-factor sign-in is on
+	// once TOTPEnabledAt is set; before that the secret awaits confirmation.
+	// TOTPLastStep is the time step of the last code accepted, so a code
+	// can't be replayed.
+	TOTPSecret    string     `json:"-" gorm:"type:varchar(64)"`
+	TOTPEnabledAt *time.Time `json:"-"`
+	TOTPLastStep  int64      `json:"-" gorm:"not null;default:0"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == "" {
 		u.ID = uuid.New().String()
 	}
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
 	return nil
 }
 
@@ -37,12 +71,71 @@ type ChangePasswordInput struct {
 	NewPassword     string `json:"new_password"`
 }
 
+// AuthResponse is a signed-in session. Token and RefreshToken are empty
+// when registration waits for the email to be verified, and when sign-in
+// waits for a second factor: then ChallengeToken is to be sent with the code
+// to POST /api/auth/login/2fa.
 type AuthResponse struct {
-	Token string   `json:"token"`
+	Token string   `json:"token,omitempty"`
 	User  AuthUser `json:"user"`
+
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+
+	// ExpiresAt is when Token expires; RefreshToken exchanges for a new one
+	// at POST /api/auth/refresh until the session ends
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	RefreshToken string     `json:"refresh_token,omitempty"`
 }
 
 type AuthUser struct {
-	ID    string `json:"id"`
+	ID               string `json:"id"`
+	Email            string `json:"email"`
+	EmailVerified    bool   `json:"email_verified"`
+	TwoFactorEnabled bool   `json:"two_factor_enabled"`
+	Role             string `json:"role"`
+}
+
+type ForgotPasswordInput struct {
 	Email string `json:"email"`
 }
+
+type ResetPasswordInput struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type VerifyEmailInput struct {
+	Token string `json:"token"`
+}
+
+// AdminUser is an account as admins see it, with what it holds.
+type AdminUser struct {
+	User
+	TwoFactorEnabled bool         `json:"two_factor_enabled"`
+	Jobs             int64        `json:"jobs"`
+	Storage          StorageUsage `json:"storage"`
+}
+
+// StorageUsage is how much file storage an account uses. Attachments count
+// only files uploaded to a job; ones linked from the document library are
+// counted once, as document versions.
+type StorageUsage struct {
+	Attachments      int64 `json:"attachments"`
+	AttachmentBytes  int64 `json:"attachment_bytes"`
+	DocumentVersions int64 `json:"document_versions"`
+	DocumentBytes    int64 `json:"document_bytes"`
+	TotalBytes       int64 `json:"total_bytes"`
+}
+
+type RoleInput struct {
+	Role string `json:"role"`
+}
+
+// CalendarFeedResponse describes the user's feed. Only its hash is stored,
+// so Token and URL are set only when the feed is created or rotated.
+type CalendarFeedResponse struct {
+	Active bool   `json:"active"`
+	Token  string `json:"token,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CompanyWatch is a company whose job board a user follows. The board is
+// polled on a schedule and every posting not seen before raises a
+// notification, and is saved as a new job when AutoSave is set.
+type CompanyWatch struct {
+	ID      string `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID  string `json:"user_id" gorm:"type:varchar(36);index;not null"`
+	Company string `json:"company" gorm:"type:varchar(255);not null"`
+	// ATS is the applicant tracking system hosting the board: greenhouse,
+	// lever, ashby or workable
+	ATS string `json:"ats" gorm:"type:varchar(20);not null"`
+	// BoardID identifies the company's board on the ATS
+	BoardID      string     `json:"board_id" gorm:"type:varchar(255);not null"`
+	AutoSave     bool       `json:"auto_save"`
+	LastPolledAt *time.Time `json:"last_polled_at"`
+	LastError    string     `json:"last_error"`
+	// OpenPostings is how many postings the board listed when last polled
+	OpenPostings int       `json:"open_postings"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (w *CompanyWatch) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == "" {
+		w.ID = uuid.New().String()
+	}
+	return nil
+}
+
+type CompanyWatchInput struct {
+	Company  string `json:"company"`
+	ATS      string `json:"ats"`
+	BoardID  string `json:"board_id"`
+	AutoSave bool   `json:"auto_save"`
+}
+
+// WatchedPosting is a posting already seen on a watched board, identified by
+// its canonical URL.
+type WatchedPosting struct {
+	WatchID     string    `gorm:"primaryKey;type:varchar(36)"`
+	PostingKey  string    `gorm:"primaryKey;type:varchar(2000)"`
+	FirstSeenAt time.Time `gorm:"not null"`
+}
+
+// Notification kinds.
+const (
+	NotificationNewOpening    = "new_opening"
+	NotificationPostingClosed = "posting_closed"
+)
+
+// Notification tells a user about something that happened in the
+// background, such as a new opening at a watched company.
+type Notification struct {
+	ID      string `json:"id" gorm:"primaryKey;type:varchar(36)"`
+	UserID  string `json:"user_id" gorm:"type:varchar(36);index;not null"`
+	Kind    string `json:"kind" gorm:"type:varchar(50);not null"`
+	Title   string `json:"title" gorm:"type:varchar(500)"`
+	Company string `json:"company" gorm:"type:varchar(500)"`
+	URL     string `json:"url" gorm:"type:varchar(2000)"`
+	// WatchID is the company watch that found the opening
+	WatchID *string `json:"watch_id,omitempty" gorm:"type:varchar(36);index"`
+	// JobID is the job the notification is about, such as the one saved
+	// for an opening
+	JobID     *string    `json:"job_id,omitempty" gorm:"type:varchar(36)"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == "" {
+		n.ID = uuid.New().String()
+	}
+	return nil
+}
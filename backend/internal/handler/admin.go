@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"job-tracker-backend/internal/domain"
+	appMiddleware "job-tracker-backend/internal/middleware"
+	"job-tracker-backend/internal/service"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AdminHandler struct {
+	service *service.AdminService
+}
+
+func NewAdminHandler(svc *service.AdminService) *AdminHandler {
+	return &AdminHandler{service: svc}
+}
+
+// Routes serves user administration. Mount it behind
+// middleware.RequireAdmin.
+func (h *AdminHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/users", h.ListUsers)
+	r.Get("/users/{id}", h.GetUser)
+	r.Put("/users/{id}/role", h.SetRole)
+	r.Post("/users/{id}/disable", h.DisableUser)
+	r.Post("/users/{id}/enable", h.EnableUser)
+	r.Post("/users/{id}/require-password-reset", h.RequirePasswordReset)
+	r.Delete("/users/{id}", h.DeleteUser)
+	return r
+}
+
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.service.ListUsers(r.URL.Query().Get("q"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(users))
+}
+
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.GetUser(chi.URLParam(r, "id"))
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(user))
+}
+
+func (h *AdminHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	var input domain.RoleInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("Invalid request body"))
+		return
+	}
+
+	actorID := appMiddleware.UserIDFromContext(r.Context())
+	user, err := h.service.SetRole(actorID, chi.URLParam(r, "id"), &input)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(user))
+}
+
+func (h *AdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	actorID := appMiddleware.UserIDFromContext(r.Context())
+	user, err := h.service.DisableUser(actorID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(user))
+}
+
+func (h *AdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.EnableUser(chi.URLParam(r, "id"))
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(user))
+}
+
+func (h *AdminHandler) RequirePasswordReset(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.RequirePasswordReset(chi.URLParam(r, "id"))
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(user))
+}
+
+func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	actorID := appMiddleware.UserIDFromContext(r.Context())
+	if err := h.service.DeleteUser(actorID, chi.URLParam(r, "id")); err != nil {
+		writeAdminError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.SuccessMessage("user deleted"))
+}
+
+func writeAdminError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case errors.Is(err, appErrors.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(response.Error("user not found"))
+		return
+	case errors.Is(err, appErrors.ErrInvalidInput):
+		w.WriteHeader(http.StatusBadRequest)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	json.NewEncoder(w).Encode(response.Error(err.Error()))
+}
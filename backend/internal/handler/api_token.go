@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"job-tracker-backend/internal/domain"
+	appMiddleware "job-tracker-backend/internal/middleware"
+	"job-tracker-backend/internal/service"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type APITokenHandler struct {
+	service *service.APITokenService
+}
+
+func NewAPITokenHandler(svc *service.APITokenService) *APITokenHandler {
+	return &APITokenHandler{service: svc}
+}
+
+func (h *APITokenHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", h.ListTokens)
+	r.Post("/", h.CreateToken)
+	r.Delete("/{id}", h.RevokeToken)
+	return r
+}
+
+func (h *APITokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	tokens, err := h.service.ListTokens(userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(tokens))
+}
+
+// CreateToken returns the new token's secret, which can't be retrieved
+// again.
+func (h *APITokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	var input domain.APITokenCreateInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("Invalid request body"))
+		return
+	}
+
+	token, err := h.service.CreateToken(userID, &input)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response.Success(token))
+}
+
+func (h *APITokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	if err := h.service.RevokeToken(userID, chi.URLParam(r, "id")); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, appErrors.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(response.Error("API token not found"))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.SuccessMessage("API token revoked"))
+}
@@ -2,7 +2,12 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"job-tracker-backend/internal/domain"
 	appMiddleware "job-tracker-backend/internal/middleware"
@@ -21,10 +26,33 @@ func NewAuthHandler(svc *service.AuthService) *AuthHandler {
 	return &AuthHandler{service: svc}
 }
 
-func (h *AuthHandler) PublicRoutes() http.Handler {
+// PublicRoutes serves the endpoints used without an access token. throttle
+// guards those that check passwords, redeem emailed tokens or send email.
+func (h *AuthHandler) PublicRoutes(throttle ...func(http.Handler) http.Handler) http.Handler {
 	r := chi.NewRouter()
-	r.Post("/register", h.Register)
-	r.Post("/login", h.Login)
+	r.Group(func(r chi.Router) {
+		r.Use(throttle...)
+		r.Post("/register", h.Register)
+		r.Post("/login", h.Login)
+		r.Post("/login/2fa", h.CompleteLogin)
+		r.Post("/forgot-password", h.ForgotPassword)
+		r.Post("/reset-password", h.ResetPassword)
+		r.Post("/verify-email", h.VerifyEmail)
+		r.Post("/resend-verification", h.ResendVerification)
+	})
+	r.Post("/refresh", h.Refresh)
+	r.Post("/logout", h.Logout)
+	return r
+}
+
+// TwoFactorRoutes serves two-factor enrollment for the signed-in user.
+func (h *AuthHandler) TwoFactorRoutes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", h.TwoFactorStatus)
+	r.Post("/setup", h.SetupTwoFactor)
+	r.Post("/confirm", h.ConfirmTwoFactor)
+	r.Post("/disable", h.DisableTwoFactor)
+	r.Post("/recovery-codes", h.RegenerateRecoveryCodes)
 	return r
 }
 
@@ -38,7 +66,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.service.Register(&input)
+	resp, err := h.service.Register(&input, clientInfo(r))
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		switch err {
@@ -69,9 +97,28 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.service.Login(&input)
+	resp, err := h.service.Login(&input, clientInfo(r))
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(response.Error("verify your email before signing in"))
+			return
+		}
+		if errors.Is(err, service.ErrPasswordResetRequired) {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(response.Error("choose a new password with the link emailed to you, or ask for one with \"Forgot password?\""))
+			return
+		}
+		if errors.Is(err, service.ErrAccountDisabled) {
+			writeDisabled(w)
+			return
+		}
+		var locked *service.LockedError
+		if errors.As(err, &locked) {
+			writeLocked(w, locked)
+			return
+		}
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(response.Error("invalid email or password"))
 		return
@@ -92,7 +139,8 @@ func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.ChangePassword(userID, &input); err != nil {
+	sessionID := appMiddleware.SessionIDFromContext(r.Context())
+	if err := h.service.ChangePassword(userID, sessionID, &input); err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		switch err {
 		case appErrors.ErrInvalidInput:
@@ -108,3 +156,377 @@ func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response.SuccessMessage("password changed successfully"))
 }
+
+// Refresh exchanges a refresh token for new access and refresh tokens.
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var input domain.RefreshInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("invalid request body"))
+		return
+	}
+
+	resp, err := h.service.Refresh(input.RefreshToken, clientInfo(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(response.Error("invalid or expired refresh token"))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error("failed to refresh session"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(resp))
+}
+
+// Logout ends the session of the refresh token in the body. It needs no
+// access token, so an expired one doesn't keep a device signed in.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var input domain.RefreshInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("invalid request body"))
+		return
+	}
+
+	if err := h.service.Logout(input.RefreshToken); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(response.Error("refresh_token is required"))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error("failed to log out"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.SuccessMessage("logged out"))
+}
+
+// LogoutAll signs the user out of every session, this one included.
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	if err := h.service.RevokeAllSessions(userID); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error("failed to sign out everywhere"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.SuccessMessage("signed out everywhere"))
+}
+
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	sessions, err := h.service.ListSessions(userID, appMiddleware.SessionIDFromContext(r.Context()))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(sessions))
+}
+
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	if err := h.service.RevokeSession(userID, chi.URLParam(r, "id")); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, appErrors.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(response.Error("session not found"))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.SuccessMessage("session revoked"))
+}
+
+// CompleteLogin finishes a two-factor sign-in with the challenge token from
+// Login and a code.
+func (h *AuthHandler) CompleteLogin(w http.ResponseWriter, r *http.Request) {
+	var input domain.TwoFactorLoginInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("invalid request body"))
+		return
+	}
+
+	resp, err := h.service.CompleteLogin(&input, clientInfo(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		var locked *service.LockedError
+		switch {
+		case errors.As(err, &locked):
+			writeLocked(w, locked)
+			return
+		case errors.Is(err, service.ErrAccountDisabled):
+			writeDisabled(w)
+			return
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(resp))
+}
+
+func (h *AuthHandler) TwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	status, err := h.service.TwoFactorStatus(userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(status))
+}
+
+// SetupTwoFactor returns a new authenticator secret to confirm.
+func (h *AuthHandler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	setup, err := h.service.SetupTwoFactor(userID)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(setup))
+}
+
+// ConfirmTwoFactor turns two-factor sign-in on and returns recovery codes.
+func (h *AuthHandler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	var input domain.TwoFactorCodeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("invalid request body"))
+		return
+	}
+
+	codes, err := h.service.ConfirmTwoFactor(userID, &input)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(codes))
+}
+
+func (h *AuthHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	var input domain.PasswordInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("invalid request body"))
+		return
+	}
+
+	if err := h.service.DisableTwoFactor(userID, &input); err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.SuccessMessage("two-factor authentication turned off"))
+}
+
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	var input domain.PasswordInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("invalid request body"))
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(userID, &input)
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(codes))
+}
+
+// writeTwoFactorError answers a failed two-factor enrollment change. Wrong
+// passwords and codes are 400, not 401, which would read as a signed-out
+// session.
+func writeTwoFactorError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case errors.Is(err, appErrors.ErrInvalidInput):
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, appErrors.ErrAlreadyExists):
+		w.WriteHeader(http.StatusConflict)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	json.NewEncoder(w).Encode(response.Error(err.Error()))
+}
+
+// writeDisabled answers a sign-in to an account an admin disabled.
+func writeDisabled(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(response.Error("this account has been disabled"))
+}
+
+// writeLocked answers a sign-in to a locked account with 429 and when to
+// try again.
+func writeLocked(w http.ResponseWriter, locked *service.LockedError) {
+	seconds := int(math.Ceil(time.Until(locked.Until).Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(response.Error(fmt.Sprintf("too many failed sign-ins; try again in %d seconds", seconds)))
+}
+
+// ListLoginAttempts returns the recent sign-in attempts on the account.
+func (h *AuthHandler) ListLoginAttempts(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	attempts, err := h.service.LoginAttempts(userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(attempts))
+}
+
+// ForgotPassword emails a password reset link. It answers the same whether
+// or not the email has an account.
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var input domain.ForgotPasswordInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("invalid request body"))
+		return
+	}
+
+	if err := h.service.ForgotPassword(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("email is required"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.SuccessMessage("if an account exists for this email, a reset link has been sent"))
+}
+
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var input domain.ResetPasswordInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("invalid request body"))
+		return
+	}
+
+	if err := h.service.ResetPassword(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.SuccessMessage("password reset; sign in with your new password"))
+}
+
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var input domain.VerifyEmailInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("invalid request body"))
+		return
+	}
+
+	if err := h.service.VerifyEmail(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.SuccessMessage("email verified"))
+}
+
+// ResendVerification emails a new verification link to an unverified
+// account.
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var input domain.ForgotPasswordInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("invalid request body"))
+		return
+	}
+
+	if err := h.service.ResendVerification(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("email is required"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.SuccessMessage("if this email awaits verification, a new link has been sent"))
+}
+
+// clientInfo describes the device of a request for its session.
+func clientInfo(r *http.Request) domain.ClientInfo {
+	return domain.ClientInfo{UserAgent: r.UserAgent(), IP: appMiddleware.ClientIP(r)}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	appMiddleware "job-tracker-backend/internal/middleware"
+	"job-tracker-backend/internal/scan"
+	"job-tracker-backend/internal/service"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxRestoreUploadSize bounds the restore archive upload.
+const maxRestoreUploadSize = 1 << 30 // 1GB
+
+type BackupHandler struct {
+	service *service.BackupService
+}
+
+func NewBackupHandler(svc *service.BackupService) *BackupHandler {
+	return &BackupHandler{service: svc}
+}
+
+func (h *BackupHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", h.Download)
+	r.Post("/restore", h.Restore)
+	return r
+}
+
+func (h *BackupHandler) Download(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	filename := fmt.Sprintf("jobtracker-backup-%s.zip", time.Now().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+
+	if err := h.service.WriteArchive(userID, w); err != nil {
+		log.Printf("backup for user %s failed: %v", userID, err)
+	}
+}
+
+// Restore imports an archive uploaded as the "archive" multipart field into
+// the authenticated account.
+func (h *BackupHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRestoreUploadSize)
+	// Anything beyond 32MB is spooled to a temporary file by the multipart
+	// reader, which also gives us the io.ReaderAt zip needs.
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("Failed to parse form data"))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("archive")
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("Failed to get archive from form"))
+		return
+	}
+	defer file.Close()
+
+	result, err := h.service.RestoreArchive(userID, file, header.Size)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case errors.Is(err, scan.ErrInfected):
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		case errors.Is(err, service.ErrScanFailed):
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(result))
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	appMiddleware "job-tracker-backend/internal/middleware"
+	"job-tracker-backend/internal/service"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type CalendarHandler struct {
+	service *service.CalendarService
+}
+
+func NewCalendarHandler(svc *service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{service: svc}
+}
+
+// PublicRoutes serves the token-authenticated feed that calendar apps poll.
+func (h *CalendarHandler) PublicRoutes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/{token}.ics", h.Feed)
+	return r
+}
+
+func (h *CalendarHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/feed", h.GetFeed)
+	r.Post("/feed/rotate", h.RotateFeed)
+	return r
+}
+
+func (h *CalendarHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	feed, err := h.service.GetFeed(userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(feed))
+}
+
+func (h *CalendarHandler) RotateFeed(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	feed, err := h.service.RotateFeed(userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(feed))
+}
+
+func (h *CalendarHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	data, err := h.service.FeedByToken(token)
+	if err != nil {
+		if err == appErrors.ErrNotFound {
+			http.Error(w, "Calendar not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Write(data)
+}
+
+func (h *CalendarHandler) JobCalendar(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	data, err := h.service.JobCalendar(userID, id)
+	if err != nil {
+		if err == appErrors.ErrNotFound {
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"job-%s.ics\"", id))
+	w.Write(data)
+}
@@ -0,0 +1,317 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"job-tracker-backend/internal/domain"
+	appMiddleware "job-tracker-backend/internal/middleware"
+	"job-tracker-backend/internal/scan"
+	"job-tracker-backend/internal/service"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type DocumentHandler struct {
+	service *service.DocumentService
+}
+
+func NewDocumentHandler(svc *service.DocumentService) *DocumentHandler {
+	return &DocumentHandler{service: svc}
+}
+
+func (h *DocumentHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/", h.ListDocuments)
+	r.Post("/", h.CreateDocument)
+	r.Get("/{id}", h.GetDocument)
+	r.Delete("/{id}", h.DeleteDocument)
+	r.Post("/{id}/versions", h.UploadVersion)
+	r.Get("/{id}/versions/{vid}/download", h.DownloadVersion)
+	r.Get("/{id}/versions/{vid}/jobs", h.VersionJobs)
+	r.Delete("/{id}/versions/{vid}", h.DeleteVersion)
+
+	return r
+}
+
+func (h *DocumentHandler) ListDocuments(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	docs, err := h.service.ListDocuments(userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	if docs == nil {
+		docs = []domain.Document{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(docs))
+}
+
+func (h *DocumentHandler) GetDocument(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	doc, err := h.service.GetDocument(userID, id)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, appErrors.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(response.Error("Document not found"))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(doc))
+}
+
+// CreateDocument starts a new document from the uploaded "file" part. The
+// optional "name", "label" and "file_type" form fields must precede the file.
+func (h *DocumentHandler) CreateDocument(w http.ResponseWriter, r *http.Request) {
+	h.upload(w, r, "")
+}
+
+// UploadVersion adds the uploaded "file" part as the next version of an
+// existing document, optionally labelled by a preceding "label" field.
+func (h *DocumentHandler) UploadVersion(w http.ResponseWriter, r *http.Request) {
+	h.upload(w, r, chi.URLParam(r, "id"))
+}
+
+func (h *DocumentHandler) upload(w http.ResponseWriter, r *http.Request, documentID string) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxFileSize+maxMultipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("Failed to parse form data"))
+		return
+	}
+
+	input := &service.DocumentUploadInput{
+		UserID:     userID,
+		DocumentID: documentID,
+		FileType:   r.URL.Query().Get("file_type"),
+	}
+	var result *service.DocumentUploadResult
+	for result == nil {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(response.Error("Failed to parse form data"))
+			return
+		}
+
+		switch part.FormName() {
+		case "name", "label", "file_type":
+			value, err := io.ReadAll(io.LimitReader(part, 255))
+			if err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(response.Error("Failed to parse form data"))
+				return
+			}
+			switch part.FormName() {
+			case "name":
+				input.Name = string(value)
+			case "label":
+				input.Label = string(value)
+			case "file_type":
+				input.FileType = string(value)
+			}
+		case "file":
+			input.FileName = part.FileName()
+			input.MIMEType = part.Header.Get("Content-Type")
+			input.Content = part
+			result, err = h.service.Upload(input)
+			if err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case errors.Is(err, service.ErrFileTooLarge):
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+				case errors.Is(err, scan.ErrInfected):
+					w.WriteHeader(http.StatusUnprocessableEntity)
+				case errors.Is(err, service.ErrScanFailed):
+					w.WriteHeader(http.StatusServiceUnavailable)
+				case errors.Is(err, appErrors.ErrNotFound):
+					w.WriteHeader(http.StatusNotFound)
+					json.NewEncoder(w).Encode(response.Error("Document not found"))
+					return
+				case errors.Is(err, appErrors.ErrInvalidInput):
+					w.WriteHeader(http.StatusBadRequest)
+				default:
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+				json.NewEncoder(w).Encode(response.Error(err.Error()))
+				return
+			}
+		}
+		part.Close()
+	}
+
+	if result == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("Failed to get file from form"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !result.Deduplicated {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(response.Success(result))
+}
+
+func (h *DocumentHandler) DeleteDocument(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	if err := h.service.DeleteDocument(userID, id); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case errors.Is(err, appErrors.ErrNotFound):
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(response.Error("Document not found"))
+			return
+		case errors.Is(err, appErrors.ErrInUse):
+			w.WriteHeader(http.StatusConflict)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.SuccessMessage("Document deleted successfully"))
+}
+
+func (h *DocumentHandler) DownloadVersion(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	version, err := h.service.GetVersion(userID, chi.URLParam(r, "id"), chi.URLParam(r, "vid"))
+	if err != nil {
+		if errors.Is(err, appErrors.ErrNotFound) {
+			http.Error(w, "Document version not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	content, err := h.service.OpenVersion(version)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", version.MIMEType)
+	w.Header().Set("Content-Disposition", contentDisposition("attachment", version.FileName))
+	w.Header().Set("ETag", `"`+version.ContentHash+`"`)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	http.ServeContent(w, r, "", version.CreatedAt, content)
+}
+
+// VersionJobs lists the jobs a version was attached to.
+func (h *DocumentHandler) VersionJobs(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	usages, err := h.service.VersionUsages(userID, chi.URLParam(r, "id"), chi.URLParam(r, "vid"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, appErrors.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(response.Error("Document version not found"))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	if usages == nil {
+		usages = []domain.DocumentVersionUsage{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(usages))
+}
+
+func (h *DocumentHandler) DeleteVersion(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	if err := h.service.DeleteVersion(userID, chi.URLParam(r, "id"), chi.URLParam(r, "vid")); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case errors.Is(err, appErrors.ErrNotFound):
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(response.Error("Document version not found"))
+			return
+		case errors.Is(err, appErrors.ErrInUse):
+			w.WriteHeader(http.StatusConflict)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.SuccessMessage("Document version deleted successfully"))
+}
+
+// LinkToJob attaches an existing document version to the job in the URL.
+func (h *DocumentHandler) LinkToJob(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+	jobID := chi.URLParam(r, "id")
+
+	var input domain.LinkDocumentInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.DocumentVersionID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("document_version_id is required"))
+		return
+	}
+
+	attachment, err := h.service.LinkToJob(userID, jobID, input.DocumentVersionID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case errors.Is(err, appErrors.ErrNotFound):
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(response.Error("Job not found"))
+			return
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response.Success(attachment))
+}
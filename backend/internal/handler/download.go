@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/url"
+	"strings"
+)
+
+// maxMultipartOverhead is the room left for multipart headers and small form
+// fields on top of the file size limit.
+const maxMultipartOverhead = 1 << 20
+
+// contentDisposition builds a Content-Disposition header per RFC 6266: an
+// ASCII-only quoted filename for old clients plus an RFC 5987 filename*
+// parameter carrying the exact UTF-8 name.
+func contentDisposition(dispositionType, filename string) string {
+	fallback := strings.Map(func(r rune) rune {
+		switch {
+		case r == '"' || r == '\\' || r == '/':
+			return '_'
+		case r < 0x20 || r > 0x7e:
+			return '_'
+		}
+		return r
+	}, filename)
+
+	// url.PathEscape leaves ':', '=' and '@' alone, but they are not valid
+	// RFC 5987 attr-chars.
+	encoded := strings.NewReplacer(":", "%3A", "=", "%3D", "@", "%40").Replace(url.PathEscape(filename))
+
+	return dispositionType + `; filename="` + fallback + `"; filename*=UTF-8''` + encoded
+}
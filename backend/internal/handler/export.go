@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	appMiddleware "job-tracker-backend/internal/middleware"
+	"job-tracker-backend/internal/service"
+	"job-tracker-backend/pkg/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ExportHandler struct {
+	service *service.ExportService
+}
+
+func NewExportHandler(svc *service.ExportService) *ExportHandler {
+	return &ExportHandler{service: svc}
+}
+
+func (h *ExportHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", h.Export)
+	return r
+}
+
+// Export streams the user's jobs as csv, json or ndjson. It accepts the same
+// filters as GET /api/jobs, plus columns (comma-separated, CSV
+// only) and include (comma-separated: attachments, history).
+func (h *ExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+	q := r.URL.Query()
+
+	filter, err := jobFilterFromQuery(q)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+	opts := &service.ExportOptions{
+		Format: q.Get("format"),
+		Filter: *filter,
+	}
+	if opts.Format == "" {
+		opts.Format = service.ExportFormatJSON
+	}
+	if cols := q.Get("columns"); cols != "" {
+		for _, c := range strings.Split(cols, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				opts.Columns = append(opts.Columns, c)
+			}
+		}
+	}
+	for _, inc := range strings.Split(q.Get("include"), ",") {
+		switch strings.TrimSpace(inc) {
+		case "attachments":
+			opts.IncludeAttachments = true
+		case "history":
+			opts.IncludeHistory = true
+		}
+	}
+
+	if err := h.service.Validate(opts); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	filename := fmt.Sprintf("jobs-%s.%s", time.Now().Format("20060102"), opts.Format)
+	w.Header().Set("Content-Type", h.service.ContentType(opts.Format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+
+	var flush func()
+	if f, ok := w.(http.Flusher); ok {
+		flush = f.Flush
+	}
+
+	// Headers are already sent once streaming starts, so a failure midway can
+	// only be logged; the client sees a truncated body.
+	if err := h.service.Export(userID, opts, w, flush); err != nil {
+		log.Printf("export for user %s failed: %v", userID, err)
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	appMiddleware "job-tracker-backend/internal/middleware"
+	"job-tracker-backend/internal/service"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ImportHandler struct {
+	service *service.ImportService
+}
+
+func NewImportHandler(svc *service.ImportService) *ImportHandler {
+	return &ImportHandler{service: svc}
+}
+
+func (h *ImportHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Post("/", h.Import)
+	return r
+}
+
+// Import accepts a multipart form with a "file" field and optional "mode"
+// (preview|commit), "format" (csv|json|xlsx), "source" and "mapping" (a JSON
+// object of target field to source column) fields.
+func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxImportFileSize+1<<20)
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("Failed to parse form data"))
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("Failed to get file from form"))
+		return
+	}
+	defer file.Close()
+
+	opts := &service.ImportOptions{
+		Mode:     r.FormValue("mode"),
+		Format:   r.FormValue("format"),
+		FileName: header.Filename,
+		Source:   r.FormValue("source"),
+	}
+	if mapping := r.FormValue("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &opts.Mapping); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(response.Error("mapping must be a JSON object of field to column"))
+			return
+		}
+	}
+
+	data, err := service.ReadImportFile(file)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	result, err := h.service.Import(userID, data, opts)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(result))
+}
@@ -2,12 +2,19 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"job-tracker-backend/internal/domain"
 	appMiddleware "job-tracker-backend/internal/middleware"
+	"job-tracker-backend/internal/scan"
 	"job-tracker-backend/internal/service"
 	appErrors "job-tracker-backend/pkg/errors"
 	"job-tracker-backend/pkg/response"
@@ -16,11 +23,15 @@ import (
 )
 
 type JobHandler struct {
-	service *service.JobService
+	service     *service.JobService
+	ranking     *service.RankingService
+	preferences *service.PreferenceService
+	liveness    *service.LivenessService
+	urlImport   *service.URLImportService
 }
 
-func NewJobHandler(svc *service.JobService) *JobHandler {
-	return &JobHandler{service: svc}
+func NewJobHandler(svc *service.JobService, ranking *service.RankingService, preferences *service.PreferenceService, liveness *service.LivenessService, urlImport *service.URLImportService) *JobHandler {
+	return &JobHandler{service: svc, ranking: ranking, preferences: preferences, liveness: liveness, urlImport: urlImport}
 }
 
 type AttachmentHandler struct {
@@ -31,16 +42,22 @@ func NewAttachmentHandler(svc *service.JobService) *AttachmentHandler {
 	return &AttachmentHandler{service: svc}
 }
 
-func (h *JobHandler) Routes() http.Handler {
+// Routes serves the job endpoints. throttleSearch guards search, which
+// queries outside providers.
+func (h *JobHandler) Routes(throttleSearch ...func(http.Handler) http.Handler) http.Handler {
 	r := chi.NewRouter()
 
 	r.Get("/", h.ListJobs)
 	r.Post("/", h.CreateJob)
-	r.Post("/search", h.SearchJobs)
+	r.Post("/from-url", h.CreateJobFromURL)
+	r.With(throttleSearch...).Post("/search", h.SearchJobs)
+	r.Get("/search/providers", h.SearchProviders)
 	r.Get("/{id}", h.GetJob)
 	r.Put("/{id}", h.UpdateJob)
 	r.Delete("/{id}", h.DeleteJob)
 	r.Patch("/{id}/status", h.UpdateJobStatus)
+	r.Get("/{id}/match", h.MatchResume)
+	r.Post("/{id}/check", h.CheckPosting)
 
 	attachmentHandler := NewAttachmentHandler(h.service)
 	r.Mount("/api/jobs/{id}/attachments", attachmentHandler.Routes())
@@ -51,15 +68,26 @@ func (h *JobHandler) Routes() http.Handler {
 func (h *JobHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
 	userID := appMiddleware.UserIDFromContext(r.Context())
 
-	filter := &domain.JobFilter{
-		Status: r.URL.Query().Get("status"),
-		Source: r.URL.Query().Get("source"),
+	format := r.URL.Query().Get("description_format")
+	filter, err := jobFilterFromQuery(r.URL.Query())
+	if err == nil {
+		err = service.ValidateDescriptionFormat(format)
+	}
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
 	}
 
 	jobs, err := h.service.GetAllJobs(userID, filter)
 	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			status = http.StatusBadRequest
+		}
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(response.Error(err.Error()))
 		return
 	}
@@ -67,11 +95,54 @@ func (h *JobHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
 	if jobs == nil {
 		jobs = []domain.Job{}
 	}
+	for i := range jobs {
+		service.RenderDescription(&jobs[i], format)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response.Success(jobs))
 }
 
+// jobFilterFromQuery reads the job list filters. List parameters may be
+// comma-separated, repeated, or both.
+func jobFilterFromQuery(q url.Values) (*domain.JobFilter, error) {
+	filter := &domain.JobFilter{
+		Status:    q.Get("status"),
+		Source:    q.Get("source"),
+		Query:     q.Get("q"),
+		Seniority: queryList(q, "seniority"),
+		Skills:    queryList(q, "skills"),
+		Exclude:   queryList(q, "exclude"),
+	}
+	if v := q.Get("max_years"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_years %q", v)
+		}
+		filter.MaxYears = &n
+	}
+	if v := q.Get("closed"); v != "" {
+		closed, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid closed %q", v)
+		}
+		filter.Closed = &closed
+	}
+	return filter, nil
+}
+
+func queryList(q url.Values, key string) []string {
+	var list []string
+	for _, v := range q[key] {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
 func (h *JobHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
 	userID := appMiddleware.UserIDFromContext(r.Context())
 
@@ -100,9 +171,51 @@ func (h *JobHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response.Success(job))
 }
 
+// CreateJobFromURL reads a job from its posting's page. It takes
+// {"url": "...", "mode": "preview"|"commit"}; preview, the default, returns
+// the extracted fields without saving them.
+func (h *JobHandler) CreateJobFromURL(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	var input domain.JobFromURLInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("Invalid request body"))
+		return
+	}
+
+	result, err := h.urlImport.ImportURL(userID, &input)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case errors.Is(err, appErrors.ErrAlreadyExists):
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(response.Error("Job with this URL already exists"))
+			return
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(result))
+}
+
 func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
 	userID := appMiddleware.UserIDFromContext(r.Context())
 	id := chi.URLParam(r, "id")
+	format := r.URL.Query().Get("description_format")
+	if err := service.ValidateDescriptionFormat(format); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
 
 	job, err := h.service.GetJob(userID, id)
 	if err != nil {
@@ -116,6 +229,7 @@ func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(response.Error(err.Error()))
 		return
 	}
+	service.RenderDescription(job, format)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response.Success(job))
@@ -141,6 +255,11 @@ func (h *JobHandler) UpdateJob(w http.ResponseWriter, r *http.Request) {
 			json.NewEncoder(w).Encode(response.Error("Job not found"))
 			return
 		}
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(response.Error(err.Error()))
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(response.Error(err.Error()))
 		return
@@ -170,6 +289,11 @@ func (h *JobHandler) UpdateJobStatus(w http.ResponseWriter, r *http.Request) {
 			json.NewEncoder(w).Encode(response.Error("Job not found"))
 			return
 		}
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(response.Error(err.Error()))
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(response.Error(err.Error()))
 		return
@@ -203,6 +327,14 @@ func (h *JobHandler) DeleteJob(w http.ResponseWriter, r *http.Request) {
 func (h *JobHandler) SearchJobs(w http.ResponseWriter, r *http.Request) {
 	userID := appMiddleware.UserIDFromContext(r.Context())
 
+	format := r.URL.Query().Get("description_format")
+	if err := service.ValidateDescriptionFormat(format); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
 	var params service.MCPSearchParams
 	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
 		w.Header().Set("Content-Type", "application/json")
@@ -224,21 +356,140 @@ func (h *JobHandler) SearchJobs(w http.ResponseWriter, r *http.Request) {
 		params.Format = "json"
 	}
 
-	jobs, err := h.service.SearchJobs(userID, params)
+	jobs, providers, err := h.service.SearchJobs(userID, params)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
 		json.NewEncoder(w).Encode(response.Error("Failed to search jobs: " + err.Error()))
 		return
 	}
 
+	// Optionally rank the results against one of the user's resumes
+	if attachmentID := r.URL.Query().Get("attachment_id"); attachmentID != "" {
+		if err := h.service.RankSearchResults(userID, attachmentID, jobs); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			if errors.Is(err, appErrors.ErrInvalidInput) {
+				w.WriteHeader(http.StatusBadRequest)
+			} else {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			json.NewEncoder(w).Encode(response.Error(err.Error()))
+			return
+		}
+	}
+
+	// Score by the preferences learned from the user's decisions
+	if err := h.preferences.Score(userID, jobs); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	// Score, sort and filter by the user's ranking profile, if they have one
+	var minScore *int
+	if v := r.URL.Query().Get("min_score"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(response.Error("invalid min_score"))
+			return
+		}
+		minScore = &n
+	}
+	jobs, err = h.ranking.Rank(userID, jobs, minScore)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	for i := range jobs {
+		service.RenderDescription(&jobs[i].Job, format)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response.Success(map[string]interface{}{
-		"count": len(jobs),
-		"jobs":  jobs,
+		"count":     len(jobs),
+		"jobs":      jobs,
+		"providers": providers,
 	}))
 }
 
+// SearchProviders lists the search providers that can be selected with the
+// providers field of a search.
+func (h *JobHandler) SearchProviders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(h.service.SearchProviderNames()))
+}
+
+// MatchResume scores a resume attachment, given by the attachment_id query
+// parameter or defaulting to the job's latest resume, against the job.
+func (h *JobHandler) MatchResume(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	report, err := h.service.MatchResume(userID, id, r.URL.Query().Get("attachment_id"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case errors.Is(err, appErrors.ErrNotFound):
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(response.Error("Job not found"))
+			return
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(report))
+}
+
+// CheckPosting checks now whether the job's posting is still open.
+func (h *JobHandler) CheckPosting(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	job, err := h.liveness.CheckJob(userID, id)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		var busy *service.HostBusyError
+		switch {
+		case errors.As(err, &busy):
+			seconds := max(1, int(math.Ceil(time.Until(busy.Until).Seconds())))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(response.Error(fmt.Sprintf("%s asks for time between requests; try again in %d seconds", busy.Host, seconds)))
+			return
+		case errors.Is(err, appErrors.ErrNotFound):
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(response.Error("Job not found"))
+			return
+		case errors.Is(err, appErrors.ErrInvalidInput):
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+	service.RenderDescription(job, "")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(job))
+}
+
 func (h *AttachmentHandler) Routes() http.Handler {
 	r := chi.NewRouter()
 
@@ -246,62 +497,91 @@ func (h *AttachmentHandler) Routes() http.Handler {
 	r.Get("/", h.ListAttachments)
 	r.Get("/{id}", h.GetAttachment)
 	r.Get("/{id}/download", h.DownloadAttachment)
+	r.Get("/{id}/text", h.GetAttachmentText)
 	r.Delete("/{id}", h.DeleteAttachment)
 
 	return r
 }
 
+// UploadAttachment streams the "file" part of a multipart request straight
+// into attachment storage. The file type is read from a "file_type" form
+// field sent before the file, or from the file_type query parameter.
 func (h *AttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
 	userID := appMiddleware.UserIDFromContext(r.Context())
 	jobID := chi.URLParam(r, "id")
 
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(response.Error("Failed to parse form data"))
-		return
-	}
-
-	file, header, err := r.FormFile("file")
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxFileSize+maxMultipartOverhead)
+	reader, err := r.MultipartReader()
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(response.Error("Failed to get file from form"))
+		json.NewEncoder(w).Encode(response.Error("Failed to parse form data"))
 		return
 	}
-	defer file.Close()
 
-	fileType := r.FormValue("file_type")
-	if fileType == "" {
-		fileType = "resume"
-	}
-
-	fileBytes, err := io.ReadAll(file)
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response.Error("Failed to read file"))
-		return
-	}
+	fileType := r.URL.Query().Get("file_type")
+	var attachment *domain.Attachment
+	for attachment == nil {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(response.Error("Failed to parse form data"))
+			return
+		}
 
-	input := &service.AttachmentInput{
-		JobID:    jobID,
-		UserID:   userID,
-		FileName: header.Filename,
-		FileType: fileType,
-		MIMEType: header.Header.Get("Content-Type"),
-		Data:     fileBytes,
+		switch part.FormName() {
+		case "file_type":
+			value, err := io.ReadAll(io.LimitReader(part, 64))
+			if err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(response.Error("Failed to parse form data"))
+				return
+			}
+			fileType = string(value)
+		case "file":
+			if fileType == "" {
+				fileType = "resume"
+			}
+			input := &service.AttachmentInput{
+				JobID:    jobID,
+				UserID:   userID,
+				FileName: part.FileName(),
+				FileType: fileType,
+				MIMEType: part.Header.Get("Content-Type"),
+				Content:  part,
+			}
+			attachment, err = h.service.CreateAttachment(input)
+			if err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case errors.Is(err, service.ErrFileTooLarge):
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+				case errors.Is(err, scan.ErrInfected):
+					w.WriteHeader(http.StatusUnprocessableEntity)
+				case errors.Is(err, service.ErrScanFailed):
+					w.WriteHeader(http.StatusServiceUnavailable)
+				default:
+					w.WriteHeader(http.StatusBadRequest)
+				}
+				json.NewEncoder(w).Encode(response.Error(err.Error()))
+				return
+			}
+		}
+		part.Close()
 	}
 
-	attachment, err := h.service.CreateAttachment(input)
-	if err != nil {
+	if attachment == nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		json.NewEncoder(w).Encode(response.Error("Failed to get file from form"))
 		return
 	}
 
-	attachment.Data = nil
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response.Success(attachment))
 }
@@ -366,6 +646,44 @@ func (h *AttachmentHandler) GetAttachment(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(response.Success(attachment))
 }
 
+// GetAttachmentText returns the plain text extracted from a PDF or DOCX
+// attachment; other formats have empty text.
+func (h *AttachmentHandler) GetAttachmentText(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+	id := chi.URLParam(r, "id")
+
+	attachment, err := h.service.GetAttachment(id)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if err == appErrors.ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(response.Error("Attachment not found"))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	if _, err := h.service.GetJob(userID, attachment.JobID); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(response.Error("Attachment not found"))
+		return
+	}
+
+	text, err := h.service.GetAttachmentText(attachment)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(text))
+}
+
 func (h *AttachmentHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
 	userID := appMiddleware.UserIDFromContext(r.Context())
 	id := chi.URLParam(r, "id")
@@ -385,10 +703,20 @@ func (h *AttachmentHandler) DownloadAttachment(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	content, err := h.service.OpenAttachment(attachment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer content.Close()
+
+	// ServeContent handles Range, If-Range, If-None-Match and HEAD requests
+	// against the ETag set here.
 	w.Header().Set("Content-Type", attachment.MIMEType)
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", attachment.FileName))
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", attachment.FileSize))
-	w.Write(attachment.Data)
+	w.Header().Set("Content-Disposition", contentDisposition("attachment", attachment.FileName))
+	w.Header().Set("ETag", `"`+attachment.ContentHash+`"`)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	http.ServeContent(w, r, "", attachment.CreatedAt, content)
 }
 
 func (h *AttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
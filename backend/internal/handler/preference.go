@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	appMiddleware "job-tracker-backend/internal/middleware"
+	"job-tracker-backend/internal/service"
+	"job-tracker-backend/pkg/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type PreferenceHandler struct {
+	service *service.PreferenceService
+}
+
+func NewPreferenceHandler(svc *service.PreferenceService) *PreferenceHandler {
+	return &PreferenceHandler{service: svc}
+}
+
+func (h *PreferenceHandler) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", h.GetModel)
+	r.Post("/train", h.Train)
+	return r
+}
+
+func (h *PreferenceHandler) GetModel(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	summary, err := h.service.GetModel(userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(summary))
+}
+
+// Train retrains the model right away rather than waiting for the
+// background retrainer.
+func (h *PreferenceHandler) Train(w http.ResponseWriter, r *http.Request) {
+	userID := appMiddleware.UserIDFromContext(r.Context())
+
+	summary, err := h.service.Train(userID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(summary))
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/service"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/response"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ssoStateCookie keeps a sign-in's state while the user is at the identity
+// provider.
+const ssoStateCookie = "sso_state"
+
+type SSOHandler struct {
+	service *service.SSOService
+	auth    *service.AuthService
+}
+
+func NewSSOHandler(svc *service.SSOService, authService *service.AuthService) *SSOHandler {
+	return &SSOHandler{service: svc, auth: authService}
+}
+
+// Routes serves sign-in through identity providers. The login and callback
+// routes are browser navigations, not API calls. throttle guards redeeming
+// sign-ins.
+func (h *SSOHandler) Routes(throttle ...func(http.Handler) http.Handler) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/providers", h.ListProviders)
+	r.Get("/{provider}/login", h.Begin)
+	r.Get("/{provider}/callback", h.Callback)
+	r.With(throttle...).Post("/exchange", h.Exchange)
+	return r
+}
+
+func (h *SSOHandler) ListProviders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(h.service.Providers()))
+}
+
+// Begin sends the user to the provider's sign-in page.
+func (h *SSOHandler) Begin(w http.ResponseWriter, r *http.Request) {
+	provider := chi.URLParam(r, "provider")
+	authURL, state, err := h.service.Begin(provider)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrNotFound) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(response.Error("unknown identity provider"))
+			return
+		}
+		log.Printf("SSO sign-in with %s could not start: %v", provider, err)
+		http.Redirect(w, r, h.service.FailureURL("Sign-in with this provider is unavailable right now."), http.StatusFound)
+		return
+	}
+	h.setState(w, state, 0)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback is where the provider sends the user back; it passes them on to
+// the frontend with the outcome.
+func (h *SSOHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	state := ""
+	if c, err := r.Cookie(ssoStateCookie); err == nil {
+		state = c.Value
+	}
+	h.setState(w, "", -1)
+	http.Redirect(w, r, h.service.Callback(chi.URLParam(r, "provider"), r.URL.Query(), state), http.StatusFound)
+}
+
+// Exchange redeems the code the frontend got from Callback for a session.
+func (h *SSOHandler) Exchange(w http.ResponseWriter, r *http.Request) {
+	var input domain.SSOExchangeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response.Error("invalid request body"))
+		return
+	}
+
+	resp, err := h.auth.RedeemSSOLogin(&input, clientInfo(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, appErrors.ErrInvalidInput) {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(response.Error("the sign-in has expired; sign in again"))
+			return
+		}
+		if errors.Is(err, service.ErrAccountDisabled) {
+			writeDisabled(w)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error("sign-in failed"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response.Success(resp))
+}
+
+// setState sets the state cookie, or deletes it with a negative maxAge. Lax
+// lets it ride along on the provider's redirect back, a top-level GET.
+func (h *SSOHandler) setState(w http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    value,
+		Path:     "/api/auth/sso",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.service.SecureCookies(),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
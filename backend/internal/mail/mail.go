@@ -0,0 +1,56 @@
+// Package mail sends the emails the application needs, such as password
+// reset links, through a configurable mailer.
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"job-tracker-backend/internal/config"
+)
+
+const (
+	MailerLog  = "log"
+	MailerSMTP = "smtp"
+)
+
+var ErrUnknownMailer = errors.New("unknown mailer")
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Name() string
+	Send(msg Message) error
+}
+
+// Log writes messages to the server log instead of sending them, for
+// development.
+type Log struct {
+	From string
+}
+
+func (Log) Name() string { return MailerLog }
+
+func (m Log) Send(msg Message) error {
+	log.Printf("mail from %s to %s: %s\n%s", m.From, msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// NewFromConfig returns the mailer selected by MAILER.
+func NewFromConfig(cfg *config.Config) (Mailer, error) {
+	switch cfg.Mailer {
+	case "", MailerLog:
+		return Log{From: cfg.MailFrom}, nil
+	case MailerSMTP:
+		return NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom, time.Duration(cfg.SMTPTimeoutSeconds)*time.Second)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownMailer, cfg.Mailer)
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/response"
+)
+
+// AdminStore reports whether a user is an admin.
+type AdminStore interface {
+	IsAdmin(userID string) (bool, error)
+}
+
+// RequireAdmin lets only admins through. It goes after Authenticate, and
+// looks the role up on every request rather than trusting the access token.
+func RequireAdmin(admins AdminStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := UserIDFromContext(r.Context())
+			admin, err := admins.IsAdmin(userID)
+			if err != nil && !errors.Is(err, appErrors.ErrNotFound) {
+				log.Printf("failed to check the role of %s: %v", userID, err)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(response.Error("failed to check role"))
+				return
+			}
+			if !admin {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(response.Error("admin access required"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -3,18 +3,43 @@ package middleware
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strings"
 
 	"job-tracker-backend/internal/auth"
+	"job-tracker-backend/internal/domain"
+	appErrors "job-tracker-backend/pkg/errors"
 	"job-tracker-backend/pkg/response"
 )
 
 type contextKey string
 
-const UserIDKey contextKey = "userID"
+const (
+	UserIDKey    contextKey = "userID"
+	SessionIDKey contextKey = "sessionID"
+	APITokenKey  contextKey = "apiToken"
+)
+
+// SessionStore reports whether a session is still signed in, so access
+// tokens stop working as soon as their session is revoked rather than when
+// they expire.
+type SessionStore interface {
+	SessionActive(sessionID string) (bool, error)
+}
+
+// APITokenStore looks up personal API tokens, returning ErrNotFound for
+// unknown, revoked and expired ones.
+type APITokenStore interface {
+	AuthenticateAPIToken(token, ip string) (*domain.APIToken, error)
+}
 
-func Authenticate(jwtSecret string) func(http.Handler) http.Handler {
+// Authenticate accepts session access tokens and personal API tokens, told
+// apart by auth.APITokenPrefix. API tokens only reach the endpoints
+// RequiredScope grants them.
+func Authenticate(jwtSecret string, sessions SessionStore, apiTokens APITokenStore) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			header := r.Header.Get("Authorization")
@@ -26,6 +51,10 @@ func Authenticate(jwtSecret string) func(http.Handler) http.Handler {
 			}
 
 			tokenStr := strings.TrimPrefix(header, "Bearer ")
+			if strings.HasPrefix(tokenStr, auth.APITokenPrefix) {
+				authenticateAPIToken(w, r, next, apiTokens, tokenStr)
+				return
+			}
 			claims, err := auth.ValidateToken(tokenStr, jwtSecret)
 			if err != nil {
 				w.Header().Set("Content-Type", "application/json")
@@ -34,13 +63,99 @@ func Authenticate(jwtSecret string) func(http.Handler) http.Handler {
 				return
 			}
 
+			active, err := sessions.SessionActive(claims.SessionID)
+			if err != nil {
+				log.Printf("failed to check session %s: %v", claims.SessionID, err)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(response.Error("failed to check session"))
+				return
+			}
+			if !active {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(response.Error("session has ended"))
+				return
+			}
+
 			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, SessionIDKey, claims.SessionID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+func authenticateAPIToken(w http.ResponseWriter, r *http.Request, next http.Handler, apiTokens APITokenStore, tokenStr string) {
+	token, err := apiTokens.AuthenticateAPIToken(tokenStr, ClientIP(r))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, appErrors.ErrNotFound) {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(response.Error("invalid or expired API token"))
+			return
+		}
+		log.Printf("failed to check API token: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response.Error("failed to check API token"))
+		return
+	}
+
+	scope := RequiredScope(r)
+	if scope == "" || !token.HasScope(scope) {
+		msg := "API tokens can't be used for this endpoint"
+		if scope != "" {
+			msg = fmt.Sprintf("this API token lacks the %s scope", scope)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(response.Error(msg))
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), UserIDKey, token.UserID)
+	ctx = context.WithValue(ctx, APITokenKey, token.ID)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RequiredScope returns the scope an API token needs for the request, or ""
+// for endpoints only signed-in sessions can use, such as managing the
+// account and its tokens.
+func RequiredScope(r *http.Request) string {
+	path := r.URL.Path
+	read := r.Method == http.MethodGet || r.Method == http.MethodHead
+	switch {
+	case strings.HasPrefix(path, "/api/documents"),
+		strings.HasPrefix(path, "/api/jobs/") && strings.Contains(path, "/attachments"):
+		return domain.ScopeAttachments
+	case path == "/api/jobs/search" || strings.HasPrefix(path, "/api/jobs/search/"):
+		return domain.ScopeSearch
+	case path == "/api/jobs" || strings.HasPrefix(path, "/api/jobs/"),
+		path == "/api/export" || strings.HasPrefix(path, "/api/export/"):
+		if read {
+			return domain.ScopeJobsRead
+		}
+		return domain.ScopeJobsWrite
+	case path == "/api/import" || strings.HasPrefix(path, "/api/import/"):
+		return domain.ScopeJobsWrite
+	}
+	return ""
+}
+
 func UserIDFromContext(ctx context.Context) string {
 	id, _ := ctx.Value(UserIDKey).(string)
 	return id
 }
+
+// SessionIDFromContext returns the session of the request's access token,
+// or "" when it was made with an API token.
+func SessionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(SessionIDKey).(string)
+	return id
+}
+
+// APITokenIDFromContext returns the API token the request was made with, or
+// "" when it came from a session.
+func APITokenIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(APITokenKey).(string)
+	return id
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"job-tracker-backend/pkg/ratelimit"
+	"job-tracker-backend/pkg/response"
+)
+
+// KeyFunc names what a request is throttled by, such as its client address.
+// An empty key leaves the request unthrottled.
+type KeyFunc func(r *http.Request) string
+
+// RateLimit refuses requests beyond limit per key with 429 Too Many Requests
+// and a Retry-After header. name separates the buckets of different limits
+// in a shared store.
+func RateLimit(store ratelimit.Store, name string, limit ratelimit.Limit, key KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit.Unlimited() {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if ok, wait := store.Take(name+":"+k, limit, time.Now()); !ok {
+				seconds := int(math.Ceil(wait.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				json.NewEncoder(w).Encode(response.Error(fmt.Sprintf("too many requests; try again in %d seconds", seconds)))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP returns the address of the client, which is the one that
+// connected unless RealIP took it from a trusted proxy's headers.
+func ClientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// ByIP throttles per client address.
+func ByIP(r *http.Request) string {
+	return ClientIP(r)
+}
+
+// ByUser throttles per signed-in user. It must run after Authenticate.
+func ByUser(r *http.Request) string {
+	return UserIDFromContext(r.Context())
+}
+
+// maxPeekSize bounds how much of a body ByEmail reads.
+const maxPeekSize = 64 << 10
+
+// ByEmail throttles per account, named by the email field of a JSON body,
+// so guessing one account's password from many addresses is still limited.
+// The body is left for the handler to read.
+func ByEmail(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPeekSize))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil {
+		return ""
+	}
+	var input struct {
+		Email string `json:"email"`
+	}
+	if json.Unmarshal(body, &input) != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(input.Email))
+}
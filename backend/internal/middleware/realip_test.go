@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8, 192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		realIP     string
+		forwarded  []string
+		want       string
+	}{
+		{
+			name:       "direct",
+			remoteAddr: "203.0.113.7:51234",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "direct with forged headers",
+			remoteAddr: "203.0.113.7:51234",
+			realIP:     "198.51.100.1",
+			forwarded:  []string{"198.51.100.1"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "proxied",
+			remoteAddr: "10.1.2.3:40000",
+			realIP:     "203.0.113.7",
+			forwarded:  []string{"203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "proxied with a forged hop",
+			remoteAddr: "10.1.2.3:40000",
+			forwarded:  []string{"198.51.100.1, 203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "through a chain of proxies",
+			remoteAddr: "192.0.2.1:40000",
+			forwarded:  []string{"198.51.100.1, 203.0.113.7", "10.9.9.9"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "proxied with X-Real-IP only",
+			remoteAddr: "10.1.2.3:40000",
+			realIP:     "203.0.113.7",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "proxied over IPv6",
+			remoteAddr: "10.1.2.3:40000",
+			forwarded:  []string{"2001:db8::1"},
+			want:       "2001:db8::1",
+		},
+		{
+			name:       "garbled hop",
+			remoteAddr: "10.1.2.3:40000",
+			forwarded:  []string{"203.0.113.7, not-an-ip, 10.2.2.2"},
+			want:       "10.2.2.2",
+		},
+		{
+			name:       "proxy naming no client",
+			remoteAddr: "10.1.2.3:40000",
+			want:       "10.1.2.3",
+		},
+		{
+			name:       "untrusted neighbour of a trusted address",
+			remoteAddr: "192.0.2.2:40000",
+			forwarded:  []string{"198.51.100.1"},
+			want:       "192.0.2.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r)
+			}))
+			r := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			for _, v := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRealIPWithoutTrustedProxies(t *testing.T) {
+	var got string
+	h := RealIP(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = ClientIP(r)
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.1.2.3:40000"
+	r.Header.Set("X-Real-IP", "203.0.113.7")
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if got != "10.1.2.3" {
+		t.Errorf("ClientIP() = %q, want the connecting address", got)
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		list string
+		ok   bool
+	}{
+		{list: "", ok: true},
+		{list: "10.0.0.1, 172.16.0.0/12,", ok: true},
+		{list: "::ffff:10.0.0.1", ok: true},
+		{list: "10.0.0.0/33"},
+		{list: "proxy.internal"},
+	}
+	for _, tt := range tests {
+		_, err := ParseTrustedProxies(tt.list)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseTrustedProxies(%q) error = %v, want ok %v", tt.list, err, tt.ok)
+		}
+	}
+}
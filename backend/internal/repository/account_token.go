@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	appErrors "job-tracker-backend/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type AccountTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountTokenRepository(db *gorm.DB) *AccountTokenRepository {
+	return &AccountTokenRepository{db: db}
+}
+
+func (r *AccountTokenRepository) Create(token *domain.AccountToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetUsable returns the unused, unexpired token for purpose that hashes to
+// hash.
+func (r *AccountTokenRepository) GetUsable(hash, purpose string, now time.Time) (*domain.AccountToken, error) {
+	var token domain.AccountToken
+	err := r.db.Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hash, purpose, now).
+		First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Use marks a token used. It returns ErrNotFound if it already was, so a
+// token can't be redeemed twice by concurrent requests.
+func (r *AccountTokenRepository) Use(id string, at time.Time) error {
+	result := r.db.Model(&domain.AccountToken{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteForUser removes the user's tokens for purpose, used or not, so a new
+// one replaces those sent before.
+func (r *AccountTokenRepository) DeleteForUser(userID, purpose string) error {
+	return r.db.Where("user_id = ? AND purpose = ?", userID, purpose).Delete(&domain.AccountToken{}).Error
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	appErrors "job-tracker-backend/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type APITokenRepository struct {
+	db *gorm.DB
+}
+
+func NewAPITokenRepository(db *gorm.DB) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+func (r *APITokenRepository) Create(token *domain.APIToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *APITokenRepository) GetByHash(hash string) (*domain.APIToken, error) {
+	var token domain.APIToken
+	if err := r.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetAll returns the user's tokens, newest first.
+func (r *APITokenRepository) GetAll(userID string) ([]domain.APIToken, error) {
+	var tokens []domain.APIToken
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *APITokenRepository) Count(userID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.APIToken{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// Touch records that the token was used at, from ip, at most once a minute.
+func (r *APITokenRepository) Touch(id, ip string, at time.Time) error {
+	return r.db.Model(&domain.APIToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, at.Add(-time.Minute)).
+		Updates(map[string]interface{}{"last_used_at": at, "last_used_ip": ip}).Error
+}
+
+func (r *APITokenRepository) Delete(id, userID string) error {
+	result := r.db.Delete(&domain.APIToken{}, "id = ? AND user_id = ?", id, userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.ErrNotFound
+	}
+	return nil
+}
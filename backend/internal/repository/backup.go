@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"job-tracker-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// restoreBatchSize keeps multi-row inserts well under the database's limit
+// on bind parameters.
+const restoreBatchSize = 100
+
+type BackupRepository struct {
+	db *gorm.DB
+}
+
+func NewBackupRepository(db *gorm.DB) *BackupRepository {
+	return &BackupRepository{db: db}
+}
+
+// GetNotifications returns all of the user's notifications, oldest first.
+func (r *BackupRepository) GetNotifications(userID string) ([]domain.Notification, error) {
+	var notifications []domain.Notification
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&notifications).Error
+	return notifications, err
+}
+
+// GetWatchedPostings returns the postings seen by the given watches.
+func (r *BackupRepository) GetWatchedPostings(watchIDs []string) ([]domain.WatchedPosting, error) {
+	var postings []domain.WatchedPosting
+	if len(watchIDs) == 0 {
+		return postings, nil
+	}
+	err := r.db.Where("watch_id IN ?", watchIDs).Order("first_seen_at").Find(&postings).Error
+	return postings, err
+}
+
+// Restore inserts every row of a restored archive in one transaction, so a
+// failed restore leaves nothing behind. Rows must already have their IDs.
+// The ranking profile and preference model are left alone if the user
+// saved their own in the meantime.
+func (r *BackupRepository) Restore(set *domain.RestoreSet) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		batches := []struct {
+			n    int
+			rows interface{}
+		}{
+			{len(set.Documents), set.Documents},
+			{len(set.DocumentVersions), set.DocumentVersions},
+			{len(set.Jobs), set.Jobs},
+			{len(set.Attachments), set.Attachments},
+			{len(set.StatusChanges), set.StatusChanges},
+			{len(set.Watches), set.Watches},
+			{len(set.WatchedPostings), set.WatchedPostings},
+			{len(set.Notifications), set.Notifications},
+		}
+		for _, b := range batches {
+			if b.n == 0 {
+				continue
+			}
+			if err := tx.CreateInBatches(b.rows, restoreBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if set.RankingProfile != nil {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(set.RankingProfile).Error; err != nil {
+				return err
+			}
+		}
+		if set.PreferenceModel != nil {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(set.PreferenceModel).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
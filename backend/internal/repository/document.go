@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"errors"
+
+	"job-tracker-backend/internal/domain"
+	appErrors "job-tracker-backend/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type DocumentRepository struct {
+	db *gorm.DB
+}
+
+func NewDocumentRepository(db *gorm.DB) *DocumentRepository {
+	return &DocumentRepository{db: db}
+}
+
+func (r *DocumentRepository) Create(doc *domain.Document) error {
+	return r.db.Create(doc).Error
+}
+
+func (r *DocumentRepository) GetByID(id, userID string) (*domain.Document, error) {
+	var doc domain.Document
+	err := r.db.Preload("Versions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("version DESC")
+	}).Where("id = ? AND user_id = ?", id, userID).First(&doc).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrNotFound
+		}
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (r *DocumentRepository) GetAll(userID string) ([]domain.Document, error) {
+	var docs []domain.Document
+	err := r.db.Preload("Versions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("version DESC")
+	}).Where("user_id = ?", userID).Order("updated_at DESC").Find(&docs).Error
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (r *DocumentRepository) Touch(id string) error {
+	return r.db.Model(&domain.Document{}).Where("id = ?", id).Update("updated_at", gorm.Expr("CURRENT_TIMESTAMP")).Error
+}
+
+func (r *DocumentRepository) Delete(id string) error {
+	result := r.db.Delete(&domain.Document{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.ErrNotFound
+	}
+	return nil
+}
+
+// Version methods
+
+func (r *DocumentRepository) CreateVersion(version *domain.DocumentVersion) error {
+	return r.db.Create(version).Error
+}
+
+func (r *DocumentRepository) GetVersion(id, userID string) (*domain.DocumentVersion, error) {
+	var version domain.DocumentVersion
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&version).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrNotFound
+		}
+		return nil, err
+	}
+	return &version, nil
+}
+
+// GetVersionByHash finds a version of any of the user's documents with the
+// given content hash.
+func (r *DocumentRepository) GetVersionByHash(userID, contentHash string) (*domain.DocumentVersion, error) {
+	var version domain.DocumentVersion
+	if err := r.db.Where("user_id = ? AND content_hash = ?", userID, contentHash).Order("created_at").First(&version).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrNotFound
+		}
+		return nil, err
+	}
+	return &version, nil
+}
+
+func (r *DocumentRepository) NextVersionNumber(documentID string) (int, error) {
+	var max int
+	if err := r.db.Model(&domain.DocumentVersion{}).Where("document_id = ?", documentID).
+		Select("COALESCE(MAX(version), 0)").Scan(&max).Error; err != nil {
+		return 0, err
+	}
+	return max + 1, nil
+}
+
+func (r *DocumentRepository) CountVersions(documentID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.DocumentVersion{}).Where("document_id = ?", documentID).Count(&count).Error
+	return count, err
+}
+
+func (r *DocumentRepository) DeleteVersion(id string) error {
+	result := r.db.Delete(&domain.DocumentVersion{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.ErrNotFound
+	}
+	return nil
+}
+
+// CountLinks returns how many attachments link to each of the given
+// versions. Versions without links are absent from the map.
+func (r *DocumentRepository) CountLinks(versionIDs []string) (map[string]int64, error) {
+	var rows []struct {
+		DocumentVersionID string
+		Count             int64
+	}
+	result := make(map[string]int64)
+	if len(versionIDs) == 0 {
+		return result, nil
+	}
+	err := r.db.Model(&domain.Attachment{}).
+		Select("document_version_id, COUNT(*) AS count").
+		Where("document_version_id IN ?", versionIDs).
+		Group("document_version_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.DocumentVersionID] = row.Count
+	}
+	return result, nil
+}
+
+// GetUsages lists the jobs a version has been attached to, newest first.
+func (r *DocumentRepository) GetUsages(versionID string) ([]domain.DocumentVersionUsage, error) {
+	var usages []domain.DocumentVersionUsage
+	err := r.db.Table("attachments").
+		Select("attachments.id AS attachment_id, jobs.id AS job_id, jobs.job_title, jobs.company_name, jobs.status, attachments.created_at AS attached_at").
+		Joins("JOIN jobs ON jobs.id = attachments.job_id").
+		Where("attachments.document_version_id = ?", versionID).
+		Order("attachments.created_at DESC").
+		Scan(&usages).Error
+	if err != nil {
+		return nil, err
+	}
+	return usages, nil
+}
+
+func (r *DocumentRepository) GetVersionsByBackend(backend string) ([]domain.DocumentVersion, error) {
+	var versions []domain.DocumentVersion
+	if err := r.db.Where("storage_backend = ?", backend).Order("created_at").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (r *DocumentRepository) GetVersionsByUser(userID string) ([]domain.DocumentVersion, error) {
+	var versions []domain.DocumentVersion
+	if err := r.db.Omit("extracted_text").Where("user_id = ?", userID).Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// UpdateVersionBackend moves a version, and every attachment linking to it,
+// over to another storage backend.
+func (r *DocumentRepository) UpdateVersionBackend(id, backend string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.DocumentVersion{}).Where("id = ?", id).Update("storage_backend", backend).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.Attachment{}).Where("document_version_id = ?", id).Update("storage_backend", backend).Error
+	})
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	appErrors "job-tracker-backend/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type ExternalIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewExternalIdentityRepository(db *gorm.DB) *ExternalIdentityRepository {
+	return &ExternalIdentityRepository{db: db}
+}
+
+func (r *ExternalIdentityRepository) Create(identity *domain.ExternalIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+func (r *ExternalIdentityRepository) Get(provider, subject string) (*domain.ExternalIdentity, error) {
+	var identity domain.ExternalIdentity
+	if err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrNotFound
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// Touch records a sign-in with the identity and the email it came with.
+func (r *ExternalIdentityRepository) Touch(id, email string, at time.Time) error {
+	return r.db.Model(&domain.ExternalIdentity{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"email": email, "last_login_at": at}).Error
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"errors"
+	"time"
+
 	"job-tracker-backend/internal/domain"
 	appErrors "job-tracker-backend/pkg/errors"
 
@@ -33,19 +35,7 @@ func (r *JobRepository) GetByID(id, userID string) (*domain.Job, error) {
 
 func (r *JobRepository) GetAll(filter *domain.JobFilter) ([]domain.Job, error) {
 	var jobs []domain.Job
-	query := r.db.Model(&domain.Job{})
-
-	if filter != nil {
-		if filter.UserID != "" {
-			query = query.Where("user_id = ?", filter.UserID)
-		}
-		if filter.Status != "" {
-			query = query.Where("status = ?", filter.Status)
-		}
-		if filter.Source != "" {
-			query = query.Where("source = ?", filter.Source)
-		}
-	}
+	query := r.filtered(filter)
 
 	if err := query.Order("created_at DESC").Find(&jobs).Error; err != nil {
 		return nil, err
@@ -58,7 +48,7 @@ func (r *JobRepository) GetAll(filter *domain.JobFilter) ([]domain.Job, error) {
 			jobIDs[i] = job.ID
 		}
 		var attachments []domain.Attachment
-		if err := r.db.Where("job_id IN ?", jobIDs).Find(&attachments).Error; err != nil {
+		if err := r.db.Omit("data", "extracted_text").Where("job_id IN ?", jobIDs).Find(&attachments).Error; err != nil {
 			return nil, err
 		}
 		// Attachments to jobs
@@ -74,21 +64,137 @@ func (r *JobRepository) GetAll(filter *domain.JobFilter) ([]domain.Job, error) {
 	return jobs, nil
 }
 
-func (r *JobRepository) Update(job *domain.Job) error {
-	return r.db.Save(job).Error
+// jobSearchDocument is the text of a job that full-text search matches
+// against. It must stay in sync with the index created in database.Migrate.
+const jobSearchDocument = "coalesce(job_title, '') || ' ' || coalesce(company_name, '') || ' ' || coalesce(location, '') || ' ' || coalesce(description, '') || ' ' || coalesce(notes, '')"
+
+func (r *JobRepository) filtered(filter *domain.JobFilter) *gorm.DB {
+	query := r.db.Model(&domain.Job{})
+
+	if filter != nil {
+		if filter.UserID != "" {
+			query = query.Where("user_id = ?", filter.UserID)
+		}
+		if filter.Status != "" {
+			query = query.Where("status = ?", filter.Status)
+		}
+		if filter.Source != "" {
+			query = query.Where("source = ?", filter.Source)
+		}
+		if filter.Query != "" {
+			query = query.Where(
+				"to_tsvector('english', "+jobSearchDocument+") @@ websearch_to_tsquery('english', ?) OR EXISTS ("+
+					"SELECT 1 FROM attachments WHERE attachments.job_id = jobs.id AND "+
+					"to_tsvector('english', coalesce(attachments.extracted_text, '')) @@ websearch_to_tsquery('english', ?))",
+				filter.Query, filter.Query,
+			)
+		}
+		if filter.MaxYears != nil {
+			query = query.Where("req_min_years_experience IS NULL OR req_min_years_experience <= ?", *filter.MaxYears)
+		}
+		if len(filter.Seniority) > 0 {
+			query = query.Where("req_seniority IN ?", filter.Seniority)
+		}
+		for _, skill := range filter.Skills {
+			query = query.Where(
+				"EXISTS (SELECT 1 FROM jsonb_array_elements_text(coalesce(jobs.req_skills, '[]'::jsonb)) AS skill WHERE lower(skill) = lower(?))",
+				skill,
+			)
+		}
+		if filter.Closed != nil {
+			if *filter.Closed {
+				query = query.Where("closed_at IS NOT NULL")
+			} else {
+				query = query.Where("closed_at IS NULL")
+			}
+		}
+		for _, exclude := range filter.Exclude {
+			switch exclude {
+			case domain.ExcludeCitizenship:
+				query = query.Where("NOT coalesce(req_citizenship_required, false)")
+			case domain.ExcludeClearance:
+				query = query.Where("coalesce(req_clearance, '') = ''")
+			case domain.ExcludeNoSponsorship:
+				query = query.Where("coalesce(req_visa_sponsorship, '') <> ?", "unavailable")
+			}
+		}
+	}
+	return query
 }
 
-func (r *JobRepository) Delete(id, userID string) error {
-	result := r.db.Delete(&domain.Job{}, "id = ? AND user_id = ?", id, userID)
-	if result.Error != nil {
-		return result.Error
+// Stream walks the jobs matching filter with a single cursor, handing them to
+// fn in batches of batchSize so callers never hold the full result set.
+func (r *JobRepository) Stream(filter *domain.JobFilter, batchSize int, fn func([]domain.Job) error) error {
+	rows, err := r.filtered(filter).Order("created_at DESC").Rows()
+	if err != nil {
+		return err
 	}
-	if result.RowsAffected == 0 {
-		return appErrors.ErrNotFound
+	defer rows.Close()
+
+	batch := make([]domain.Job, 0, batchSize)
+	for rows.Next() {
+		var job domain.Job
+		if err := r.db.ScanRows(rows, &job); err != nil {
+			return err
+		}
+		batch = append(batch, job)
+		if len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([]domain.Job, 0, batchSize)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return fn(batch)
 	}
 	return nil
 }
 
+// GetScheduled returns the user's jobs that have an interview, follow-up or
+// deadline date set.
+func (r *JobRepository) GetScheduled(userID string) ([]domain.Job, error) {
+	var jobs []domain.Job
+	err := r.db.Where("user_id = ?", userID).
+		Where("interview_at IS NOT NULL OR follow_up_at IS NOT NULL OR deadline_at IS NOT NULL").
+		Order("created_at DESC").
+		Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *JobRepository) Update(job *domain.Job) error {
+	return r.db.Save(job).Error
+}
+
+// Delete removes the user's job with its attachments and status history,
+// in one transaction. The contents of attachments holding their own files
+// must be deleted from storage first.
+func (r *JobRepository) Delete(id, userID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		jobIDs := tx.Model(&domain.Job{}).Select("id").Where("id = ? AND user_id = ?", id, userID)
+		if err := tx.Where("job_id IN (?)", jobIDs).Delete(&domain.Attachment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("job_id IN (?)", jobIDs).Delete(&domain.StatusChange{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&domain.Job{}, "id = ? AND user_id = ?", id, userID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return appErrors.ErrNotFound
+		}
+		return nil
+	})
+}
+
 func (r *JobRepository) ExistsByURL(url, userID string) (bool, error) {
 	var count int64
 	if err := r.db.Model(&domain.Job{}).Where("job_url = ? AND user_id = ?", url, userID).Count(&count).Error; err != nil {
@@ -97,6 +203,104 @@ func (r *JobRepository) ExistsByURL(url, userID string) (bool, error) {
 	return count > 0, nil
 }
 
+// HasDuplicate reports whether the user already has job: one with the same
+// URL or, when it has none, the same title, company and creation time.
+func (r *JobRepository) HasDuplicate(job *domain.Job) (bool, error) {
+	query := r.db.Model(&domain.Job{}).Where("user_id = ?", job.UserID)
+	if job.JobURL != "" {
+		query = query.Where("job_url = ?", job.JobURL)
+	} else {
+		query = query.Where("COALESCE(job_url, '') = '' AND job_title = ? AND company_name = ? AND created_at = ?", job.JobTitle, job.CompanyName, job.CreatedAt)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// UpdateRequirements stores a job's analyzed requirements without touching
+// its updated timestamp.
+func (r *JobRepository) UpdateRequirements(id string, req *domain.JobRequirements) error {
+	return r.db.Model(&domain.Job{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"req_skills":               req.Skills,
+		"req_min_years_experience": req.MinYearsExperience,
+		"req_seniority":            req.Seniority,
+		"req_visa_sponsorship":     req.VisaSponsorship,
+		"req_citizenship_required": req.CitizenshipRequired,
+		"req_clearance":            req.Clearance,
+		"req_education":            req.Education,
+		"req_analyzed_at":          req.AnalyzedAt,
+	}).Error
+}
+
+// UpdateDescription stores a job's sanitized and raw description without
+// touching its updated timestamp.
+func (r *JobRepository) UpdateDescription(id, description, raw, format string) error {
+	return r.db.Model(&domain.Job{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"description":        description,
+		"description_raw":    raw,
+		"description_format": format,
+	}).Error
+}
+
+// GetDescriptions returns the descriptions of the user's most recent jobs.
+func (r *JobRepository) GetDescriptions(userID string, limit int) ([]string, error) {
+	var descriptions []string
+	err := r.db.Model(&domain.Job{}).
+		Where("user_id = ? AND description <> ''", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Pluck("description", &descriptions).Error
+	return descriptions, err
+}
+
+// GetDueForCheck returns up to limit open postings, across all users, not
+// checked since before, least recently checked first. Only the fields the
+// liveness checker needs are loaded.
+func (r *JobRepository) GetDueForCheck(before time.Time, limit int) ([]domain.Job, error) {
+	var jobs []domain.Job
+	err := r.db.Select("id", "user_id", "job_title", "company_name", "job_url", "status", "last_checked_at").
+		Where("closed_at IS NULL AND job_url LIKE 'http%'").
+		Where("last_checked_at IS NULL OR last_checked_at < ?", before).
+		Order("last_checked_at NULLS FIRST").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// UpdateLiveness stores the outcome of a liveness check without touching the
+// job's updated timestamp.
+func (r *JobRepository) UpdateLiveness(id string, checkedAt time.Time, closedAt *time.Time, result string) error {
+	return r.db.Model(&domain.Job{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"last_checked_at":   checkedAt,
+		"closed_at":         closedAt,
+		"last_check_result": result,
+	}).Error
+}
+
+// GetByStatuses returns the title, company, description and status of the
+// user's jobs in the given statuses.
+func (r *JobRepository) GetByStatuses(userID string, statuses []string) ([]domain.Job, error) {
+	var jobs []domain.Job
+	err := r.db.Select("id", "job_title", "company_name", "description", "status").
+		Where("user_id = ? AND status IN ?", userID, statuses).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// GetUsersChangedSinceTraining lists the users who have edited a job since
+// their preference model was last trained, or who have jobs but no model.
+func (r *JobRepository) GetUsersChangedSinceTraining() ([]string, error) {
+	var userIDs []string
+	err := r.db.Model(&domain.Job{}).
+		Joins("LEFT JOIN preference_models ON preference_models.user_id = jobs.user_id").
+		Group("jobs.user_id, preference_models.trained_at").
+		Having("preference_models.trained_at IS NULL OR MAX(jobs.updated_at) > preference_models.trained_at").
+		Pluck("jobs.user_id", &userIDs).Error
+	return userIDs, err
+}
+
 func (r *JobRepository) CreateBatch(jobs []domain.Job) error {
 	if len(jobs) == 0 {
 		return nil
@@ -112,7 +316,7 @@ func (r *JobRepository) CreateAttachment(attachment *domain.Attachment) error {
 
 func (r *JobRepository) GetAttachmentByID(id string) (*domain.Attachment, error) {
 	var attachment domain.Attachment
-	if err := r.db.First(&attachment, "id = ?", id).Error; err != nil {
+	if err := r.db.Omit("data").First(&attachment, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, appErrors.ErrNotFound
 		}
@@ -123,7 +327,7 @@ func (r *JobRepository) GetAttachmentByID(id string) (*domain.Attachment, error)
 
 func (r *JobRepository) GetAttachmentsByJobID(jobID string) ([]domain.Attachment, error) {
 	var attachments []domain.Attachment
-	if err := r.db.Where("job_id = ?", jobID).Order("created_at DESC").Find(&attachments).Error; err != nil {
+	if err := r.db.Omit("data", "extracted_text").Where("job_id = ?", jobID).Order("created_at DESC").Find(&attachments).Error; err != nil {
 		return nil, err
 	}
 	return attachments, nil
@@ -140,6 +344,80 @@ func (r *JobRepository) DeleteAttachment(id string) error {
 	return nil
 }
 
+// GetAttachmentsByBackend returns metadata of every attachment whose own
+// contents live in the named storage backend. Attachments linking to a
+// document version are left out; they move with the version.
+func (r *JobRepository) GetAttachmentsByBackend(backend string) ([]domain.Attachment, error) {
+	var attachments []domain.Attachment
+	if err := r.db.Omit("data", "extracted_text").Where("storage_backend = ? AND document_version_id IS NULL", backend).Order("created_at").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// GetAttachmentsByUser returns metadata of every attachment of the user's
+// jobs that holds its own contents.
+func (r *JobRepository) GetAttachmentsByUser(userID string) ([]domain.Attachment, error) {
+	var attachments []domain.Attachment
+	err := r.db.Omit("data", "extracted_text").
+		Where("document_version_id IS NULL AND job_id IN (?)", r.db.Model(&domain.Job{}).Select("id").Where("user_id = ?", userID)).
+		Find(&attachments).Error
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (r *JobRepository) UpdateAttachmentContent(id string, size int64, contentHash string) error {
+	return r.db.Model(&domain.Attachment{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"file_size":    size,
+		"content_hash": contentHash,
+	}).Error
+}
+
+func (r *JobRepository) UpdateAttachmentText(id, text string, extractedAt time.Time) error {
+	return r.db.Model(&domain.Attachment{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"extracted_text":    text,
+		"text_extracted_at": extractedAt,
+	}).Error
+}
+
+func (r *JobRepository) UpdateAttachmentBackend(id, backend string) error {
+	return r.db.Model(&domain.Attachment{}).Where("id = ?", id).Update("storage_backend", backend).Error
+}
+
 func (r *JobRepository) DeleteAttachmentsByJobID(jobID string) error {
 	return r.db.Where("job_id = ?", jobID).Delete(&domain.Attachment{}).Error
 }
+
+// GetAttachmentMetadataByJobIDs returns attachments without their file data,
+// grouped by job ID.
+func (r *JobRepository) GetAttachmentMetadataByJobIDs(jobIDs []string) (map[string][]domain.Attachment, error) {
+	var attachments []domain.Attachment
+	if err := r.db.Omit("data", "extracted_text").Where("job_id IN ?", jobIDs).Order("created_at").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string][]domain.Attachment)
+	for _, att := range attachments {
+		result[att.JobID] = append(result[att.JobID], att)
+	}
+	return result, nil
+}
+
+// Status history methods
+
+func (r *JobRepository) CreateStatusChange(change *domain.StatusChange) error {
+	return r.db.Create(change).Error
+}
+
+func (r *JobRepository) GetStatusHistoryByJobIDs(jobIDs []string) (map[string][]domain.StatusChange, error) {
+	var changes []domain.StatusChange
+	if err := r.db.Where("job_id IN ?", jobIDs).Order("changed_at").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string][]domain.StatusChange)
+	for _, c := range changes {
+		result[c.JobID] = append(result[c.JobID], c)
+	}
+	return result, nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"time"
+
+	"job-tracker-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type LoginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+func (r *LoginAttemptRepository) Create(attempt *domain.LoginAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+// GetForUser returns the user's most recent sign-in attempts, newest first.
+func (r *LoginAttemptRepository) GetForUser(userID string, limit int) ([]domain.LoginAttempt, error) {
+	var attempts []domain.LoginAttempt
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&attempts).Error
+	return attempts, err
+}
+
+// DeleteBefore removes attempts older than cutoff.
+func (r *LoginAttemptRepository) DeleteBefore(cutoff time.Time) error {
+	return r.db.Where("created_at < ?", cutoff).Delete(&domain.LoginAttempt{}).Error
+}
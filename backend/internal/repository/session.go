@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	appErrors "job-tracker-backend/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+func (r *SessionRepository) Create(session *domain.Session) error {
+	return r.db.Create(session).Error
+}
+
+// GetByTokenHash returns the session whose current refresh token hashes to
+// hash.
+func (r *SessionRepository) GetByTokenHash(hash string) (*domain.Session, error) {
+	return r.first("refresh_token_hash = ?", hash)
+}
+
+// GetByPreviousTokenHash returns the session whose last replaced refresh
+// token hashes to hash.
+func (r *SessionRepository) GetByPreviousTokenHash(hash string) (*domain.Session, error) {
+	return r.first("previous_token_hash = ?", hash)
+}
+
+func (r *SessionRepository) first(query string, args ...interface{}) (*domain.Session, error) {
+	var session domain.Session
+	if err := r.db.Where(query, args...).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetAll returns the user's unexpired sessions, most recently used first.
+func (r *SessionRepository) GetAll(userID string, now time.Time) ([]domain.Session, error) {
+	var sessions []domain.Session
+	err := r.db.Where("user_id = ? AND expires_at > ?", userID, now).Order("last_used_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// IsActive reports whether the session exists and hasn't expired.
+func (r *SessionRepository) IsActive(id string, now time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&domain.Session{}).Where("id = ? AND expires_at > ?", id, now).Count(&count).Error
+	return count > 0, err
+}
+
+// Rotate replaces the session's refresh token, provided it is still
+// oldHash, so two requests can't both rotate the same token. It returns
+// ErrNotFound if the token was rotated or the session revoked meanwhile.
+func (r *SessionRepository) Rotate(session *domain.Session, oldHash string) error {
+	result := r.db.Model(&domain.Session{}).
+		Where("id = ? AND refresh_token_hash = ?", session.ID, oldHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  session.RefreshTokenHash,
+			"previous_token_hash": oldHash,
+			"user_agent":          session.UserAgent,
+			"device":              session.Device,
+			"ip":                  session.IP,
+			"last_used_at":        session.LastUsedAt,
+			"expires_at":          session.ExpiresAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.ErrNotFound
+	}
+	return nil
+}
+
+// Touch records that the session was used at, at most once a minute.
+func (r *SessionRepository) Touch(id string, at time.Time) error {
+	return r.db.Model(&domain.Session{}).
+		Where("id = ? AND last_used_at < ?", id, at.Add(-time.Minute)).
+		Update("last_used_at", at).Error
+}
+
+func (r *SessionRepository) Delete(id, userID string) error {
+	result := r.db.Delete(&domain.Session{}, "id = ? AND user_id = ?", id, userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteAll revokes every session of the user except keepID, which may be
+// empty.
+func (r *SessionRepository) DeleteAll(userID, keepID string) error {
+	return r.db.Where("user_id = ? AND id <> ?", userID, keepID).Delete(&domain.Session{}).Error
+}
+
+func (r *SessionRepository) DeleteExpired(userID string, now time.Time) error {
+	return r.db.Where("user_id = ? AND expires_at <= ?", userID, now).Delete(&domain.Session{}).Error
+}
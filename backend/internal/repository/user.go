@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"time"
 
 	"job-tracker-backend/internal/domain"
 	appErrors "job-tracker-backend/pkg/errors"
@@ -43,6 +44,247 @@ func (r *UserRepository) GetByID(id string) (*domain.User, error) {
 	return &user, nil
 }
 
+// UpdatePasswordHash sets a new password, which satisfies a password reset
+// an admin required.
 func (r *UserRepository) UpdatePasswordHash(id, hash string) error {
-	return r.db.Model(&domain.User{}).Where("id = ?", id).Update("password_hash", hash).Error
+	return r.db.Model(&domain.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password_hash":           hash,
+		"password_reset_required": false,
+	}).Error
+}
+
+// MarkEmailVerified records that the user verified their email, keeping
+// the first time they did.
+func (r *UserRepository) MarkEmailVerified(id string, at time.Time) error {
+	return r.db.Model(&domain.User{}).Where("id = ?", id).
+		Update("email_verified_at", gorm.Expr("COALESCE(email_verified_at, ?)", at)).Error
+}
+
+// RecordLoginFailure counts a wrong password and locks sign-in until
+// lockedUntil, if set.
+func (r *UserRepository) RecordLoginFailure(id string, lockedUntil *time.Time) error {
+	return r.db.Model(&domain.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"failed_logins": gorm.Expr("failed_logins + 1"),
+		"locked_until":  lockedUntil,
+	}).Error
+}
+
+// ResetLoginFailures clears the failure count and any lock.
+func (r *UserRepository) ResetLoginFailures(id string) error {
+	return r.db.Model(&domain.User{}).Where("id = ? AND (failed_logins > 0 OR locked_until IS NOT NULL)", id).
+		Updates(map[string]interface{}{"failed_logins": 0, "locked_until": nil}).Error
+}
+
+// SetTOTP stores an authenticator secret, enabled from enabledAt or awaiting
+// confirmation if nil. An empty secret turns two-factor sign-in off.
+func (r *UserRepository) SetTOTP(id, secret string, enabledAt *time.Time) error {
+	return r.db.Model(&domain.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"totp_secret":     secret,
+		"totp_enabled_at": enabledAt,
+		"totp_last_step":  0,
+	}).Error
+}
+
+// UseTOTPStep records that a code of step was accepted, provided no code of
+// that or a later step was, and reports whether it was.
+func (r *UserRepository) UseTOTPStep(id string, step int64) (bool, error) {
+	result := r.db.Model(&domain.User{}).Where("id = ? AND totp_last_step < ?", id, step).Update("totp_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *UserRepository) GetByCalendarTokenHash(hash string) (*domain.User, error) {
+	var user domain.User
+	if hash == "" {
+		return nil, appErrors.ErrNotFound
+	}
+	if err := r.db.Where("calendar_token_hash = ?", hash).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *UserRepository) UpdateCalendarTokenHash(id, hash string) error {
+	return r.db.Model(&domain.User{}).Where("id = ?", id).Update("calendar_token_hash", hash).Error
+}
+
+// GetRankingProfile returns the user's ranking profile, or ErrNotFound if
+// they haven't saved one.
+func (r *UserRepository) GetRankingProfile(userID string) (*domain.RankingProfile, error) {
+	var profile domain.RankingProfile
+	if err := r.db.First(&profile, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrNotFound
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *UserRepository) SaveRankingProfile(profile *domain.RankingProfile) error {
+	return r.db.Save(profile).Error
+}
+
+// GetPreferenceModel returns the user's preference model, or ErrNotFound if
+// none has been trained yet.
+func (r *UserRepository) GetPreferenceModel(userID string) (*domain.PreferenceModel, error) {
+	var model domain.PreferenceModel
+	if err := r.db.First(&model, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrNotFound
+		}
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *UserRepository) SavePreferenceModel(model *domain.PreferenceModel) error {
+	return r.db.Save(model).Error
+}
+
+// List returns the users whose email contains query, oldest first.
+func (r *UserRepository) List(query string) ([]domain.User, error) {
+	var users []domain.User
+	db := r.db.Order("created_at")
+	if query != "" {
+		db = db.Where("POSITION(LOWER(?) IN email) > 0", query)
+	}
+	err := db.Find(&users).Error
+	return users, err
+}
+
+func (r *UserRepository) SetRole(id, role string) error {
+	return r.updateOne(id, map[string]interface{}{"role": role})
+}
+
+// SetDisabled disables the account as of at, or enables it when at is nil.
+func (r *UserRepository) SetDisabled(id string, at *time.Time) error {
+	return r.updateOne(id, map[string]interface{}{"disabled_at": at})
+}
+
+func (r *UserRepository) RequirePasswordReset(id string) error {
+	return r.updateOne(id, map[string]interface{}{"password_reset_required": true})
+}
+
+func (r *UserRepository) updateOne(id string, fields map[string]interface{}) error {
+	result := r.db.Model(&domain.User{}).Where("id = ?", id).Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return appErrors.ErrNotFound
+	}
+	return nil
+}
+
+// CountJobs returns how many jobs each of the given users has. Users without
+// jobs are absent from the map.
+func (r *UserRepository) CountJobs(ids []string) (map[string]int64, error) {
+	var rows []struct {
+		UserID string
+		Count  int64
+	}
+	err := r.db.Model(&domain.Job{}).
+		Select("user_id, COUNT(*) AS count").
+		Where("user_id IN ?", ids).
+		Group("user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		result[row.UserID] = row.Count
+	}
+	return result, nil
+}
+
+// StorageUsage returns the file storage each of the given users takes up.
+// Users without files are absent from the map.
+func (r *UserRepository) StorageUsage(ids []string) (map[string]domain.StorageUsage, error) {
+	type usageRow struct {
+		UserID string
+		Count  int64
+		Bytes  int64
+	}
+	var attachments, versions []usageRow
+	err := r.db.Table("attachments").
+		Select("jobs.user_id, COUNT(*) AS count, COALESCE(SUM(attachments.file_size), 0) AS bytes").
+		Joins("JOIN jobs ON jobs.id = attachments.job_id").
+		Where("jobs.user_id IN ? AND attachments.document_version_id IS NULL", ids).
+		Group("jobs.user_id").
+		Scan(&attachments).Error
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.Model(&domain.DocumentVersion{}).
+		Select("user_id, COUNT(*) AS count, COALESCE(SUM(file_size), 0) AS bytes").
+		Where("user_id IN ?", ids).
+		Group("user_id").
+		Scan(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]domain.StorageUsage)
+	for _, row := range attachments {
+		usage := result[row.UserID]
+		usage.Attachments, usage.AttachmentBytes = row.Count, row.Bytes
+		usage.TotalBytes += row.Bytes
+		result[row.UserID] = usage
+	}
+	for _, row := range versions {
+		usage := result[row.UserID]
+		usage.DocumentVersions, usage.DocumentBytes = row.Count, row.Bytes
+		usage.TotalBytes += row.Bytes
+		result[row.UserID] = usage
+	}
+	return result, nil
+}
+
+// Delete removes the user and every row that belongs to them, in one
+// transaction. The contents of their files must be deleted from storage
+// first.
+func (r *UserRepository) Delete(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		jobIDs := tx.Model(&domain.Job{}).Select("id").Where("user_id = ?", id)
+		watchIDs := tx.Model(&domain.CompanyWatch{}).Select("id").Where("user_id = ?", id)
+		steps := []struct {
+			model interface{}
+			query string
+			arg   interface{}
+		}{
+			{&domain.Attachment{}, "job_id IN (?)", jobIDs},
+			{&domain.StatusChange{}, "user_id = ?", id},
+			{&domain.Notification{}, "user_id = ?", id},
+			{&domain.Job{}, "user_id = ?", id},
+			{&domain.DocumentVersion{}, "user_id = ?", id},
+			{&domain.Document{}, "user_id = ?", id},
+			{&domain.WatchedPosting{}, "watch_id IN (?)", watchIDs},
+			{&domain.CompanyWatch{}, "user_id = ?", id},
+			{&domain.RankingProfile{}, "user_id = ?", id},
+			{&domain.PreferenceModel{}, "user_id = ?", id},
+			{&domain.Session{}, "user_id = ?", id},
+			{&domain.AccountToken{}, "user_id = ?", id},
+			{&domain.RecoveryCode{}, "user_id = ?", id},
+			{&domain.ExternalIdentity{}, "user_id = ?", id},
+			{&domain.APIToken{}, "user_id = ?", id},
+			{&domain.LoginAttempt{}, "user_id = ?", id},
+		}
+		for _, step := range steps {
+			if err := tx.Where(step.query, step.arg).Delete(step.model).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Delete(&domain.User{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return appErrors.ErrNotFound
+		}
+		return nil
+	})
 }
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	appErrors "job-tracker-backend/pkg/errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WatchRepository struct {
+	db *gorm.DB
+}
+
+func NewWatchRepository(db *gorm.DB) *WatchRepository {
+	return &WatchRepository{db: db}
+}
+
+func (r *WatchRepository) Create(watch *domain.CompanyWatch) error {
+	return r.db.Create(watch).Error
+}
+
+func (r *WatchRepository) GetByID(id, userID string) (*domain.CompanyWatch, error) {
+	var watch domain.CompanyWatch
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&watch).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, appErrors.ErrNotFound
+		}
+		return nil, err
+	}
+	return &watch, nil
+}
+
+func (r *WatchRepository) GetAll(userID string) ([]domain.CompanyWatch, error) {
+	var watches []domain.CompanyWatch
+	err := r.db.Where("user_id = ?", userID).Order("company").Find(&watches).Error
+	return watches, err
+}
+
+func (r *WatchRepository) ExistsForBoard(userID, ats, boardID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&domain.CompanyWatch{}).
+		Where("user_id = ? AND ats = ? AND board_id = ?", userID, ats, boardID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetDue returns every user's watches not polled since before, least
+// recently polled first.
+func (r *WatchRepository) GetDue(before time.Time) ([]domain.CompanyWatch, error) {
+	var watches []domain.CompanyWatch
+	err := r.db.Where("last_polled_at IS NULL OR last_polled_at < ?", before).
+		Order("last_polled_at NULLS FIRST").
+		Find(&watches).Error
+	return watches, err
+}
+
+func (r *WatchRepository) Update(watch *domain.CompanyWatch) error {
+	return r.db.Save(watch).Error
+}
+
+// Delete removes a watch and the postings it has seen. Notifications it
+// raised are kept.
+func (r *WatchRepository) Delete(id, userID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&domain.CompanyWatch{}, "id = ? AND user_id = ?", id, userID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return appErrors.ErrNotFound
+		}
+		if err := tx.Delete(&domain.WatchedPosting{}, "watch_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.Notification{}).Where("watch_id = ?", id).Update("watch_id", nil).Error
+	})
+}
+
+// GetSeenKeys returns the keys of the postings the watch has already seen.
+func (r *WatchRepository) GetSeenKeys(watchID string) (map[string]bool, error) {
+	var keys []string
+	if err := r.db.Model(&domain.WatchedPosting{}).Where("watch_id = ?", watchID).Pluck("posting_key", &keys).Error; err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		seen[k] = true
+	}
+	return seen, nil
+}
+
+// AddSeen records postings as seen by the watch. Keys already recorded are
+// ignored.
+func (r *WatchRepository) AddSeen(watchID string, keys []string, seenAt time.Time) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	rows := make([]domain.WatchedPosting, len(keys))
+	for i, k := range keys {
+		rows[i] = domain.WatchedPosting{WatchID: watchID, PostingKey: k, FirstSeenAt: seenAt}
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(rows, 500).Error
+}
@@ -0,0 +1,127 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// clamdChunkSize stays well below clamd's default StreamMaxLength.
+const clamdChunkSize = 64 * 1024
+
+// ClamAV scans files with a clamd daemon using its INSTREAM command.
+type ClamAV struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamAV takes the daemon address as tcp://host:port or
+// unix:///path/to/clamd.sock.
+func NewClamAV(addr string, timeout time.Duration) (*ClamAV, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clamd address %q: %w", addr, err)
+	}
+	c := &ClamAV{network: u.Scheme, timeout: timeout}
+	switch u.Scheme {
+	case "tcp":
+		c.address = u.Host
+	case "unix":
+		c.address = u.Path
+	default:
+		return nil, fmt.Errorf("invalid clamd address %q: scheme must be tcp or unix", addr)
+	}
+	if c.address == "" {
+		return nil, fmt.Errorf("invalid clamd address %q", addr)
+	}
+	if c.timeout <= 0 {
+		c.timeout = time.Minute
+	}
+	return c, nil
+}
+
+func (c *ClamAV) Name() string { return ScannerClamAV }
+
+// Ping checks that the daemon is reachable.
+func (c *ClamAV) Ping() error {
+	reply, err := c.command("zPING\x00", nil)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("clamd: unexpected reply to PING: %q", reply)
+	}
+	return nil
+}
+
+func (c *ClamAV) Scan(r io.Reader) error {
+	reply, err := c.command("zINSTREAM\x00", r)
+	if err != nil {
+		return err
+	}
+
+	// Replies look like "stream: OK", "stream: Eicar-Signature FOUND" or
+	// "INSTREAM size limit exceeded. ERROR".
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return nil
+	case strings.HasSuffix(result, " FOUND"):
+		return &InfectedError{Signature: strings.TrimSuffix(result, " FOUND")}
+	}
+	return fmt.Errorf("clamd: %s", reply)
+}
+
+// command sends cmd, followed by body in INSTREAM chunks when body is set,
+// and returns the NUL-terminated reply.
+func (c *ClamAV) command(cmd string, body io.Reader) (string, error) {
+	conn, err := net.DialTimeout(c.network, c.address, c.timeout)
+	if err != nil {
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(c.timeout))
+
+	w := bufio.NewWriterSize(conn, clamdChunkSize+4)
+	if _, err := w.WriteString(cmd); err != nil {
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+	if body != nil {
+		buf := make([]byte, clamdChunkSize)
+		var size [4]byte
+		for {
+			n, err := io.ReadFull(body, buf)
+			if n > 0 {
+				binary.BigEndian.PutUint32(size[:], uint32(n))
+				w.Write(size[:])
+				if _, werr := w.Write(buf[:n]); werr != nil {
+					return "", fmt.Errorf("clamd: %w", werr)
+				}
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+		}
+		binary.BigEndian.PutUint32(size[:], 0)
+		w.Write(size[:])
+	}
+	if err := w.Flush(); err != nil {
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && len(reply) == 0 {
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+	return string(bytes.TrimRight(reply, "\x00\n")), nil
+}
@@ -0,0 +1,58 @@
+// Package scan runs uploaded files past a malware scanner before they are
+// stored.
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"job-tracker-backend/internal/config"
+)
+
+const (
+	ScannerNone   = "none"
+	ScannerClamAV = "clamav"
+)
+
+var (
+	ErrInfected       = errors.New("file rejected by virus scanner")
+	ErrUnknownScanner = errors.New("unknown scanner")
+)
+
+// InfectedError names the signature a scanner matched. It wraps ErrInfected.
+type InfectedError struct {
+	Signature string
+}
+
+func (e *InfectedError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrInfected, e.Signature)
+}
+
+func (e *InfectedError) Unwrap() error { return ErrInfected }
+
+// Scanner inspects file contents. Scan returns nil for clean files, an
+// error wrapping ErrInfected for rejected ones, and any other error when the
+// scan itself could not be completed.
+type Scanner interface {
+	Name() string
+	Scan(r io.Reader) error
+}
+
+// Nop accepts everything.
+type Nop struct{}
+
+func (Nop) Name() string         { return ScannerNone }
+func (Nop) Scan(io.Reader) error { return nil }
+
+// NewFromConfig returns the scanner selected by SCANNER.
+func NewFromConfig(cfg *config.Config) (Scanner, error) {
+	switch cfg.Scanner {
+	case "", ScannerNone:
+		return Nop{}, nil
+	case ScannerClamAV:
+		return NewClamAV(cfg.ClamAVAddress, time.Duration(cfg.ClamAVTimeoutSeconds)*time.Second)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownScanner, cfg.Scanner)
+}
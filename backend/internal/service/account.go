@@ -0,0 +1,212 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"job-tracker-backend/internal/auth"
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/mail"
+	appErrors "job-tracker-backend/pkg/errors"
+)
+
+const (
+	passwordResetTTL     = time.Hour
+	emailVerificationTTL = 48 * time.Hour
+	// requiredResetTTL is how long the link sent when an admin requires a
+	// new password lasts; ForgotPassword sends another after that
+	requiredResetTTL = 48 * time.Hour
+)
+
+// ForgotPassword emails the user a link to reset their password. It
+// succeeds whether or not the email belongs to an account, and does its work
+// in the background so the response time doesn't tell either.
+func (s *AuthService) ForgotPassword(input *domain.ForgotPasswordInput) error {
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	if email == "" {
+		return appErrors.ErrInvalidInput
+	}
+	go func() {
+		user, err := s.userRepo.GetByEmail(email)
+		if err != nil {
+			return
+		}
+		token, err := s.issueToken(user, domain.TokenPasswordReset, passwordResetTTL)
+		if err != nil {
+			log.Printf("password reset for %s: %v", user.ID, err)
+			return
+		}
+		s.send(mail.Message{
+			To:      user.Email,
+			Subject: "Reset your Job Tracker password",
+			Body: "Someone asked to reset the password of your Job Tracker account.\n\n" +
+				"To choose a new password, open this link within an hour:\n\n" +
+				s.link("/reset-password", token) + "\n\n" +
+				"If it wasn't you, ignore this email; your password stays the same.\n",
+		})
+	}()
+	return nil
+}
+
+// RequirePasswordReset makes the user choose a new password before signing
+// in with one again: their sessions end, and they are emailed a reset link.
+func (s *AuthService) RequirePasswordReset(userID string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.RequirePasswordReset(user.ID); err != nil {
+		return err
+	}
+	if err := s.sessions.DeleteAll(user.ID, ""); err != nil {
+		return err
+	}
+	token, err := s.issueToken(user, domain.TokenPasswordReset, requiredResetTTL)
+	if err != nil {
+		return err
+	}
+	go s.send(mail.Message{
+		To:      user.Email,
+		Subject: "Choose a new Job Tracker password",
+		Body: "An administrator asked you to choose a new password for your Job Tracker account, " +
+			"and you have been signed out.\n\n" +
+			"To choose one, open this link within 48 hours:\n\n" +
+			s.link("/reset-password", token) + "\n\n" +
+			"After that, ask for a new link with \"Forgot password?\" on the sign-in page.\n",
+	})
+	return nil
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword. Every
+// session of the user is signed out and any sign-in lock lifted, and since
+// the token arrived by email, the email counts as verified.
+func (s *AuthService) ResetPassword(input *domain.ResetPasswordInput) error {
+	if len(input.Password) < 8 {
+		return fmt.Errorf("%w: password must be at least 8 characters", appErrors.ErrInvalidInput)
+	}
+	token, err := s.redeemToken(input.Token, domain.TokenPasswordReset)
+	if err != nil {
+		return err
+	}
+	hash, err := auth.HashPassword(input.Password)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePasswordHash(token.UserID, hash); err != nil {
+		return err
+	}
+	if err := s.userRepo.MarkEmailVerified(token.UserID, time.Now()); err != nil {
+		return err
+	}
+	if err := s.userRepo.ResetLoginFailures(token.UserID); err != nil {
+		return err
+	}
+	if err := s.tokens.DeleteForUser(token.UserID, domain.TokenPasswordReset); err != nil {
+		return err
+	}
+	return s.sessions.DeleteAll(token.UserID, "")
+}
+
+// VerifyEmail confirms the user's email with a token sent on registration.
+func (s *AuthService) VerifyEmail(input *domain.VerifyEmailInput) error {
+	token, err := s.redeemToken(input.Token, domain.TokenEmailVerification)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.MarkEmailVerified(token.UserID, time.Now()); err != nil {
+		return err
+	}
+	return s.tokens.DeleteForUser(token.UserID, domain.TokenEmailVerification)
+}
+
+// ResendVerification sends a new verification link to an unverified
+// account. Like ForgotPassword, it doesn't reveal whether the account
+// exists.
+func (s *AuthService) ResendVerification(input *domain.ForgotPasswordInput) error {
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	if email == "" {
+		return appErrors.ErrInvalidInput
+	}
+	go func() {
+		user, err := s.userRepo.GetByEmail(email)
+		if err != nil || user.EmailVerifiedAt != nil {
+			return
+		}
+		if err := s.sendVerification(user); err != nil {
+			log.Printf("email verification for %s: %v", user.ID, err)
+		}
+	}()
+	return nil
+}
+
+// sendVerification emails the user a link to verify their email. The email
+// is sent in the background.
+func (s *AuthService) sendVerification(user *domain.User) error {
+	token, err := s.issueToken(user, domain.TokenEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	go s.send(mail.Message{
+		To:      user.Email,
+		Subject: "Verify your Job Tracker email",
+		Body: "Welcome to Job Tracker!\n\n" +
+			"To confirm this is your email, open this link within 48 hours:\n\n" +
+			s.link("/verify-email", token) + "\n\n" +
+			"If you didn't create an account, ignore this email.\n",
+	})
+	return nil
+}
+
+// issueToken creates a token for purpose, replacing any sent before, and
+// returns it; only its hash is stored.
+func (s *AuthService) issueToken(user *domain.User, purpose string, ttl time.Duration) (string, error) {
+	token, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	if err := s.tokens.DeleteForUser(user.ID, purpose); err != nil {
+		return "", err
+	}
+	err = s.tokens.Create(&domain.AccountToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	return token, err
+}
+
+// redeemToken marks a token for purpose used and returns it. Unknown,
+// expired and used tokens are all invalid input.
+func (s *AuthService) redeemToken(raw, purpose string) (*domain.AccountToken, error) {
+	invalid := fmt.Errorf("%w: the link is invalid or has expired", appErrors.ErrInvalidInput)
+	if raw == "" {
+		return nil, invalid
+	}
+	now := time.Now()
+	token, err := s.tokens.GetUsable(auth.HashToken(raw), purpose, now)
+	if err == nil {
+		err = s.tokens.Use(token.ID, now)
+	}
+	if errors.Is(err, appErrors.ErrNotFound) {
+		return nil, invalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (s *AuthService) link(path, token string) string {
+	return s.appBaseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func (s *AuthService) send(msg mail.Message) {
+	if err := s.mailer.Send(msg); err != nil {
+		log.Printf("sending %q to %s via %s: %v", msg.Subject, msg.To, s.mailer.Name(), err)
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+	"job-tracker-backend/internal/storage"
+	appErrors "job-tracker-backend/pkg/errors"
+)
+
+// AdminService lets admins manage every user's account. An admin can't
+// change their own role, disable or delete themselves, so there is always
+// someone left to undo a mistake.
+type AdminService struct {
+	users *repository.UserRepository
+	jobs  *repository.JobRepository
+	docs  *repository.DocumentRepository
+	blobs *storage.Manager
+	auth  *AuthService
+}
+
+func NewAdminService(users *repository.UserRepository, jobs *repository.JobRepository, docs *repository.DocumentRepository, blobs *storage.Manager, auth *AuthService) *AdminService {
+	return &AdminService{users: users, jobs: jobs, docs: docs, blobs: blobs, auth: auth}
+}
+
+// IsAdmin reports whether the user is an admin. It is checked on every
+// request, so a demoted admin loses access right away.
+func (s *AdminService) IsAdmin(userID string) (bool, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return false, err
+	}
+	return user.Role == domain.RoleAdmin && user.DisabledAt == nil, nil
+}
+
+// ListUsers returns the users whose email contains query, with their job
+// counts and storage usage.
+func (s *AdminService) ListUsers(query string) ([]domain.AdminUser, error) {
+	users, err := s.users.List(strings.ToLower(strings.TrimSpace(query)))
+	if err != nil {
+		return nil, err
+	}
+	return s.withUsage(users)
+}
+
+func (s *AdminService) GetUser(id string) (*domain.AdminUser, error) {
+	user, err := s.users.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.withUsage([]domain.User{*user})
+	if err != nil {
+		return nil, err
+	}
+	return &result[0], nil
+}
+
+func (s *AdminService) withUsage(users []domain.User) ([]domain.AdminUser, error) {
+	result := make([]domain.AdminUser, len(users))
+	if len(users) == 0 {
+		return result, nil
+	}
+	ids := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	jobs, err := s.users.CountJobs(ids)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.users.StorageUsage(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i, u := range users {
+		result[i] = domain.AdminUser{
+			User:             u,
+			TwoFactorEnabled: u.TOTPEnabledAt != nil,
+			Jobs:             jobs[u.ID],
+			Storage:          usage[u.ID],
+		}
+	}
+	return result, nil
+}
+
+func (s *AdminService) SetRole(actorID, id string, input *domain.RoleInput) (*domain.AdminUser, error) {
+	role := strings.ToLower(strings.TrimSpace(input.Role))
+	if role != domain.RoleUser && role != domain.RoleAdmin {
+		return nil, fmt.Errorf("%w: role must be '%s' or '%s'", appErrors.ErrInvalidInput, domain.RoleUser, domain.RoleAdmin)
+	}
+	if id == actorID {
+		return nil, fmt.Errorf("%w: you can't change your own role", appErrors.ErrInvalidInput)
+	}
+	if err := s.users.SetRole(id, role); err != nil {
+		return nil, err
+	}
+	return s.GetUser(id)
+}
+
+// DisableUser stops the user from signing in or using their API tokens,
+// and signs them out everywhere.
+func (s *AdminService) DisableUser(actorID, id string) (*domain.AdminUser, error) {
+	if id == actorID {
+		return nil, fmt.Errorf("%w: you can't disable your own account", appErrors.ErrInvalidInput)
+	}
+	if err := s.disable(id); err != nil {
+		return nil, err
+	}
+	return s.GetUser(id)
+}
+
+func (s *AdminService) disable(id string) error {
+	now := time.Now()
+	if err := s.users.SetDisabled(id, &now); err != nil {
+		return err
+	}
+	return s.auth.RevokeAllSessions(id)
+}
+
+func (s *AdminService) EnableUser(id string) (*domain.AdminUser, error) {
+	if err := s.users.SetDisabled(id, nil); err != nil {
+		return nil, err
+	}
+	return s.GetUser(id)
+}
+
+// RequirePasswordReset signs the user out and makes them choose a new
+// password, through an emailed link, before signing in with one again.
+func (s *AdminService) RequirePasswordReset(id string) (*domain.AdminUser, error) {
+	if err := s.auth.RequirePasswordReset(id); err != nil {
+		return nil, err
+	}
+	return s.GetUser(id)
+}
+
+// DeleteUser deletes the user with everything they own: jobs, attachments,
+// documents, watches and the rest. The account is disabled first, so
+// nothing new is stored while its files are deleted; if deleting a file
+// fails, the account stays disabled and deleting it again picks up where
+// this left off.
+func (s *AdminService) DeleteUser(actorID, id string) error {
+	if id == actorID {
+		return fmt.Errorf("%w: you can't delete your own account", appErrors.ErrInvalidInput)
+	}
+	if err := s.disable(id); err != nil {
+		return err
+	}
+
+	attachments, err := s.jobs.GetAttachmentsByUser(id)
+	if err != nil {
+		return err
+	}
+	for _, att := range attachments {
+		if err := s.deleteBlob(att.StorageBackend, att.ID); err != nil {
+			return fmt.Errorf("failed to delete attachment contents: %w", err)
+		}
+	}
+	versions, err := s.docs.GetVersionsByUser(id)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if err := s.deleteBlob(v.StorageBackend, v.ID); err != nil {
+			return fmt.Errorf("failed to delete document contents: %w", err)
+		}
+	}
+	return s.users.Delete(id)
+}
+
+func (s *AdminService) deleteBlob(backend, key string) error {
+	store, err := s.blobs.Store(backend)
+	if err != nil {
+		return err
+	}
+	return store.Delete(key)
+}
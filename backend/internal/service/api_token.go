@@ -0,0 +1,140 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"job-tracker-backend/internal/auth"
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+	appErrors "job-tracker-backend/pkg/errors"
+)
+
+// maxAPITokens bounds how many tokens a user can hold at once.
+const maxAPITokens = 50
+
+// APITokenService manages personal API tokens, with which scripts call the
+// API on a user's behalf without signing in.
+type APITokenService struct {
+	tokens *repository.APITokenRepository
+	users  *repository.UserRepository
+}
+
+func NewAPITokenService(tokens *repository.APITokenRepository, users *repository.UserRepository) *APITokenService {
+	return &APITokenService{tokens: tokens, users: users}
+}
+
+func (s *APITokenService) ListTokens(userID string) ([]domain.APIToken, error) {
+	tokens, err := s.tokens.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	if tokens == nil {
+		tokens = []domain.APIToken{}
+	}
+	return tokens, nil
+}
+
+// CreateToken creates a token with the given scopes. The returned secret is
+// the only copy; only its hash is kept.
+func (s *APITokenService) CreateToken(userID string, input *domain.APITokenCreateInput) (*domain.APITokenCreated, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > 100 {
+		return nil, fmt.Errorf("%w: name is required and at most 100 characters", appErrors.ErrInvalidInput)
+	}
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if input.ExpiresInDays < 0 {
+		return nil, fmt.Errorf("%w: expires_in_days can't be negative", appErrors.ErrInvalidInput)
+	}
+	count, err := s.tokens.Count(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxAPITokens {
+		return nil, fmt.Errorf("%w: at most %d API tokens; revoke one first", appErrors.ErrInvalidInput, maxAPITokens)
+	}
+
+	random, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	raw := auth.APITokenPrefix + random
+	token := &domain.APIToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    raw[:len(auth.APITokenPrefix)+8],
+		TokenHash: auth.HashToken(raw),
+		Scopes:    scopes,
+	}
+	if input.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, input.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := s.tokens.Create(token); err != nil {
+		return nil, err
+	}
+	return &domain.APITokenCreated{APIToken: *token, Token: raw}, nil
+}
+
+func (s *APITokenService) RevokeToken(userID, id string) error {
+	return s.tokens.Delete(id, userID)
+}
+
+// AuthenticateAPIToken returns the unexpired token raw stands for,
+// recording its use from ip. It returns ErrNotFound for unknown, revoked
+// and expired tokens, and for tokens of disabled accounts.
+func (s *APITokenService) AuthenticateAPIToken(raw, ip string) (*domain.APIToken, error) {
+	if !strings.HasPrefix(raw, auth.APITokenPrefix) {
+		return nil, appErrors.ErrNotFound
+	}
+	token, err := s.tokens.GetByHash(auth.HashToken(raw))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if token.ExpiresAt != nil && !now.Before(*token.ExpiresAt) {
+		return nil, appErrors.ErrNotFound
+	}
+	user, err := s.users.GetByID(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DisabledAt != nil {
+		return nil, appErrors.ErrNotFound
+	}
+	if err := s.tokens.Touch(token.ID, ip, now); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// normalizeScopes checks scopes against the known ones, dropping
+// duplicates.
+func normalizeScopes(scopes []string) (domain.StringList, error) {
+	result := domain.StringList{}
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		known := false
+		for _, s := range domain.APITokenScopes {
+			known = known || s == scope
+		}
+		if !known {
+			return nil, fmt.Errorf("%w: unknown scope %q; scopes are %s", appErrors.ErrInvalidInput, scope, strings.Join(domain.APITokenScopes, ", "))
+		}
+		duplicate := false
+		for _, s := range result {
+			duplicate = duplicate || s == scope
+		}
+		if !duplicate {
+			result = append(result, scope)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", appErrors.ErrInvalidInput)
+	}
+	return result, nil
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"job-tracker-backend/internal/config"
+)
+
+// Applicant tracking systems whose public job board APIs can be read.
+const (
+	ATSGreenhouse = "greenhouse"
+	ATSLever      = "lever"
+	ATSAshby      = "ashby"
+	ATSWorkable   = "workable"
+)
+
+// Default base URLs of the job board APIs; a board identifier is appended.
+var defaultATSURLs = map[string]string{
+	ATSGreenhouse: "https://boards-api.greenhouse.io/v1/boards/",
+	ATSLever:      "https://api.lever.co/v0/postings/",
+	ATSAshby:      "https://api.ashbyhq.com/posting-api/job-board/",
+	ATSWorkable:   "https://apply.workable.com/api/v1/widget/accounts/",
+}
+
+// maxBoardSize bounds how much of a job board response is read.
+const maxBoardSize = 20 << 20
+
+var boardIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ATSClient reads companies' job boards from applicant tracking systems.
+// Boards list every open position of a company.
+type ATSClient struct {
+	baseURLs   map[string]string
+	httpClient *http.Client
+}
+
+// NewATSClient returns a client using baseURLs, keyed by ATS, in place of
+// the public APIs, so boards can be served by a local fixture server.
+func NewATSClient(baseURLs map[string]string, timeout time.Duration) *ATSClient {
+	urls := make(map[string]string, len(defaultATSURLs))
+	for ats, u := range defaultATSURLs {
+		urls[ats] = u
+		if override := baseURLs[ats]; override != "" {
+			urls[ats] = strings.TrimRight(override, "/") + "/"
+		}
+	}
+	return &ATSClient{baseURLs: urls, httpClient: &http.Client{Timeout: timeout}}
+}
+
+// NewATSClientFromConfig returns a client using the API base URLs and
+// timeout set in cfg.
+func NewATSClientFromConfig(cfg *config.Config) *ATSClient {
+	return NewATSClient(map[string]string{
+		ATSGreenhouse: cfg.GreenhouseAPIURL,
+		ATSLever:      cfg.LeverAPIURL,
+		ATSAshby:      cfg.AshbyAPIURL,
+		ATSWorkable:   cfg.WorkableAPIURL,
+	}, time.Duration(cfg.SearchTimeoutSeconds)*time.Second)
+}
+
+// ValidateBoard checks an ATS name and board identifier.
+func ValidateBoard(ats, board string) error {
+	if _, ok := defaultATSURLs[ats]; !ok {
+		return fmt.Errorf("unsupported ATS %q (allowed: %s, %s, %s, %s)", ats, ATSGreenhouse, ATSLever, ATSAshby, ATSWorkable)
+	}
+	if !boardIDPattern.MatchString(board) {
+		return fmt.Errorf("invalid board identifier %q", board)
+	}
+	return nil
+}
+
+// FetchBoard returns the postings on a company's board.
+func (c *ATSClient) FetchBoard(ats, board string) ([]Posting, error) {
+	if err := ValidateBoard(ats, board); err != nil {
+		return nil, err
+	}
+	endpoint := c.baseURLs[ats] + url.PathEscape(board)
+	switch ats {
+	case ATSGreenhouse:
+		return c.fetchGreenhouse(endpoint+"/jobs?content=true", board)
+	case ATSLever:
+		return c.fetchLever(endpoint+"?mode=json", board)
+	case ATSAshby:
+		return c.fetchAshby(endpoint+"?includeCompensation=true", board)
+	default:
+		return c.fetchWorkable(endpoint+"?details=true", board)
+	}
+}
+
+func (c *ATSClient) getJSON(endpoint string, v any) error {
+	resp, err := c.httpClient.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("board not found")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBoardSize)).Decode(v); err != nil {
+		return fmt.Errorf("invalid board response: %w", err)
+	}
+	return nil
+}
+
+type greenhouseBoard struct {
+	Jobs []struct {
+		Title       string `json:"title"`
+		AbsoluteURL string `json:"absolute_url"`
+		CompanyName string `json:"company_name"`
+		Location    struct {
+			Name string `json:"name"`
+		} `json:"location"`
+		// Content is entity-escaped HTML
+		Content        string `json:"content"`
+		FirstPublished string `json:"first_published"`
+		UpdatedAt      string `json:"updated_at"`
+	} `json:"jobs"`
+}
+
+func (c *ATSClient) fetchGreenhouse(endpoint, board string) ([]Posting, error) {
+	var resp greenhouseBoard
+	if err := c.getJSON(endpoint, &resp); err != nil {
+		return nil, err
+	}
+	postings := make([]Posting, 0, len(resp.Jobs))
+	for _, job := range resp.Jobs {
+		postings = append(postings, Posting{
+			Title:       job.Title,
+			Company:     firstNonEmpty(job.CompanyName, board),
+			Location:    job.Location.Name,
+			URL:         job.AbsoluteURL,
+			Description: job.Content,
+			IsRemote:    isRemoteText(job.Location.Name),
+			Source:      ATSGreenhouse,
+			PostedAt:    parsePostedDate(firstNonEmpty(job.FirstPublished, job.UpdatedAt)),
+		})
+	}
+	return postings, nil
+}
+
+type leverPosting struct {
+	Text       string `json:"text"`
+	HostedURL  string `json:"hostedUrl"`
+	CreatedAt  int64  `json:"createdAt"`
+	Categories struct {
+		Location   string `json:"location"`
+		Commitment string `json:"commitment"`
+	} `json:"categories"`
+	WorkplaceType string `json:"workplaceType"`
+	Description   string `json:"description"`
+	Lists         []struct {
+		Text    string `json:"text"`
+		Content string `json:"content"`
+	} `json:"lists"`
+	Additional  string `json:"additional"`
+	SalaryRange *struct {
+		Min      float64 `json:"min"`
+		Max      float64 `json:"max"`
+		Currency string  `json:"currency"`
+		Interval string  `json:"interval"`
+	} `json:"salaryRange"`
+}
+
+func (c *ATSClient) fetchLever(endpoint, board string) ([]Posting, error) {
+	var resp []leverPosting
+	if err := c.getJSON(endpoint, &resp); err != nil {
+		return nil, err
+	}
+	postings := make([]Posting, 0, len(resp))
+	for _, job := range resp {
+		// The description's sections come as separate HTML fragments.
+		description := job.Description
+		for _, list := range job.Lists {
+			description += "<h3>" + list.Text + "</h3><ul>" + list.Content + "</ul>"
+		}
+		description += job.Additional
+
+		posting := Posting{
+			Title:       job.Text,
+			Company:     board,
+			Location:    job.Categories.Location,
+			URL:         job.HostedURL,
+			Description: description,
+			JobType:     job.Categories.Commitment,
+			IsRemote:    job.WorkplaceType == "remote" || isRemoteText(job.Categories.Location),
+			Source:      ATSLever,
+		}
+		if job.CreatedAt > 0 {
+			t := time.UnixMilli(job.CreatedAt)
+			posting.PostedAt = &t
+		}
+		if sr := job.SalaryRange; sr != nil {
+			posting.SalaryMin, posting.SalaryMax = sr.Min, sr.Max
+			// Intervals read "per-year-salary", "per-hour-wage" and so on.
+			switch {
+			case strings.Contains(sr.Interval, "hour"):
+				posting.SalaryPeriod = "hourly"
+			case strings.Contains(sr.Interval, "day"):
+				posting.SalaryPeriod = "daily"
+			case strings.Contains(sr.Interval, "week"):
+				posting.SalaryPeriod = "weekly"
+			case strings.Contains(sr.Interval, "month"):
+				posting.SalaryPeriod = "monthly"
+			default:
+				posting.SalaryPeriod = "yearly"
+			}
+			posting.Salary = strings.TrimSpace(fmt.Sprintf("%s %.0f-%.0f %s", sr.Currency, sr.Min, sr.Max, posting.SalaryPeriod))
+		}
+		postings = append(postings, posting)
+	}
+	return postings, nil
+}
+
+type ashbyBoard struct {
+	Jobs []struct {
+		Title            string `json:"title"`
+		Location         string `json:"location"`
+		EmploymentType   string `json:"employmentType"`
+		IsRemote         bool   `json:"isRemote"`
+		JobURL           string `json:"jobUrl"`
+		PublishedAt      string `json:"publishedAt"`
+		DescriptionHTML  string `json:"descriptionHtml"`
+		DescriptionPlain string `json:"descriptionPlain"`
+		IsListed         *bool  `json:"isListed"`
+		Compensation     *struct {
+			Summary string `json:"compensationTierSummary"`
+		} `json:"compensation"`
+	} `json:"jobs"`
+}
+
+func (c *ATSClient) fetchAshby(endpoint, board string) ([]Posting, error) {
+	var resp ashbyBoard
+	if err := c.getJSON(endpoint, &resp); err != nil {
+		return nil, err
+	}
+	postings := make([]Posting, 0, len(resp.Jobs))
+	for _, job := range resp.Jobs {
+		if job.IsListed != nil && !*job.IsListed {
+			continue
+		}
+		posting := Posting{
+			Title:       job.Title,
+			Company:     board,
+			Location:    job.Location,
+			URL:         job.JobURL,
+			Description: firstNonEmpty(job.DescriptionHTML, job.DescriptionPlain),
+			JobType:     job.EmploymentType,
+			IsRemote:    job.IsRemote || isRemoteText(job.Location),
+			Source:      ATSAshby,
+			PostedAt:    parsePostedDate(job.PublishedAt),
+		}
+		if job.Compensation != nil {
+			posting.Salary = job.Compensation.Summary
+		}
+		postings = append(postings, posting)
+	}
+	return postings, nil
+}
+
+type workableAccount struct {
+	Name string `json:"name"`
+	Jobs []struct {
+		Title          string `json:"title"`
+		Shortcode      string `json:"shortcode"`
+		EmploymentType string `json:"employment_type"`
+		Telecommuting  bool   `json:"telecommuting"`
+		URL            string `json:"url"`
+		PublishedOn    string `json:"published_on"`
+		CreatedAt      string `json:"created_at"`
+		City           string `json:"city"`
+		State          string `json:"state"`
+		Country        string `json:"country"`
+		Description    string `json:"description"`
+	} `json:"jobs"`
+}
+
+func (c *ATSClient) fetchWorkable(endpoint, board string) ([]Posting, error) {
+	var resp workableAccount
+	if err := c.getJSON(endpoint, &resp); err != nil {
+		return nil, err
+	}
+	postings := make([]Posting, 0, len(resp.Jobs))
+	for _, job := range resp.Jobs {
+		var place []string
+		for _, p := range []string{job.City, job.State, job.Country} {
+			if p != "" {
+				place = append(place, p)
+			}
+		}
+		location := strings.Join(place, ", ")
+		postings = append(postings, Posting{
+			Title:       job.Title,
+			Company:     firstNonEmpty(resp.Name, board),
+			Location:    location,
+			URL:         job.URL,
+			Description: job.Description,
+			JobType:     job.EmploymentType,
+			IsRemote:    job.Telecommuting || isRemoteText(location),
+			Source:      ATSWorkable,
+			PostedAt:    parsePostedDate(firstNonEmpty(job.PublishedOn, job.CreatedAt)),
+		})
+	}
+	return postings, nil
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/storage"
+)
+
+// Attachment constants
+const (
+	MaxFileSize                int64 = 10 * 1024 * 1024 // 10MB
+	AllowedFileTypeResume            = "resume"
+	AllowedFileTypeCoverLetter       = "cover_letter"
+)
+
+var ErrFileTooLarge = errors.New("file too large: max size is 10MB")
+
+var allowedMIMETypes = map[string]bool{
+	"application/pdf":    true,
+	"application/msword": true,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": true,
+}
+
+type AttachmentInput struct {
+	JobID    string
+	UserID   string
+	FileName string
+	FileType string // "resume" or "cover_letter"
+	MIMEType string
+	Content  io.Reader
+}
+
+// CreateAttachment stages input.Content, checking its real type and
+// scanning it, and only then stores it in the primary blob store.
+func (s *JobService) CreateAttachment(input *AttachmentInput) (*domain.Attachment, error) {
+	if err := validateAttachmentType(input.FileType, input.MIMEType); err != nil {
+		return nil, err
+	}
+
+	// Verify job exists and belongs to this user
+	_, err := s.repo.GetByID(input.JobID, input.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+
+	upload, err := stageUpload(input.Content, input.MIMEType, s.scanner)
+	if err != nil {
+		return nil, err
+	}
+	defer upload.Close()
+
+	store := s.blobs.Primary()
+	now := time.Now()
+	attachment := &domain.Attachment{
+		JobID:           input.JobID,
+		FileName:        input.FileName,
+		FileType:        input.FileType,
+		MIMEType:        upload.mimeType,
+		FileSize:        upload.size,
+		ContentHash:     upload.hash,
+		StorageBackend:  store.Name(),
+		ExtractedText:   extractText(upload.mimeType, upload.File, upload.size),
+		TextExtractedAt: &now,
+	}
+
+	if err := s.repo.CreateAttachment(attachment); err != nil {
+		return nil, err
+	}
+	if err := store.Put(attachment.ID, upload, upload.size, attachment.MIMEType); err != nil {
+		s.discardAttachment(store, attachment.ID)
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	return attachment, nil
+}
+
+func validateAttachmentType(fileType, mimeType string) error {
+	if fileType != AllowedFileTypeResume && fileType != AllowedFileTypeCoverLetter {
+		return fmt.Errorf("invalid file type: %s (must be 'resume' or 'cover_letter')", fileType)
+	}
+	if !genericMIMETypes[mimeType] && !allowedMIMETypes[mimeType] {
+		return fmt.Errorf("invalid MIME type: %s (allowed: application/pdf, application/msword, application/vnd.openxmlformats-officedocument.wordprocessingml.document)", mimeType)
+	}
+	return nil
+}
+
+func (s *JobService) discardAttachment(store storage.BlobStore, id string) {
+	if err := store.Delete(id); err != nil {
+		log.Printf("failed to remove contents of attachment %s: %v", id, err)
+	}
+	if err := s.repo.DeleteAttachment(id); err != nil {
+		log.Printf("failed to remove attachment %s: %v", id, err)
+	}
+}
+
+func (s *JobService) GetAttachment(id string) (*domain.Attachment, error) {
+	return s.repo.GetAttachmentByID(id)
+}
+
+func (s *JobService) GetAttachmentsByJobID(jobID string) ([]domain.Attachment, error) {
+	return s.repo.GetAttachmentsByJobID(jobID)
+}
+
+// OpenAttachment returns a reader over the attachment's contents from
+// whichever store holds them. Attachments uploaded before content hashing
+// existed get their hash computed and saved here.
+func (s *JobService) OpenAttachment(attachment *domain.Attachment) (io.ReadSeekCloser, error) {
+	store, err := s.blobs.Store(attachment.StorageBackend)
+	if err != nil {
+		return nil, err
+	}
+	content, err := store.Open(attachment.BlobKey())
+	if err != nil {
+		return nil, err
+	}
+	if attachment.ContentHash != "" {
+		return content, nil
+	}
+
+	h := sha256.New()
+	size, err := io.Copy(h, content)
+	if err == nil {
+		_, err = content.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		content.Close()
+		return nil, err
+	}
+	attachment.FileSize = size
+	attachment.ContentHash = hex.EncodeToString(h.Sum(nil))
+	if err := s.repo.UpdateAttachmentContent(attachment.ID, size, attachment.ContentHash); err != nil {
+		log.Printf("failed to save content hash of attachment %s: %v", attachment.ID, err)
+	}
+	return content, nil
+}
+
+// GetAttachmentText returns the attachment's extracted text. Attachments
+// uploaded before extraction existed are extracted on first request.
+func (s *JobService) GetAttachmentText(attachment *domain.Attachment) (*domain.AttachmentText, error) {
+	if attachment.TextExtractedAt == nil {
+		content, err := s.OpenAttachment(attachment)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(content)
+		content.Close()
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		attachment.ExtractedText = extractText(attachment.MIMEType, bytes.NewReader(data), int64(len(data)))
+		attachment.TextExtractedAt = &now
+		if err := s.repo.UpdateAttachmentText(attachment.ID, attachment.ExtractedText, now); err != nil {
+			log.Printf("failed to save text of attachment %s: %v", attachment.ID, err)
+		}
+	}
+	return &domain.AttachmentText{
+		AttachmentID: attachment.ID,
+		FileName:     attachment.FileName,
+		Text:         attachment.ExtractedText,
+		ExtractedAt:  attachment.TextExtractedAt,
+	}, nil
+}
+
+// DeleteAttachment removes an attachment and its contents. For attachments
+// linking to a document version only the link is removed.
+func (s *JobService) DeleteAttachment(id string) error {
+	attachment, err := s.repo.GetAttachmentByID(id)
+	if err != nil {
+		return err
+	}
+	if attachment.DocumentVersionID != nil {
+		return s.repo.DeleteAttachment(id)
+	}
+	store, err := s.blobs.Store(attachment.StorageBackend)
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete attachment contents: %w", err)
+	}
+	return s.repo.DeleteAttachment(id)
+}
+
+// MigrateAttachments copies the contents of every attachment stored in the
+// from backend to the to backend, switches the attachment over and then
+// deletes the source copy. It returns the number of attachments moved; a
+// failure leaves already-moved attachments in place, so it can be re-run.
+func (s *JobService) MigrateAttachments(from, to string, progress func(moved, total int)) (int, error) {
+	src, err := s.blobs.Store(from)
+	if err != nil {
+		return 0, err
+	}
+	dst, err := s.blobs.Store(to)
+	if err != nil {
+		return 0, err
+	}
+	if from == to {
+		return 0, nil
+	}
+
+	attachments, err := s.repo.GetAttachmentsByBackend(from)
+	if err != nil {
+		return 0, err
+	}
+	for i, att := range attachments {
+		if err := copyBlob(src, dst, att.ID, att.MIMEType); err != nil {
+			return i, fmt.Errorf("attachment %s: %w", att.ID, err)
+		}
+		if err := s.repo.UpdateAttachmentBackend(att.ID, to); err != nil {
+			return i, fmt.Errorf("attachment %s: %w", att.ID, err)
+		}
+		if err := src.Delete(att.ID); err != nil {
+			log.Printf("attachment %s moved but source copy not deleted: %v", att.ID, err)
+		}
+		if progress != nil {
+			progress(i+1, len(attachments))
+		}
+	}
+	return len(attachments), nil
+}
+
+func copyBlob(src, dst storage.BlobStore, key, contentType string) error {
+	r, err := src.Open(key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return dst.Put(key, r, size, contentType)
+}
+
+// limitedHashReader counts and hashes what passes through it and stops with
+// ErrFileTooLarge once more than limit bytes have been read.
+type limitedHashReader struct {
+	r        io.Reader
+	h        hash.Hash
+	n        int64
+	limit    int64
+	exceeded bool
+}
+
+func newLimitedHashReader(r io.Reader, limit int64) *limitedHashReader {
+	return &limitedHashReader{r: r, h: sha256.New(), limit: limit}
+}
+
+func (l *limitedHashReader) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, ErrFileTooLarge
+	}
+	if remaining := l.limit + 1 - l.n; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	l.h.Write(p[:n])
+	if l.n > l.limit {
+		l.exceeded = true
+		return n, ErrFileTooLarge
+	}
+	return n, err
+}
+
+func (l *limitedHashReader) sum() string {
+	return hex.EncodeToString(l.h.Sum(nil))
+}
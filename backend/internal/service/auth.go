@@ -1,26 +1,88 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"job-tracker-backend/internal/auth"
+	"job-tracker-backend/internal/config"
 	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/mail"
 	"job-tracker-backend/internal/repository"
 	appErrors "job-tracker-backend/pkg/errors"
 )
 
+// ErrEmailNotVerified is returned by Login when verified emails are required
+// and the user hasn't verified theirs yet.
+var ErrEmailNotVerified = errors.New("email not verified")
+
+// ErrAccountDisabled is returned by sign-in to an account an admin disabled.
+var ErrAccountDisabled = errors.New("account disabled")
+
+// ErrPasswordResetRequired is returned by Login when an admin requires the
+// user to choose a new password through a reset link first.
+var ErrPasswordResetRequired = errors.New("password reset required")
+
+// LockedError is returned by Login while sign-in to an account is locked
+// after repeated wrong passwords.
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("sign-in locked until %s", e.Until.Format(time.RFC3339))
+}
+
+// loginAttemptRetention is how long sign-in attempts are kept for auditing.
+const loginAttemptRetention = 90 * 24 * time.Hour
+
 type AuthService struct {
-	userRepo  *repository.UserRepository
-	jwtSecret string
-	jwtExpiry time.Duration
+	userRepo      *repository.UserRepository
+	sessions      *repository.SessionRepository
+	tokens        *repository.AccountTokenRepository
+	attempts      *repository.LoginAttemptRepository
+	recoveryCodes *repository.RecoveryCodeRepository
+	mailer        mail.Mailer
+	jwtSecret     string
+	jwtExpiry     time.Duration
+	refreshExpiry time.Duration
+	appBaseURL    string
+	// requireVerified refuses sign-in to users who haven't verified their
+	// email
+	requireVerified bool
+
+	lockoutThreshold int
+	lockoutBase      time.Duration
+	lockoutMax       time.Duration
+
+	pruneMu      sync.Mutex
+	lastPrunedAt time.Time
 }
 
-func NewAuthService(repo *repository.UserRepository, secret string, expiry time.Duration) *AuthService {
-	return &AuthService{userRepo: repo, jwtSecret: secret, jwtExpiry: expiry}
+func NewAuthService(cfg *config.Config, repo *repository.UserRepository, sessions *repository.SessionRepository, tokens *repository.AccountTokenRepository, attempts *repository.LoginAttemptRepository, recoveryCodes *repository.RecoveryCodeRepository, mailer mail.Mailer) *AuthService {
+	return &AuthService{
+		userRepo:         repo,
+		sessions:         sessions,
+		tokens:           tokens,
+		attempts:         attempts,
+		recoveryCodes:    recoveryCodes,
+		mailer:           mailer,
+		jwtSecret:        cfg.JWTSecret,
+		jwtExpiry:        cfg.JWTExpiration,
+		refreshExpiry:    cfg.RefreshTokenExpiration,
+		appBaseURL:       strings.TrimRight(cfg.AppBaseURL, "/"),
+		requireVerified:  cfg.RequireVerifiedEmail,
+		lockoutThreshold: cfg.LoginLockoutThreshold,
+		lockoutBase:      cfg.LoginLockoutBase,
+		lockoutMax:       cfg.LoginLockoutMax,
+	}
 }
 
-func (s *AuthService) Register(input *domain.RegisterInput) (*domain.AuthResponse, error) {
+func (s *AuthService) Register(input *domain.RegisterInput, client domain.ClientInfo) (*domain.AuthResponse, error) {
 	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
 	if input.Email == "" || input.Password == "" {
 		return nil, appErrors.ErrInvalidInput
@@ -43,26 +105,248 @@ func (s *AuthService) Register(input *domain.RegisterInput) (*domain.AuthRespons
 	if err := s.userRepo.Create(user); err != nil {
 		return nil, err
 	}
+	if err := s.sendVerification(user); err != nil {
+		return nil, err
+	}
 
-	return s.buildAuthResponse(user)
+	if s.requireVerified {
+		// No session until the email is verified
+		return &domain.AuthResponse{User: authUser(user)}, nil
+	}
+	return s.startSession(user, client)
 }
 
-func (s *AuthService) Login(input *domain.LoginInput) (*domain.AuthResponse, error) {
+// Login signs a user in. Wrong passwords are counted, and enough of them in a
+// row lock the account for a while; every attempt is recorded. With
+// two-factor sign-in on, a correct password only earns a challenge token for
+// CompleteLogin.
+func (s *AuthService) Login(input *domain.LoginInput, client domain.ClientInfo) (*domain.AuthResponse, error) {
 	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
 
 	user, err := s.userRepo.GetByEmail(input.Email)
 	if err != nil {
+		if errors.Is(err, appErrors.ErrNotFound) {
+			s.recordLogin("", input.Email, client, domain.LoginUnknownUser)
+		}
 		return nil, appErrors.ErrInvalidInput // don't leak "user not found"
 	}
 
+	now := time.Now()
+	if user.LockedUntil != nil && user.LockedUntil.After(now) {
+		s.recordLogin(user.ID, user.Email, client, domain.LoginLocked)
+		return nil, &LockedError{Until: *user.LockedUntil}
+	}
+
 	if !auth.CheckPasswordHash(input.Password, user.PasswordHash) {
+		s.recordLogin(user.ID, user.Email, client, domain.LoginBadPassword)
+		if err := s.userRepo.RecordLoginFailure(user.ID, s.lockUntil(user.FailedLogins+1, now)); err != nil {
+			return nil, err
+		}
 		return nil, appErrors.ErrInvalidInput
 	}
+	if s.requireVerified && user.EmailVerifiedAt == nil {
+		s.recordLogin(user.ID, user.Email, client, domain.LoginUnverified)
+		return nil, ErrEmailNotVerified
+	}
+	if err := s.checkEnabled(user, client); err != nil {
+		return nil, err
+	}
+	if user.PasswordResetRequired {
+		s.recordLogin(user.ID, user.Email, client, domain.LoginMustReset)
+		return nil, ErrPasswordResetRequired
+	}
+	return s.signIn(user, client, domain.LoginSucceeded)
+}
 
-	return s.buildAuthResponse(user)
+// signIn starts a session for a user whose first factor checked out,
+// recording outcome, or challenges them for the second factor.
+func (s *AuthService) signIn(user *domain.User, client domain.ClientInfo, outcome string) (*domain.AuthResponse, error) {
+	if err := s.checkEnabled(user, client); err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		challenge, err := s.issueToken(user, domain.TokenLoginChallenge, loginChallengeTTL)
+		if err != nil {
+			return nil, err
+		}
+		s.recordLogin(user.ID, user.Email, client, domain.LoginChallenged)
+		return &domain.AuthResponse{User: authUser(user), TwoFactorRequired: true, ChallengeToken: challenge}, nil
+	}
+
+	if err := s.userRepo.ResetLoginFailures(user.ID); err != nil {
+		return nil, err
+	}
+	resp, err := s.startSession(user, client)
+	if err != nil {
+		return nil, err
+	}
+	s.recordLogin(user.ID, user.Email, client, outcome)
+	return resp, nil
 }
 
-func (s *AuthService) ChangePassword(userID string, input *domain.ChangePasswordInput) error {
+// checkEnabled refuses sign-in to a disabled account.
+func (s *AuthService) checkEnabled(user *domain.User, client domain.ClientInfo) error {
+	if user.DisabledAt == nil {
+		return nil
+	}
+	s.recordLogin(user.ID, user.Email, client, domain.LoginDisabled)
+	return ErrAccountDisabled
+}
+
+// LoginAttempts returns the user's recent sign-in attempts, newest first.
+func (s *AuthService) LoginAttempts(userID string) ([]domain.LoginAttempt, error) {
+	attempts, err := s.attempts.GetForUser(userID, 50)
+	if err != nil {
+		return nil, err
+	}
+	if attempts == nil {
+		attempts = []domain.LoginAttempt{}
+	}
+	return attempts, nil
+}
+
+// lockUntil returns when sign-in unlocks after failures wrong passwords in
+// a row, or nil if they don't lock it. Each failure past the threshold
+// doubles the lock.
+func (s *AuthService) lockUntil(failures int, now time.Time) *time.Time {
+	if s.lockoutThreshold <= 0 || failures < s.lockoutThreshold {
+		return nil
+	}
+	lock := s.lockoutMax
+	if doublings := failures - s.lockoutThreshold; doublings < 30 {
+		lock = min(s.lockoutBase<<doublings, s.lockoutMax)
+	}
+	until := now.Add(lock)
+	return &until
+}
+
+// recordLogin audits a sign-in attempt. Failing to record one doesn't fail
+// the sign-in.
+func (s *AuthService) recordLogin(userID, email string, client domain.ClientInfo, outcome string) {
+	err := s.attempts.Create(&domain.LoginAttempt{
+		UserID:    userID,
+		Email:     truncateRunes(email, 255),
+		IP:        truncateRunes(client.IP, 64),
+		UserAgent: truncateRunes(client.UserAgent, 500),
+		Outcome:   outcome,
+	})
+	if err != nil {
+		log.Printf("failed to record login attempt for %s: %v", email, err)
+	}
+
+	s.pruneMu.Lock()
+	defer s.pruneMu.Unlock()
+	if now := time.Now(); now.Sub(s.lastPrunedAt) > time.Hour {
+		s.lastPrunedAt = now
+		if err := s.attempts.DeleteBefore(now.Add(-loginAttemptRetention)); err != nil {
+			log.Printf("failed to prune login attempts: %v", err)
+		}
+	}
+}
+
+// Refresh exchanges a refresh token for a new access token and a new
+// refresh token. A refresh token that was already exchanged ends its
+// session: either it leaked, or the legitimate client and an attacker now
+// hold different tokens, and signing in again settles which is which.
+func (s *AuthService) Refresh(refreshToken string, client domain.ClientInfo) (*domain.AuthResponse, error) {
+	if refreshToken == "" {
+		return nil, appErrors.ErrInvalidInput
+	}
+	hash := auth.HashToken(refreshToken)
+	session, err := s.sessions.GetByTokenHash(hash)
+	if errors.Is(err, appErrors.ErrNotFound) {
+		if reused, err := s.sessions.GetByPreviousTokenHash(hash); err == nil {
+			s.sessions.Delete(reused.ID, reused.UserID)
+		}
+		return nil, appErrors.ErrInvalidInput
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !session.ExpiresAt.After(now) {
+		s.sessions.Delete(session.ID, session.UserID)
+		return nil, appErrors.ErrInvalidInput
+	}
+	user, err := s.userRepo.GetByID(session.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	newToken, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	session.RefreshTokenHash = auth.HashToken(newToken)
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(s.refreshExpiry)
+	setClient(session, client)
+	if err := s.sessions.Rotate(session, hash); err != nil {
+		if errors.Is(err, appErrors.ErrNotFound) {
+			return nil, appErrors.ErrInvalidInput
+		}
+		return nil, err
+	}
+	return s.buildAuthResponse(user, session, newToken)
+}
+
+// Logout ends the session of a refresh token. Unknown tokens are ignored, so
+// logging out twice is harmless.
+func (s *AuthService) Logout(refreshToken string) error {
+	if refreshToken == "" {
+		return appErrors.ErrInvalidInput
+	}
+	session, err := s.sessions.GetByTokenHash(auth.HashToken(refreshToken))
+	if errors.Is(err, appErrors.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.sessions.Delete(session.ID, session.UserID)
+}
+
+// ListSessions returns the user's sessions, marking currentID as current.
+func (s *AuthService) ListSessions(userID, currentID string) ([]domain.Session, error) {
+	sessions, err := s.sessions.GetAll(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	if sessions == nil {
+		sessions = []domain.Session{}
+	}
+	return sessions, nil
+}
+
+func (s *AuthService) RevokeSession(userID, id string) error {
+	return s.sessions.Delete(id, userID)
+}
+
+// RevokeAllSessions signs the user out everywhere, this device included.
+func (s *AuthService) RevokeAllSessions(userID string) error {
+	return s.sessions.DeleteAll(userID, "")
+}
+
+// SessionActive reports whether an access token's session is still signed
+// in, recording its use.
+func (s *AuthService) SessionActive(sessionID string) (bool, error) {
+	if sessionID == "" {
+		return false, nil
+	}
+	now := time.Now()
+	active, err := s.sessions.IsActive(sessionID, now)
+	if err != nil || !active {
+		return false, err
+	}
+	return true, s.sessions.Touch(sessionID, now)
+}
+
+// ChangePassword sets a new password and signs out every other session,
+// keeping the one of the request, currentSessionID.
+func (s *AuthService) ChangePassword(userID, currentSessionID string, input *domain.ChangePasswordInput) error {
 	if len(input.NewPassword) < 8 {
 		return appErrors.ErrInvalidInput
 	}
@@ -81,16 +365,104 @@ func (s *AuthService) ChangePassword(userID string, input *domain.ChangePassword
 		return err
 	}
 
-	return s.userRepo.UpdatePasswordHash(userID, hash)
+	if err := s.userRepo.UpdatePasswordHash(userID, hash); err != nil {
+		return err
+	}
+	return s.sessions.DeleteAll(userID, currentSessionID)
 }
 
-func (s *AuthService) buildAuthResponse(user *domain.User) (*domain.AuthResponse, error) {
-	token, err := auth.GenerateToken(user.ID, user.Email, s.jwtSecret, s.jwtExpiry)
+// startSession signs a user in on a new session.
+func (s *AuthService) startSession(user *domain.User, client domain.ClientInfo) (*domain.AuthResponse, error) {
+	now := time.Now()
+	if err := s.sessions.DeleteExpired(user.ID, now); err != nil {
+		return nil, err
+	}
+	refreshToken, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	session := &domain.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: auth.HashToken(refreshToken),
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(s.refreshExpiry),
+	}
+	setClient(session, client)
+	if err := s.sessions.Create(session); err != nil {
+		return nil, err
+	}
+	return s.buildAuthResponse(user, session, refreshToken)
+}
+
+func (s *AuthService) buildAuthResponse(user *domain.User, session *domain.Session, refreshToken string) (*domain.AuthResponse, error) {
+	expiresAt := time.Now().Add(s.jwtExpiry)
+	token, err := auth.GenerateToken(user.ID, user.Email, session.ID, s.jwtSecret, s.jwtExpiry)
 	if err != nil {
 		return nil, err
 	}
 	return &domain.AuthResponse{
-		Token: token,
-		User:  domain.AuthUser{ID: user.ID, Email: user.Email},
+		Token:        token,
+		User:         authUser(user),
+		ExpiresAt:    &expiresAt,
+		RefreshToken: refreshToken,
 	}, nil
 }
+
+func authUser(user *domain.User) domain.AuthUser {
+	return domain.AuthUser{
+		ID:               user.ID,
+		Email:            user.Email,
+		EmailVerified:    user.EmailVerifiedAt != nil,
+		TwoFactorEnabled: user.TOTPEnabledAt != nil,
+		Role:             user.Role,
+	}
+}
+
+func setClient(session *domain.Session, client domain.ClientInfo) {
+	session.UserAgent = truncateRunes(client.UserAgent, 500)
+	session.IP = truncateRunes(client.IP, 64)
+	session.Device = describeDevice(client.UserAgent)
+}
+
+// describeDevice names the browser and operating system of a user agent,
+// such as "Firefox on Linux", for telling sessions apart.
+func describeDevice(userAgent string) string {
+	browser := ""
+	// Order matters: Edge and Opera also claim to be Chrome, and Chrome to
+	// be Safari.
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"}, {"Safari/", "Safari"},
+		{"curl/", "curl"}, {"PostmanRuntime/", "Postman"},
+	} {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	os := ""
+	for _, o := range []struct{ token, name string }{
+		{"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Android", "Android"}, {"Windows", "Windows"},
+		{"Mac OS X", "macOS"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, o.token) {
+			os = o.name
+			break
+		}
+	}
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "" || os != "":
+		return browser + os
+	case userAgent != "":
+		return "Unknown device"
+	}
+	return ""
+}
+
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
@@ -0,0 +1,830 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+	"job-tracker-backend/internal/scan"
+	"job-tracker-backend/internal/storage"
+	appErrors "job-tracker-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+const (
+	backupManifestName    = "manifest.json"
+	maxBackupManifestSize = 256 << 20 // 256MB
+)
+
+type BackupService struct {
+	backupRepo *repository.BackupRepository
+	jobRepo    *repository.JobRepository
+	userRepo   *repository.UserRepository
+	docRepo    *repository.DocumentRepository
+	watchRepo  *repository.WatchRepository
+	blobs      *storage.Manager
+	scanner    scan.Scanner
+}
+
+func NewBackupService(backupRepo *repository.BackupRepository, jobRepo *repository.JobRepository, userRepo *repository.UserRepository, docRepo *repository.DocumentRepository, watchRepo *repository.WatchRepository, blobs *storage.Manager, scanner scan.Scanner) *BackupService {
+	return &BackupService{backupRepo: backupRepo, jobRepo: jobRepo, userRepo: userRepo, docRepo: docRepo, watchRepo: watchRepo, blobs: blobs, scanner: scanner}
+}
+
+// WriteArchive writes a zip archive of everything the user owns to w.
+// File contents are loaded and written one at a time.
+func (s *BackupService) WriteArchive(userID string, w io.Writer) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	manifest := &domain.BackupManifest{
+		FormatVersion:    domain.BackupFormatVersion,
+		CreatedAt:        time.Now().UTC(),
+		Owner:            domain.BackupOwner{ID: user.ID, Email: user.Email},
+		Jobs:             []domain.BackupJob{},
+		Attachments:      []domain.BackupAttachment{},
+		StatusHistory:    []domain.StatusChange{},
+		Documents:        []domain.BackupDocument{},
+		DocumentVersions: []domain.BackupDocumentVersion{},
+		Watches:          []domain.BackupWatch{},
+		Notifications:    []domain.Notification{},
+	}
+	backends := make(map[string]string) // path in the archive -> backend holding the contents
+
+	err = s.jobRepo.Stream(&domain.JobFilter{UserID: userID}, exportBatchSize, func(jobs []domain.Job) error {
+		ids := make([]string, len(jobs))
+		for i, job := range jobs {
+			ids[i] = job.ID
+			manifest.Jobs = append(manifest.Jobs, toBackupJob(&job))
+		}
+		attachments, err := s.jobRepo.GetAttachmentMetadataByJobIDs(ids)
+		if err != nil {
+			return err
+		}
+		history, err := s.jobRepo.GetStatusHistoryByJobIDs(ids)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			for _, att := range attachments[id] {
+				meta := domain.BackupAttachment{
+					ID:                att.ID,
+					JobID:             att.JobID,
+					FileName:          att.FileName,
+					FileType:          att.FileType,
+					MIMEType:          att.MIMEType,
+					FileSize:          att.FileSize,
+					CreatedAt:         att.CreatedAt,
+					DocumentVersionID: att.DocumentVersionID,
+				}
+				if att.DocumentVersionID == nil {
+					meta.Path = "attachments/" + att.ID
+					backends[meta.Path] = att.StorageBackend
+				}
+				manifest.Attachments = append(manifest.Attachments, meta)
+			}
+			manifest.StatusHistory = append(manifest.StatusHistory, history[id]...)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	docs, err := s.docRepo.GetAll(userID)
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		manifest.Documents = append(manifest.Documents, domain.BackupDocument{
+			ID:        doc.ID,
+			Name:      doc.Name,
+			FileType:  doc.FileType,
+			CreatedAt: doc.CreatedAt,
+			UpdatedAt: doc.UpdatedAt,
+		})
+		for _, v := range doc.Versions {
+			meta := domain.BackupDocumentVersion{
+				ID:          v.ID,
+				DocumentID:  v.DocumentID,
+				Version:     v.Version,
+				Label:       v.Label,
+				FileName:    v.FileName,
+				MIMEType:    v.MIMEType,
+				FileSize:    v.FileSize,
+				ContentHash: v.ContentHash,
+				Path:        "documents/" + v.ID,
+				CreatedAt:   v.CreatedAt,
+			}
+			backends[meta.Path] = v.StorageBackend
+			manifest.DocumentVersions = append(manifest.DocumentVersions, meta)
+		}
+	}
+
+	if err := s.addSettings(userID, manifest); err != nil {
+		return err
+	}
+	if err := s.addWatches(userID, manifest); err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	mw, err := zw.Create(backupManifestName)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return err
+	}
+
+	for _, meta := range manifest.Attachments {
+		if meta.Path == "" {
+			continue
+		}
+		if err := s.writeFile(zw, meta.Path, meta.ID, backends[meta.Path], meta.CreatedAt); err != nil {
+			return fmt.Errorf("load attachment %s: %w", meta.ID, err)
+		}
+	}
+	for _, meta := range manifest.DocumentVersions {
+		if err := s.writeFile(zw, meta.Path, meta.ID, backends[meta.Path], meta.CreatedAt); err != nil {
+			return fmt.Errorf("load document version %s: %w", meta.ID, err)
+		}
+	}
+	return zw.Close()
+}
+
+// addSettings adds the user's ranking profile and preference model, if they
+// have them.
+func (s *BackupService) addSettings(userID string, manifest *domain.BackupManifest) error {
+	profile, err := s.userRepo.GetRankingProfile(userID)
+	if err == nil {
+		manifest.RankingProfile = profile
+	} else if !errors.Is(err, appErrors.ErrNotFound) {
+		return err
+	}
+
+	model, err := s.userRepo.GetPreferenceModel(userID)
+	if err == nil {
+		manifest.PreferenceModel = &domain.BackupPreferenceModel{
+			Model:     model.Model,
+			Liked:     model.Liked,
+			Disliked:  model.Disliked,
+			TrainedAt: model.TrainedAt,
+		}
+	} else if !errors.Is(err, appErrors.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// addWatches adds the user's company watches, the postings they have seen
+// and the user's notifications.
+func (s *BackupService) addWatches(userID string, manifest *domain.BackupManifest) error {
+	watches, err := s.watchRepo.GetAll(userID)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, len(watches))
+	for i, w := range watches {
+		ids[i] = w.ID
+	}
+	postings, err := s.backupRepo.GetWatchedPostings(ids)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string][]domain.BackupSeenPosting)
+	for _, p := range postings {
+		seen[p.WatchID] = append(seen[p.WatchID], domain.BackupSeenPosting{Key: p.PostingKey, FirstSeenAt: p.FirstSeenAt})
+	}
+	for _, w := range watches {
+		manifest.Watches = append(manifest.Watches, domain.BackupWatch{
+			ID:           w.ID,
+			Company:      w.Company,
+			ATS:          w.ATS,
+			BoardID:      w.BoardID,
+			AutoSave:     w.AutoSave,
+			LastPolledAt: w.LastPolledAt,
+			LastError:    w.LastError,
+			OpenPostings: w.OpenPostings,
+			CreatedAt:    w.CreatedAt,
+			UpdatedAt:    w.UpdatedAt,
+			Seen:         seen[w.ID],
+		})
+	}
+
+	notifications, err := s.backupRepo.GetNotifications(userID)
+	if err != nil {
+		return err
+	}
+	manifest.Notifications = append(manifest.Notifications, notifications...)
+	return nil
+}
+
+// writeFile copies the contents stored under key into the archive at path.
+func (s *BackupService) writeFile(zw *zip.Writer, path, key, backend string, modified time.Time) error {
+	// Attachments are already compressed document formats; storing them
+	// avoids burning CPU for no gain.
+	fw, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     path,
+		Method:   zip.Store,
+		Modified: modified,
+	})
+	if err != nil {
+		return err
+	}
+	store, err := s.blobs.Store(backend)
+	if err != nil {
+		return err
+	}
+	r, err := store.Open(key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(fw, r)
+	return err
+}
+
+// RestoreArchive imports an archive produced by WriteArchive into the given
+// account. Every record gets a new ID, and what the account already has is
+// skipped: jobs with the same URL or, without one, the same title, company
+// and creation time, together with their attachments and history; document
+// versions with the same contents; and watches of the same board. The
+// ranking profile and preference model are only restored if the account
+// has none. Records are validated like those created through the API, and
+// files are checked and scanned like uploads. Files are stored before the
+// rows are inserted in one transaction; if anything fails, nothing is kept.
+func (s *BackupService) RestoreArchive(userID string, r io.ReaderAt, size int64) (result *domain.RestoreResult, err error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not a zip archive: %v", appErrors.ErrInvalidInput, err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	mf, ok := files[backupManifestName]
+	if !ok {
+		return nil, fmt.Errorf("%w: archive has no %s", appErrors.ErrInvalidInput, backupManifestName)
+	}
+
+	var manifest domain.BackupManifest
+	if err := readZipJSON(mf, maxBackupManifestSize, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: invalid manifest: %v", appErrors.ErrInvalidInput, err)
+	}
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > domain.BackupFormatVersion {
+		return nil, fmt.Errorf("%w: unsupported backup format version %d", appErrors.ErrInvalidInput, manifest.FormatVersion)
+	}
+	if err := validateManifest(&manifest); err != nil {
+		return nil, err
+	}
+
+	rs := &archiveRestore{
+		s:        s,
+		userID:   userID,
+		files:    files,
+		store:    s.blobs.Primary(),
+		jobIDs:   make(map[string]string),
+		versions: make(map[string]*domain.DocumentVersion),
+		hashes:   make(map[string]*domain.DocumentVersion),
+		watchIDs: make(map[string]string),
+	}
+	defer func() {
+		if err != nil {
+			rs.discard()
+		}
+	}()
+
+	steps := []func(*domain.BackupManifest) error{
+		rs.documents,
+		rs.jobs,
+		rs.attachments,
+		rs.statusHistory,
+		rs.watches,
+		rs.notifications,
+		rs.settings,
+	}
+	for _, step := range steps {
+		if err := step(&manifest); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.backupRepo.Restore(&rs.set); err != nil {
+		return nil, fmt.Errorf("restore: %w", err)
+	}
+
+	result = &rs.result
+	result.JobsCreated = len(rs.set.Jobs)
+	result.AttachmentsCreated = len(rs.set.Attachments)
+	result.StatusChangesCreated = len(rs.set.StatusChanges)
+	result.DocumentsCreated = len(rs.set.Documents)
+	result.DocumentVersionsCreated = len(rs.set.DocumentVersions)
+	result.WatchesCreated = len(rs.set.Watches)
+	result.NotificationsCreated = len(rs.set.Notifications)
+	result.RankingProfileRestored = rs.set.RankingProfile != nil
+	result.PreferenceModelRestored = rs.set.PreferenceModel != nil
+	return result, nil
+}
+
+// validateManifest checks the records of an archive the way they are
+// checked when created through the API, so a hand-edited archive can't
+// restore what the API would refuse.
+func validateManifest(m *domain.BackupManifest) error {
+	for _, bj := range m.Jobs {
+		if err := validateJobStatus(bj.Status); err != nil {
+			return fmt.Errorf("job %s: %w", bj.ID, err)
+		}
+	}
+	for _, sc := range m.StatusHistory {
+		if err := validateJobStatus(sc.ToStatus); err != nil {
+			return fmt.Errorf("status change %s: %w", sc.ID, err)
+		}
+		if sc.FromStatus != "" {
+			if err := validateJobStatus(sc.FromStatus); err != nil {
+				return fmt.Errorf("status change %s: %w", sc.ID, err)
+			}
+		}
+	}
+	for _, ba := range m.Attachments {
+		if err := validateAttachmentType(ba.FileType, ba.MIMEType); err != nil {
+			return fmt.Errorf("%w: attachment %s: %v", appErrors.ErrInvalidInput, ba.ID, err)
+		}
+	}
+	docs := make(map[string]*domain.BackupDocument, len(m.Documents))
+	for i := range m.Documents {
+		docs[m.Documents[i].ID] = &m.Documents[i]
+	}
+	for _, bv := range m.DocumentVersions {
+		bd, ok := docs[bv.DocumentID]
+		if !ok {
+			return fmt.Errorf("%w: document version %s has no document", appErrors.ErrInvalidInput, bv.ID)
+		}
+		if err := validateAttachmentType(bd.FileType, bv.MIMEType); err != nil {
+			return fmt.Errorf("%w: document version %s: %v", appErrors.ErrInvalidInput, bv.ID, err)
+		}
+	}
+	for _, bw := range m.Watches {
+		if err := ValidateBoard(bw.ATS, bw.BoardID); err != nil {
+			return fmt.Errorf("%w: watch %s: %v", appErrors.ErrInvalidInput, bw.ID, err)
+		}
+	}
+	return nil
+}
+
+// archiveRestore is the state of one RestoreArchive call: the rows to
+// insert, and which archive IDs they replace.
+type archiveRestore struct {
+	s       *BackupService
+	userID  string
+	files   map[string]*zip.File
+	store   storage.BlobStore
+	set     domain.RestoreSet
+	result  domain.RestoreResult
+	written []string // blob keys stored so far
+
+	jobIDs   map[string]string                  // archive job ID -> new job ID
+	versions map[string]*domain.DocumentVersion // archive version ID -> version to link to
+	hashes   map[string]*domain.DocumentVersion // content hash -> restored or existing version
+	watchIDs map[string]string                  // archive watch ID -> new watch ID
+}
+
+// discard removes the contents stored by a failed restore.
+func (rs *archiveRestore) discard() {
+	for _, key := range rs.written {
+		if err := rs.store.Delete(key); err != nil {
+			log.Printf("failed to remove restored contents %s: %v", key, err)
+		}
+	}
+}
+
+// stageFile stages the archive member at path the way uploads are: its
+// contents must be a document of type declared, and pass the scanner.
+func (rs *archiveRestore) stageFile(path, declared string) (*stagedUpload, error) {
+	f, ok := rs.files[path]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s missing from archive", appErrors.ErrInvalidInput, path)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", appErrors.ErrInvalidInput, path, err)
+	}
+	defer rc.Close()
+	upload, err := stageUpload(rc, declared, rs.s.scanner)
+	if errors.Is(err, ErrFileTooLarge) {
+		return nil, fmt.Errorf("%w: %s: %v", appErrors.ErrInvalidInput, path, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return upload, nil
+}
+
+// put stores a staged file under key, to be removed again if the restore
+// fails.
+func (rs *archiveRestore) put(key string, upload *stagedUpload) error {
+	rs.written = append(rs.written, key)
+	return rs.store.Put(key, upload, upload.size, upload.mimeType)
+}
+
+// documents restores document versions the account doesn't already have,
+// creating their documents as needed. Versions it has are linked to instead.
+func (rs *archiveRestore) documents(m *domain.BackupManifest) error {
+	docs := make(map[string]*domain.BackupDocument, len(m.Documents))
+	for i := range m.Documents {
+		docs[m.Documents[i].ID] = &m.Documents[i]
+	}
+	docIDs := make(map[string]string) // archive document ID -> new document ID
+
+	for _, bv := range m.DocumentVersions {
+		if err := rs.document(docs, docIDs, &bv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// document restores one document version, or links to the version with the
+// same contents if there already is one.
+func (rs *archiveRestore) document(docs map[string]*domain.BackupDocument, docIDs map[string]string, bv *domain.BackupDocumentVersion) error {
+	upload, err := rs.stageFile(bv.Path, bv.MIMEType)
+	if err != nil {
+		return err
+	}
+	defer upload.Close()
+
+	if v, ok := rs.hashes[upload.hash]; ok {
+		rs.versions[bv.ID] = v
+		rs.result.DocumentVersionsSkipped++
+		return nil
+	}
+	existing, err := rs.s.docRepo.GetVersionByHash(rs.userID, upload.hash)
+	if err == nil {
+		rs.versions[bv.ID] = existing
+		rs.hashes[upload.hash] = existing
+		rs.result.DocumentVersionsSkipped++
+		return nil
+	}
+	if !errors.Is(err, appErrors.ErrNotFound) {
+		return err
+	}
+
+	docID, ok := docIDs[bv.DocumentID]
+	if !ok {
+		bd, ok := docs[bv.DocumentID]
+		if !ok {
+			return fmt.Errorf("%w: document version %s has no document", appErrors.ErrInvalidInput, bv.ID)
+		}
+		doc := domain.Document{
+			ID:        uuid.New().String(),
+			UserID:    rs.userID,
+			Name:      bd.Name,
+			FileType:  bd.FileType,
+			CreatedAt: bd.CreatedAt,
+			UpdatedAt: bd.UpdatedAt,
+		}
+		rs.set.Documents = append(rs.set.Documents, doc)
+		docID = doc.ID
+		docIDs[bd.ID] = docID
+	}
+
+	version := &domain.DocumentVersion{
+		ID:             uuid.New().String(),
+		DocumentID:     docID,
+		UserID:         rs.userID,
+		Version:        bv.Version,
+		Label:          bv.Label,
+		FileName:       bv.FileName,
+		MIMEType:       upload.mimeType,
+		FileSize:       upload.size,
+		ContentHash:    upload.hash,
+		StorageBackend: rs.store.Name(),
+		ExtractedText:  extractText(upload.mimeType, upload.File, upload.size),
+		CreatedAt:      bv.CreatedAt,
+	}
+	if err := rs.put(version.ID, upload); err != nil {
+		return fmt.Errorf("restore document version %s: %w", bv.ID, err)
+	}
+	rs.set.DocumentVersions = append(rs.set.DocumentVersions, *version)
+	rs.versions[bv.ID] = version
+	rs.hashes[upload.hash] = version
+	return nil
+}
+
+func (rs *archiveRestore) jobs(m *domain.BackupManifest) error {
+	seen := make(map[string]bool) // restoreJobKey of jobs in the archive
+	for _, bj := range m.Jobs {
+		job := fromBackupJob(&bj, rs.userID)
+		key := restoreJobKey(job)
+		duplicate := seen[key]
+		if !duplicate {
+			var err error
+			if duplicate, err = rs.s.jobRepo.HasDuplicate(job); err != nil {
+				return err
+			}
+		}
+		seen[key] = true
+		if duplicate {
+			rs.result.JobsSkipped++
+			continue
+		}
+		job.ID = uuid.New().String()
+		raw := bj.DescriptionRaw
+		if raw == "" {
+			raw = bj.Description
+		}
+		setDescription(job, raw)
+		analyzeJob(job)
+		rs.set.Jobs = append(rs.set.Jobs, *job)
+		rs.jobIDs[bj.ID] = job.ID
+	}
+	return nil
+}
+
+// attachments restores the attachments of restored jobs, linking those that
+// linked to a document version to its restored or existing counterpart.
+func (rs *archiveRestore) attachments(m *domain.BackupManifest) error {
+	for _, ba := range m.Attachments {
+		jobID, ok := rs.jobIDs[ba.JobID]
+		if !ok {
+			continue
+		}
+		if ba.DocumentVersionID != nil {
+			v, ok := rs.versions[*ba.DocumentVersionID]
+			if !ok {
+				return fmt.Errorf("%w: attachment %s links to a document version missing from archive", appErrors.ErrInvalidInput, ba.ID)
+			}
+			now := time.Now()
+			rs.set.Attachments = append(rs.set.Attachments, domain.Attachment{
+				ID:                uuid.New().String(),
+				JobID:             jobID,
+				FileName:          ba.FileName,
+				FileType:          ba.FileType,
+				MIMEType:          v.MIMEType,
+				FileSize:          v.FileSize,
+				ContentHash:       v.ContentHash,
+				CreatedAt:         ba.CreatedAt,
+				StorageBackend:    v.StorageBackend,
+				DocumentVersionID: &v.ID,
+				ExtractedText:     v.ExtractedText,
+				TextExtractedAt:   &now,
+			})
+			continue
+		}
+
+		attachment, err := rs.attachment(&ba, jobID)
+		if err != nil {
+			return err
+		}
+		rs.set.Attachments = append(rs.set.Attachments, *attachment)
+	}
+	return nil
+}
+
+// attachment stores the file of an attachment that holds its own copy.
+func (rs *archiveRestore) attachment(ba *domain.BackupAttachment, jobID string) (*domain.Attachment, error) {
+	upload, err := rs.stageFile(ba.Path, ba.MIMEType)
+	if err != nil {
+		return nil, err
+	}
+	defer upload.Close()
+
+	now := time.Now()
+	attachment := &domain.Attachment{
+		ID:              uuid.New().String(),
+		JobID:           jobID,
+		FileName:        ba.FileName,
+		FileType:        ba.FileType,
+		MIMEType:        upload.mimeType,
+		FileSize:        upload.size,
+		ContentHash:     upload.hash,
+		CreatedAt:       ba.CreatedAt,
+		StorageBackend:  rs.store.Name(),
+		ExtractedText:   extractText(upload.mimeType, upload.File, upload.size),
+		TextExtractedAt: &now,
+	}
+	if err := rs.put(attachment.ID, upload); err != nil {
+		return nil, fmt.Errorf("restore attachment %s: %w", ba.ID, err)
+	}
+	return attachment, nil
+}
+
+func (rs *archiveRestore) statusHistory(m *domain.BackupManifest) error {
+	for _, sc := range m.StatusHistory {
+		jobID, ok := rs.jobIDs[sc.JobID]
+		if !ok {
+			continue
+		}
+		rs.set.StatusChanges = append(rs.set.StatusChanges, domain.StatusChange{
+			ID:         uuid.New().String(),
+			JobID:      jobID,
+			UserID:     rs.userID,
+			FromStatus: sc.FromStatus,
+			ToStatus:   sc.ToStatus,
+			ChangedAt:  sc.ChangedAt,
+		})
+	}
+	return nil
+}
+
+// watches restores company watches of boards the account doesn't watch yet,
+// with the postings they have seen.
+func (rs *archiveRestore) watches(m *domain.BackupManifest) error {
+	seen := make(map[string]bool) // ATS and board of watches in the archive
+	for _, bw := range m.Watches {
+		key := bw.ATS + "\x00" + bw.BoardID
+		duplicate := seen[key]
+		if !duplicate {
+			var err error
+			if duplicate, err = rs.s.watchRepo.ExistsForBoard(rs.userID, bw.ATS, bw.BoardID); err != nil {
+				return err
+			}
+		}
+		seen[key] = true
+		if duplicate {
+			rs.result.WatchesSkipped++
+			continue
+		}
+		watch := domain.CompanyWatch{
+			ID:           uuid.New().String(),
+			UserID:       rs.userID,
+			Company:      bw.Company,
+			ATS:          bw.ATS,
+			BoardID:      bw.BoardID,
+			AutoSave:     bw.AutoSave,
+			LastPolledAt: bw.LastPolledAt,
+			LastError:    bw.LastError,
+			OpenPostings: bw.OpenPostings,
+			CreatedAt:    bw.CreatedAt,
+			UpdatedAt:    bw.UpdatedAt,
+		}
+		rs.set.Watches = append(rs.set.Watches, watch)
+		rs.watchIDs[bw.ID] = watch.ID
+
+		keys := make(map[string]bool, len(bw.Seen))
+		for _, p := range bw.Seen {
+			if keys[p.Key] {
+				continue
+			}
+			keys[p.Key] = true
+			rs.set.WatchedPostings = append(rs.set.WatchedPostings, domain.WatchedPosting{
+				WatchID:     watch.ID,
+				PostingKey:  p.Key,
+				FirstSeenAt: p.FirstSeenAt,
+			})
+		}
+	}
+	return nil
+}
+
+// notifications restores notifications along with the watch that raised
+// them or, for those without one, the job they are about.
+func (rs *archiveRestore) notifications(m *domain.BackupManifest) error {
+	for _, bn := range m.Notifications {
+		n := bn
+		n.ID = uuid.New().String()
+		n.UserID = rs.userID
+		n.WatchID, n.JobID = nil, nil
+		if bn.JobID != nil {
+			if id, ok := rs.jobIDs[*bn.JobID]; ok {
+				n.JobID = &id
+			}
+		}
+		if bn.WatchID != nil {
+			id, ok := rs.watchIDs[*bn.WatchID]
+			if !ok {
+				continue
+			}
+			n.WatchID = &id
+		} else if bn.JobID != nil && n.JobID == nil {
+			continue
+		}
+		rs.set.Notifications = append(rs.set.Notifications, n)
+	}
+	return nil
+}
+
+// settings restores the ranking profile and preference model unless the
+// account has its own.
+func (rs *archiveRestore) settings(m *domain.BackupManifest) error {
+	if m.RankingProfile != nil {
+		_, err := rs.s.userRepo.GetRankingProfile(rs.userID)
+		if errors.Is(err, appErrors.ErrNotFound) {
+			profile := *m.RankingProfile
+			profile.UserID = rs.userID
+			rs.set.RankingProfile = &profile
+		} else if err != nil {
+			return err
+		}
+	}
+	if m.PreferenceModel != nil {
+		_, err := rs.s.userRepo.GetPreferenceModel(rs.userID)
+		if errors.Is(err, appErrors.ErrNotFound) {
+			rs.set.PreferenceModel = &domain.PreferenceModel{
+				UserID:    rs.userID,
+				Model:     m.PreferenceModel.Model,
+				Liked:     m.PreferenceModel.Liked,
+				Disliked:  m.PreferenceModel.Disliked,
+				TrainedAt: m.PreferenceModel.TrainedAt,
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreJobKey identifies a job the way JobRepository.HasDuplicate matches
+// it, to catch duplicates within one archive.
+func restoreJobKey(job *domain.Job) string {
+	if job.JobURL != "" {
+		return "url\x00" + job.JobURL
+	}
+	return strings.Join([]string{"job", job.JobTitle, job.CompanyName, job.CreatedAt.UTC().Format(time.RFC3339Nano)}, "\x00")
+}
+
+func readZipJSON(f *zip.File, limit int64, v interface{}) error {
+	data, err := readZipFile(f, limit)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// readZipFile reads an archive member, refusing members that expand beyond
+// limit bytes.
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s exceeds %d bytes", f.Name, limit)
+	}
+	return data, nil
+}
+
+func toBackupJob(job *domain.Job) domain.BackupJob {
+	return domain.BackupJob{
+		ID:           job.ID,
+		JobTitle:     job.JobTitle,
+		CompanyName:  job.CompanyName,
+		Location:     job.Location,
+		JobURL:       job.JobURL,
+		Description:  job.Description,
+		Salary:       job.Salary,
+		JobType:      job.JobType,
+		IsRemote:     job.IsRemote,
+		EasyApply:    job.EasyApply,
+		ViaRecruiter: job.ViaRecruiter,
+		Source:       job.Source,
+		Status:       job.Status,
+		Notes:        job.Notes,
+		InterviewAt:  job.InterviewAt,
+		FollowUpAt:   job.FollowUpAt,
+		DeadlineAt:   job.DeadlineAt,
+		CreatedAt:    job.CreatedAt,
+		UpdatedAt:    job.UpdatedAt,
+
+		DescriptionRaw: job.DescriptionRaw,
+	}
+}
+
+func fromBackupJob(bj *domain.BackupJob, userID string) *domain.Job {
+	return &domain.Job{
+		UserID:       userID,
+		JobTitle:     bj.JobTitle,
+		CompanyName:  bj.CompanyName,
+		Location:     bj.Location,
+		JobURL:       bj.JobURL,
+		Salary:       bj.Salary,
+		JobType:      bj.JobType,
+		IsRemote:     bj.IsRemote,
+		EasyApply:    bj.EasyApply,
+		ViaRecruiter: bj.ViaRecruiter,
+		Source:       bj.Source,
+		Status:       bj.Status,
+		Notes:        bj.Notes,
+		InterviewAt:  bj.InterviewAt,
+		FollowUpAt:   bj.FollowUpAt,
+		DeadlineAt:   bj.DeadlineAt,
+		CreatedAt:    bj.CreatedAt,
+		UpdatedAt:    bj.UpdatedAt,
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+	"job-tracker-backend/internal/scan"
+	"job-tracker-backend/internal/storage"
+	appErrors "job-tracker-backend/pkg/errors"
+)
+
+func TestRestoreArchiveRejectsTamperedRecords(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(m *domain.BackupManifest)
+	}{
+		{
+			name:   "unknown job status",
+			tamper: func(m *domain.BackupManifest) { m.Jobs[0].Status = "hired" },
+		},
+		{
+			name:   "unknown status in history",
+			tamper: func(m *domain.BackupManifest) { m.StatusHistory[0].ToStatus = "hired" },
+		},
+		{
+			name:   "unknown previous status in history",
+			tamper: func(m *domain.BackupManifest) { m.StatusHistory[0].FromStatus = "<script>" },
+		},
+		{
+			name:   "unknown attachment file type",
+			tamper: func(m *domain.BackupManifest) { m.Attachments[0].FileType = "portfolio" },
+		},
+		{
+			name:   "attachment of a disallowed type",
+			tamper: func(m *domain.BackupManifest) { m.Attachments[0].MIMEType = "text/html" },
+		},
+		{
+			name:   "unknown document file type",
+			tamper: func(m *domain.BackupManifest) { m.Documents[0].FileType = "portfolio" },
+		},
+		{
+			name:   "document version of a disallowed type",
+			tamper: func(m *domain.BackupManifest) { m.DocumentVersions[0].MIMEType = "application/x-msdownload" },
+		},
+		{
+			name:   "document version without its document",
+			tamper: func(m *domain.BackupManifest) { m.DocumentVersions[0].DocumentID = "doc-missing" },
+		},
+		{
+			name:   "unsupported ATS",
+			tamper: func(m *domain.BackupManifest) { m.Watches[0].ATS = "workday" },
+		},
+		{
+			name:   "invalid board",
+			tamper: func(m *domain.BackupManifest) { m.Watches[0].BoardID = "../admin" },
+		},
+	}
+
+	if err := validateManifest(testBackupManifest()); err != nil {
+		t.Fatalf("validateManifest() of the untampered archive error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The archive is refused before anything is looked up or
+			// stored: the mock database expects no statements, and its
+			// files would pass the checks uploads get
+			db, _ := newMockDB(t)
+			root := t.TempDir()
+			store, err := storage.NewFilesystemStore(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			blobs, err := storage.NewManager(storage.BackendFilesystem, store)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := NewBackupService(repository.NewBackupRepository(db), repository.NewJobRepository(db), repository.NewUserRepository(db),
+				repository.NewDocumentRepository(db), repository.NewWatchRepository(db), blobs, scan.Nop{})
+
+			m := testBackupManifest()
+			tt.tamper(m)
+			archive := testBackupArchive(t, m)
+			_, err = s.RestoreArchive("user-1", bytes.NewReader(archive), int64(len(archive)))
+			if !errors.Is(err, appErrors.ErrInvalidInput) {
+				t.Fatalf("RestoreArchive() error = %v, want invalid input", err)
+			}
+			if entries, _ := os.ReadDir(root); len(entries) != 0 {
+				t.Errorf("RestoreArchive() stored %d files", len(entries))
+			}
+		})
+	}
+}
+
+// testBackupManifest returns the manifest of a valid archive with one of
+// each record.
+func testBackupManifest() *domain.BackupManifest {
+	versionID := "version-1"
+	return &domain.BackupManifest{
+		FormatVersion: domain.BackupFormatVersion,
+		Jobs: []domain.BackupJob{
+			{ID: "job-1", JobTitle: "Go Developer", JobURL: "https://example.com/jobs/1", Status: string(domain.StatusApplied)},
+		},
+		StatusHistory: []domain.StatusChange{
+			{ID: "change-1", JobID: "job-1", FromStatus: string(domain.StatusNew), ToStatus: string(domain.StatusApplied)},
+		},
+		Attachments: []domain.BackupAttachment{
+			{ID: "att-1", JobID: "job-1", FileName: "resume.pdf", FileType: AllowedFileTypeResume, MIMEType: "application/pdf", Path: "files/att-1"},
+			{ID: "att-2", JobID: "job-1", FileName: "letter.pdf", FileType: AllowedFileTypeCoverLetter, MIMEType: "application/pdf", DocumentVersionID: &versionID},
+		},
+		Documents: []domain.BackupDocument{
+			{ID: "doc-1", Name: "Cover letter", FileType: AllowedFileTypeCoverLetter},
+		},
+		DocumentVersions: []domain.BackupDocumentVersion{
+			{ID: versionID, DocumentID: "doc-1", Version: 1, FileName: "letter.pdf", MIMEType: "application/pdf", Path: "documents/version-1"},
+		},
+		Watches: []domain.BackupWatch{
+			{ID: "watch-1", Company: "Acme", ATS: ATSGreenhouse, BoardID: "acme"},
+		},
+	}
+}
+
+// testPDF is the smallest file uploads accept as a PDF.
+const testPDF = "%PDF-1.4\nstartxref\n0\n%%EOF\n"
+
+// testBackupArchive returns an archive of m, with a PDF at each file path.
+func testBackupArchive(t *testing.T, m *domain.BackupManifest) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string][]byte{}
+	for _, ba := range m.Attachments {
+		if ba.Path != "" {
+			files[ba.Path] = []byte(testPDF)
+		}
+	}
+	for _, bv := range m.DocumentVersions {
+		files[bv.Path] = []byte(testPDF)
+	}
+	manifest, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files[backupManifestName] = manifest
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"job-tracker-backend/internal/auth"
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/ical"
+)
+
+const (
+	calendarProdID         = "-//jobby_search//Job Tracker//EN"
+	interviewDuration      = time.Hour
+	interviewAlarmLeadTime = 30 * time.Minute
+)
+
+type CalendarService struct {
+	jobRepo       *repository.JobRepository
+	userRepo      *repository.UserRepository
+	publicBaseURL string
+}
+
+func NewCalendarService(jobRepo *repository.JobRepository, userRepo *repository.UserRepository, publicBaseURL string) *CalendarService {
+	return &CalendarService{
+		jobRepo:       jobRepo,
+		userRepo:      userRepo,
+		publicBaseURL: strings.TrimRight(publicBaseURL, "/"),
+	}
+}
+
+// GetFeed creates the user's feed on first use, returning its token and
+// subscription URL. Once it exists only its hash is kept, so later calls
+// just report it as active; RotateFeed issues a new URL.
+func (s *CalendarService) GetFeed(userID string) (*domain.CalendarFeedResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.CalendarTokenHash == "" {
+		return s.RotateFeed(userID)
+	}
+	return &domain.CalendarFeedResponse{Active: true}, nil
+}
+
+// RotateFeed replaces the user's feed token, invalidating previously shared
+// subscription URLs.
+func (s *CalendarService) RotateFeed(userID string) (*domain.CalendarFeedResponse, error) {
+	token, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateCalendarTokenHash(userID, auth.HashToken(token)); err != nil {
+		return nil, err
+	}
+	return s.feedResponse(token), nil
+}
+
+// FeedByToken renders the .ics feed for the user owning token.
+func (s *CalendarService) FeedByToken(token string) ([]byte, error) {
+	user, err := s.userRepo.GetByCalendarTokenHash(auth.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	// A disabled account's feed goes dark with the rest of its access
+	if user.DisabledAt != nil {
+		return nil, appErrors.ErrNotFound
+	}
+	jobs, err := s.jobRepo.GetScheduled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &ical.Calendar{ProdID: calendarProdID, Name: "Job Tracker"}
+	for i := range jobs {
+		cal.Events = append(cal.Events, jobEvents(&jobs[i])...)
+	}
+	return render(cal)
+}
+
+// JobCalendar renders a standalone .ics file with the events of a single job.
+func (s *CalendarService) JobCalendar(userID, jobID string) ([]byte, error) {
+	job, err := s.jobRepo.GetByID(jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	cal := &ical.Calendar{ProdID: calendarProdID, Events: jobEvents(job)}
+	return render(cal)
+}
+
+func (s *CalendarService) feedResponse(token string) *domain.CalendarFeedResponse {
+	return &domain.CalendarFeedResponse{
+		Active: true,
+		Token:  token,
+		URL:    fmt.Sprintf("%s/api/calendar/%s.ics", s.publicBaseURL, token),
+	}
+}
+
+func render(cal *ical.Calendar) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := cal.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func jobEvents(job *domain.Job) []ical.Event {
+	title := job.JobTitle
+	if job.CompanyName != "" {
+		title = fmt.Sprintf("%s at %s", job.JobTitle, job.CompanyName)
+	}
+
+	var events []ical.Event
+	if job.InterviewAt != nil {
+		events = append(events, ical.Event{
+			UID:         fmt.Sprintf("interview-%s@jobby_search", job.ID),
+			Summary:     "Interview: " + title,
+			Description: job.Notes,
+			Location:    job.Location,
+			URL:         job.JobURL,
+			Start:       *job.InterviewAt,
+			End:         job.InterviewAt.Add(interviewDuration),
+			Categories:  []string{"Interview"},
+			Alarm: &ical.Alarm{
+				Before:      interviewAlarmLeadTime,
+				Description: "Interview: " + title,
+			},
+		})
+	}
+	if job.FollowUpAt != nil {
+		events = append(events, ical.Event{
+			UID:        fmt.Sprintf("follow-up-%s@jobby_search", job.ID),
+			Summary:    "Follow up: " + title,
+			URL:        job.JobURL,
+			Start:      *job.FollowUpAt,
+			AllDay:     true,
+			Categories: []string{"Reminder"},
+		})
+	}
+	if job.DeadlineAt != nil {
+		events = append(events, ical.Event{
+			UID:        fmt.Sprintf("deadline-%s@jobby_search", job.ID),
+			Summary:    "Application deadline: " + title,
+			URL:        job.JobURL,
+			Start:      *job.DeadlineAt,
+			AllDay:     true,
+			Categories: []string{"Deadline"},
+		})
+	}
+	return events
+}
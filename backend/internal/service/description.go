@@ -0,0 +1,77 @@
+package service
+
+import (
+	"fmt"
+
+	"job-tracker-backend/internal/domain"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/richtext"
+)
+
+// Renderings of a job description served by the API. HTML is the stored,
+// sanitized form; raw is the description exactly as it was received.
+const (
+	DescriptionHTML     = "html"
+	DescriptionMarkdown = "markdown"
+	DescriptionText     = "text"
+	DescriptionRaw      = "raw"
+)
+
+// setDescription stores raw as the job's original description and its
+// sanitized HTML as the description served by default.
+func setDescription(job *domain.Job, raw string) {
+	job.DescriptionRaw = raw
+	job.Description, job.DescriptionFormat = richtext.Normalize(raw)
+}
+
+// ValidateDescriptionFormat checks a requested description rendering; empty
+// means HTML.
+func ValidateDescriptionFormat(format string) error {
+	switch format {
+	case "", DescriptionHTML, DescriptionMarkdown, DescriptionText, DescriptionRaw:
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported description format %q (allowed: html, markdown, text, raw)", appErrors.ErrInvalidInput, format)
+}
+
+// RenderDescription replaces the job's description with the requested
+// rendering. Jobs saved before descriptions were sanitized are sanitized on
+// the fly, so HTML output is always safe.
+func RenderDescription(job *domain.Job, format string) {
+	if job.DescriptionFormat == "" && job.Description != "" {
+		setDescription(job, job.Description)
+	}
+	switch format {
+	case DescriptionMarkdown:
+		job.Description = richtext.ToMarkdown(job.Description)
+	case DescriptionText:
+		job.Description = richtext.ToText(job.Description)
+	case DescriptionRaw:
+		job.Description = job.DescriptionRaw
+	}
+}
+
+// SanitizeDescriptions normalizes the descriptions of jobs saved before they
+// were sanitized, keeping the stored text as their raw original. progress, if
+// non-nil, is called after each job.
+func (s *JobService) SanitizeDescriptions(progress func(done int)) (int, error) {
+	done := 0
+	err := s.repo.Stream(&domain.JobFilter{}, exportBatchSize, func(jobs []domain.Job) error {
+		for i := range jobs {
+			job := &jobs[i]
+			if job.DescriptionFormat != "" || job.Description == "" {
+				continue
+			}
+			setDescription(job, job.Description)
+			if err := s.repo.UpdateDescription(job.ID, job.Description, job.DescriptionRaw, job.DescriptionFormat); err != nil {
+				return fmt.Errorf("job %s: %w", job.ID, err)
+			}
+			done++
+			if progress != nil {
+				progress(done)
+			}
+		}
+		return nil
+	})
+	return done, err
+}
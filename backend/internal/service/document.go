@@ -0,0 +1,343 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+	"job-tracker-backend/internal/scan"
+	"job-tracker-backend/internal/storage"
+	appErrors "job-tracker-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// DocumentService manages the per-user document library. Each upload
+// becomes a numbered version; uploading bytes the user already has returns
+// the existing version instead of storing a second copy, and jobs link to a
+// version rather than holding their own copy of the file.
+type DocumentService struct {
+	docRepo *repository.DocumentRepository
+	jobRepo *repository.JobRepository
+	blobs   *storage.Manager
+	scanner scan.Scanner
+}
+
+func NewDocumentService(docRepo *repository.DocumentRepository, jobRepo *repository.JobRepository, blobs *storage.Manager, scanner scan.Scanner) *DocumentService {
+	return &DocumentService{docRepo: docRepo, jobRepo: jobRepo, blobs: blobs, scanner: scanner}
+}
+
+type DocumentUploadInput struct {
+	UserID     string
+	DocumentID string // empty to start a new document
+	Name       string // name of a new document; defaults to the file name
+	Label      string // e.g. "Backend resume v3"
+	FileType   string // "resume" or "cover_letter"; new documents only
+	FileName   string
+	MIMEType   string
+	Content    io.Reader
+}
+
+type DocumentUploadResult struct {
+	Document *domain.Document        `json:"document"`
+	Version  *domain.DocumentVersion `json:"version"`
+	// Deduplicated is true when the content matched an existing version,
+	// which is returned instead of a new one.
+	Deduplicated bool `json:"deduplicated"`
+}
+
+func (s *DocumentService) ListDocuments(userID string) ([]domain.Document, error) {
+	docs, err := s.docRepo.GetAll(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range docs {
+		if err := s.fillLinkCounts(docs[i].Versions); err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+func (s *DocumentService) GetDocument(userID, id string) (*domain.Document, error) {
+	doc, err := s.docRepo.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillLinkCounts(doc.Versions); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (s *DocumentService) fillLinkCounts(versions []domain.DocumentVersion) error {
+	ids := make([]string, len(versions))
+	for i, v := range versions {
+		ids[i] = v.ID
+	}
+	counts, err := s.docRepo.CountLinks(ids)
+	if err != nil {
+		return err
+	}
+	for i := range versions {
+		versions[i].LinkCount = counts[versions[i].ID]
+	}
+	return nil
+}
+
+// Upload stores a new version, either of an existing document or as the
+// first version of a new one. The content is staged and checked first; if
+// the user already has a version with the same hash, that version is
+// returned and nothing new is stored.
+func (s *DocumentService) Upload(input *DocumentUploadInput) (*DocumentUploadResult, error) {
+	var doc *domain.Document
+	if input.DocumentID != "" {
+		existing, err := s.docRepo.GetByID(input.DocumentID, input.UserID)
+		if err != nil {
+			return nil, err
+		}
+		doc = existing
+		input.FileType = doc.FileType
+	}
+	if input.FileType == "" {
+		input.FileType = AllowedFileTypeResume
+	}
+	if err := validateAttachmentType(input.FileType, input.MIMEType); err != nil {
+		return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidInput, err)
+	}
+
+	upload, err := stageUpload(input.Content, input.MIMEType, s.scanner)
+	if err != nil {
+		return nil, err
+	}
+	defer upload.Close()
+
+	existing, err := s.docRepo.GetVersionByHash(input.UserID, upload.hash)
+	if err == nil {
+		existingDoc, err := s.docRepo.GetByID(existing.DocumentID, input.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return &DocumentUploadResult{Document: existingDoc, Version: existing, Deduplicated: true}, nil
+	}
+	if !errors.Is(err, appErrors.ErrNotFound) {
+		return nil, err
+	}
+
+	store := s.blobs.Primary()
+	version := &domain.DocumentVersion{
+		ID:             uuid.New().String(),
+		UserID:         input.UserID,
+		Label:          strings.TrimSpace(input.Label),
+		FileName:       input.FileName,
+		MIMEType:       upload.mimeType,
+		FileSize:       upload.size,
+		ContentHash:    upload.hash,
+		StorageBackend: store.Name(),
+		ExtractedText:  extractText(upload.mimeType, upload.File, upload.size),
+	}
+	if err := store.Put(version.ID, upload, upload.size, version.MIMEType); err != nil {
+		s.discardBlob(store, version.ID)
+		return nil, fmt.Errorf("failed to store document: %w", err)
+	}
+
+	if doc == nil {
+		name := strings.TrimSpace(input.Name)
+		if name == "" {
+			name = input.FileName
+		}
+		doc = &domain.Document{UserID: input.UserID, Name: name, FileType: input.FileType}
+		if err := s.docRepo.Create(doc); err != nil {
+			s.discardBlob(store, version.ID)
+			return nil, err
+		}
+	}
+
+	version.DocumentID = doc.ID
+	if version.Version, err = s.docRepo.NextVersionNumber(doc.ID); err == nil {
+		err = s.docRepo.CreateVersion(version)
+	}
+	if err != nil {
+		s.discardBlob(store, version.ID)
+		return nil, err
+	}
+	if err := s.docRepo.Touch(doc.ID); err != nil {
+		log.Printf("failed to update document %s: %v", doc.ID, err)
+	}
+	doc.Versions = append([]domain.DocumentVersion{*version}, doc.Versions...)
+	return &DocumentUploadResult{Document: doc, Version: version}, nil
+}
+
+func (s *DocumentService) discardBlob(store storage.BlobStore, key string) {
+	if err := store.Delete(key); err != nil {
+		log.Printf("failed to remove document contents %s: %v", key, err)
+	}
+}
+
+// GetVersion returns a version of one of the user's documents.
+func (s *DocumentService) GetVersion(userID, documentID, versionID string) (*domain.DocumentVersion, error) {
+	version, err := s.docRepo.GetVersion(versionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if version.DocumentID != documentID {
+		return nil, appErrors.ErrNotFound
+	}
+	return version, nil
+}
+
+func (s *DocumentService) OpenVersion(version *domain.DocumentVersion) (io.ReadSeekCloser, error) {
+	store, err := s.blobs.Store(version.StorageBackend)
+	if err != nil {
+		return nil, err
+	}
+	return store.Open(version.ID)
+}
+
+// VersionUsages lists the jobs the version was sent with.
+func (s *DocumentService) VersionUsages(userID, documentID, versionID string) ([]domain.DocumentVersionUsage, error) {
+	if _, err := s.GetVersion(userID, documentID, versionID); err != nil {
+		return nil, err
+	}
+	return s.docRepo.GetUsages(versionID)
+}
+
+// DeleteVersion removes a version that no job links to. Deleting the last
+// version removes the document as well.
+func (s *DocumentService) DeleteVersion(userID, documentID, versionID string) error {
+	version, err := s.GetVersion(userID, documentID, versionID)
+	if err != nil {
+		return err
+	}
+	counts, err := s.docRepo.CountLinks([]string{version.ID})
+	if err != nil {
+		return err
+	}
+	if counts[version.ID] > 0 {
+		return fmt.Errorf("%w: version is attached to %d job(s)", appErrors.ErrInUse, counts[version.ID])
+	}
+	if err := s.deleteVersion(version); err != nil {
+		return err
+	}
+
+	remaining, err := s.docRepo.CountVersions(documentID)
+	if err != nil {
+		return err
+	}
+	if remaining == 0 {
+		return s.docRepo.Delete(documentID)
+	}
+	return nil
+}
+
+// DeleteDocument removes a document and all its versions, provided none of
+// them is attached to a job.
+func (s *DocumentService) DeleteDocument(userID, id string) error {
+	doc, err := s.docRepo.GetByID(id, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.fillLinkCounts(doc.Versions); err != nil {
+		return err
+	}
+	for _, v := range doc.Versions {
+		if v.LinkCount > 0 {
+			return fmt.Errorf("%w: version %d is attached to %d job(s)", appErrors.ErrInUse, v.Version, v.LinkCount)
+		}
+	}
+	for i := range doc.Versions {
+		if err := s.deleteVersion(&doc.Versions[i]); err != nil {
+			return err
+		}
+	}
+	return s.docRepo.Delete(doc.ID)
+}
+
+func (s *DocumentService) deleteVersion(version *domain.DocumentVersion) error {
+	store, err := s.blobs.Store(version.StorageBackend)
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(version.ID); err != nil {
+		return fmt.Errorf("failed to delete document contents: %w", err)
+	}
+	return s.docRepo.DeleteVersion(version.ID)
+}
+
+// LinkToJob attaches a document version to a job without copying it.
+func (s *DocumentService) LinkToJob(userID, jobID, versionID string) (*domain.Attachment, error) {
+	if _, err := s.jobRepo.GetByID(jobID, userID); err != nil {
+		return nil, err
+	}
+	version, err := s.docRepo.GetVersion(versionID, userID)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrNotFound) {
+			return nil, fmt.Errorf("%w: document version not found", appErrors.ErrInvalidInput)
+		}
+		return nil, err
+	}
+	doc, err := s.docRepo.GetByID(version.DocumentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The text is copied so full-text search only has to look at attachments.
+	now := time.Now()
+	attachment := &domain.Attachment{
+		JobID:             jobID,
+		FileName:          version.FileName,
+		FileType:          doc.FileType,
+		MIMEType:          version.MIMEType,
+		FileSize:          version.FileSize,
+		ContentHash:       version.ContentHash,
+		StorageBackend:    version.StorageBackend,
+		DocumentVersionID: &version.ID,
+		ExtractedText:     version.ExtractedText,
+		TextExtractedAt:   &now,
+	}
+	if err := s.jobRepo.CreateAttachment(attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// MigrateVersions is the document counterpart of
+// JobService.MigrateAttachments.
+func (s *DocumentService) MigrateVersions(from, to string, progress func(moved, total int)) (int, error) {
+	src, err := s.blobs.Store(from)
+	if err != nil {
+		return 0, err
+	}
+	dst, err := s.blobs.Store(to)
+	if err != nil {
+		return 0, err
+	}
+	if from == to {
+		return 0, nil
+	}
+
+	versions, err := s.docRepo.GetVersionsByBackend(from)
+	if err != nil {
+		return 0, err
+	}
+	for i, v := range versions {
+		if err := copyBlob(src, dst, v.ID, v.MIMEType); err != nil {
+			return i, fmt.Errorf("document version %s: %w", v.ID, err)
+		}
+		if err := s.docRepo.UpdateVersionBackend(v.ID, to); err != nil {
+			return i, fmt.Errorf("document version %s: %w", v.ID, err)
+		}
+		if err := src.Delete(v.ID); err != nil {
+			log.Printf("document version %s moved but source copy not deleted: %v", v.ID, err)
+		}
+		if progress != nil {
+			progress(i+1, len(versions))
+		}
+	}
+	return len(versions), nil
+}
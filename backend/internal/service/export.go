@@ -0,0 +1,329 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+	appErrors "job-tracker-backend/pkg/errors"
+)
+
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatJSON   = "json"
+	ExportFormatNDJSON = "ndjson"
+
+	exportBatchSize = 200
+)
+
+// exportColumns maps CSV column names to accessors, in default column order.
+var exportColumns = []struct {
+	name  string
+	value func(*domain.ExportJob) string
+}{
+	{"id", func(j *domain.ExportJob) string { return j.ID }},
+	{"job_title", func(j *domain.ExportJob) string { return j.JobTitle }},
+	{"company_name", func(j *domain.ExportJob) string { return j.CompanyName }},
+	{"location", func(j *domain.ExportJob) string { return j.Location }},
+	{"job_url", func(j *domain.ExportJob) string { return j.JobURL }},
+	{"description", func(j *domain.ExportJob) string { return j.Description }},
+	{"salary", func(j *domain.ExportJob) string { return j.Salary }},
+	{"job_type", func(j *domain.ExportJob) string { return j.JobType }},
+	{"is_remote", func(j *domain.ExportJob) string { return strconv.FormatBool(j.IsRemote) }},
+	{"easy_apply", func(j *domain.ExportJob) string { return strconv.FormatBool(j.EasyApply) }},
+	{"via_recruiter", func(j *domain.ExportJob) string { return strconv.FormatBool(j.ViaRecruiter) }},
+	{"source", func(j *domain.ExportJob) string { return j.Source }},
+	{"status", func(j *domain.ExportJob) string { return j.Status }},
+	{"notes", func(j *domain.ExportJob) string { return j.Notes }},
+	{"interview_at", func(j *domain.ExportJob) string { return formatOptionalTime(j.InterviewAt) }},
+	{"follow_up_at", func(j *domain.ExportJob) string { return formatOptionalTime(j.FollowUpAt) }},
+	{"deadline_at", func(j *domain.ExportJob) string { return formatOptionalTime(j.DeadlineAt) }},
+	{"skills", func(j *domain.ExportJob) string { return strings.Join(j.Requirements.Skills, ", ") }},
+	{"min_years_experience", func(j *domain.ExportJob) string { return formatOptionalInt(j.Requirements.MinYearsExperience) }},
+	{"seniority", func(j *domain.ExportJob) string { return j.Requirements.Seniority }},
+	{"visa_sponsorship", func(j *domain.ExportJob) string { return j.Requirements.VisaSponsorship }},
+	{"citizenship_required", func(j *domain.ExportJob) string { return strconv.FormatBool(j.Requirements.CitizenshipRequired) }},
+	{"clearance", func(j *domain.ExportJob) string { return j.Requirements.Clearance }},
+	{"education", func(j *domain.ExportJob) string { return j.Requirements.Education }},
+	{"created_at", func(j *domain.ExportJob) string { return j.CreatedAt.Format(time.RFC3339) }},
+	{"updated_at", func(j *domain.ExportJob) string { return j.UpdatedAt.Format(time.RFC3339) }},
+}
+
+type ExportOptions struct {
+	Format             string
+	Columns            []string // CSV only; empty means all columns
+	IncludeAttachments bool
+	IncludeHistory     bool
+	Filter             domain.JobFilter
+}
+
+type ExportService struct {
+	repo *repository.JobRepository
+}
+
+func NewExportService(repo *repository.JobRepository) *ExportService {
+	return &ExportService{repo: repo}
+}
+
+// Validate checks the options before anything is written, so that handlers
+// can still respond with a proper error status.
+func (s *ExportService) Validate(opts *ExportOptions) error {
+	switch opts.Format {
+	case ExportFormatCSV, ExportFormatJSON, ExportFormatNDJSON:
+	default:
+		return fmt.Errorf("%w: unsupported format %q (allowed: csv, json, ndjson)", appErrors.ErrInvalidInput, opts.Format)
+	}
+	if err := validateJobFilter(&opts.Filter); err != nil {
+		return err
+	}
+	for _, col := range opts.Columns {
+		if columnIndex(col) < 0 {
+			return fmt.Errorf("%w: unknown column %q", appErrors.ErrInvalidInput, col)
+		}
+	}
+	return nil
+}
+
+// ContentType returns the MIME type for the export format.
+func (s *ExportService) ContentType(format string) string {
+	switch format {
+	case ExportFormatCSV:
+		return "text/csv; charset=utf-8"
+	case ExportFormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json"
+	}
+}
+
+// Export streams the user's jobs to w in the requested format. flush, if
+// non-nil, is called after each batch so the client receives data while the
+// export is still running.
+func (s *ExportService) Export(userID string, opts *ExportOptions, w io.Writer, flush func()) error {
+	if err := s.Validate(opts); err != nil {
+		return err
+	}
+	filter := opts.Filter
+	filter.UserID = userID
+
+	var enc exportEncoder
+	switch opts.Format {
+	case ExportFormatCSV:
+		enc = newCSVEncoder(w, opts)
+	case ExportFormatNDJSON:
+		enc = &ndjsonEncoder{enc: json.NewEncoder(w)}
+	default:
+		enc = &jsonArrayEncoder{w: w}
+	}
+
+	if err := enc.begin(); err != nil {
+		return err
+	}
+	err := s.repo.Stream(&filter, exportBatchSize, func(jobs []domain.Job) error {
+		batch, err := s.enrich(jobs, opts)
+		if err != nil {
+			return err
+		}
+		for i := range batch {
+			if err := enc.write(&batch[i]); err != nil {
+				return err
+			}
+		}
+		if err := enc.flush(); err != nil {
+			return err
+		}
+		if flush != nil {
+			flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return enc.end()
+}
+
+func (s *ExportService) enrich(jobs []domain.Job, opts *ExportOptions) ([]domain.ExportJob, error) {
+	ids := make([]string, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.ID
+	}
+
+	var attachments map[string][]domain.Attachment
+	if opts.IncludeAttachments {
+		var err error
+		if attachments, err = s.repo.GetAttachmentMetadataByJobIDs(ids); err != nil {
+			return nil, err
+		}
+	}
+	var history map[string][]domain.StatusChange
+	if opts.IncludeHistory {
+		var err error
+		if history, err = s.repo.GetStatusHistoryByJobIDs(ids); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]domain.ExportJob, len(jobs))
+	for i, job := range jobs {
+		job.Attachments = attachments[job.ID]
+		result[i] = domain.ExportJob{Job: job, StatusHistory: history[job.ID]}
+	}
+	return result, nil
+}
+
+type exportEncoder interface {
+	begin() error
+	write(job *domain.ExportJob) error
+	flush() error
+	end() error
+}
+
+type jsonArrayEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonArrayEncoder) begin() error {
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonArrayEncoder) write(job *domain.ExportJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if e.count > 0 {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.count++
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonArrayEncoder) flush() error { return nil }
+
+func (e *jsonArrayEncoder) end() error {
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) begin() error                      { return nil }
+func (e *ndjsonEncoder) write(job *domain.ExportJob) error { return e.enc.Encode(job) }
+func (e *ndjsonEncoder) flush() error                      { return nil }
+func (e *ndjsonEncoder) end() error                        { return nil }
+
+type csvEncoder struct {
+	w                  *csv.Writer
+	columns            []int
+	includeAttachments bool
+	includeHistory     bool
+}
+
+func newCSVEncoder(w io.Writer, opts *ExportOptions) *csvEncoder {
+	enc := &csvEncoder{
+		w:                  csv.NewWriter(w),
+		includeAttachments: opts.IncludeAttachments,
+		includeHistory:     opts.IncludeHistory,
+	}
+	if len(opts.Columns) == 0 {
+		for i := range exportColumns {
+			enc.columns = append(enc.columns, i)
+		}
+	} else {
+		for _, col := range opts.Columns {
+			enc.columns = append(enc.columns, columnIndex(col))
+		}
+	}
+	return enc
+}
+
+func (e *csvEncoder) begin() error {
+	header := make([]string, 0, len(e.columns)+2)
+	for _, idx := range e.columns {
+		header = append(header, exportColumns[idx].name)
+	}
+	if e.includeAttachments {
+		header = append(header, "attachments")
+	}
+	if e.includeHistory {
+		header = append(header, "status_history")
+	}
+	return e.w.Write(header)
+}
+
+func (e *csvEncoder) write(job *domain.ExportJob) error {
+	record := make([]string, 0, len(e.columns)+2)
+	for _, idx := range e.columns {
+		record = append(record, escapeCSVCell(exportColumns[idx].value(job)))
+	}
+	if e.includeAttachments {
+		parts := make([]string, len(job.Attachments))
+		for i, att := range job.Attachments {
+			parts[i] = fmt.Sprintf("%s (%s, %d bytes)", att.FileName, att.FileType, att.FileSize)
+		}
+		record = append(record, escapeCSVCell(strings.Join(parts, "; ")))
+	}
+	if e.includeHistory {
+		parts := make([]string, len(job.StatusHistory))
+		for i, c := range job.StatusHistory {
+			parts[i] = fmt.Sprintf("%s->%s@%s", c.FromStatus, c.ToStatus, c.ChangedAt.Format(time.RFC3339))
+		}
+		record = append(record, escapeCSVCell(strings.Join(parts, "; ")))
+	}
+	return e.w.Write(record)
+}
+
+// escapeCSVCell prefixes values a spreadsheet would evaluate as a formula
+// with a quote, so scraped job text can't run when the export is opened.
+func escapeCSVCell(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) end() error {
+	return e.flush()
+}
+
+func columnIndex(name string) int {
+	for i, col := range exportColumns {
+		if col.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatOptionalInt(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
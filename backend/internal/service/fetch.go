@@ -0,0 +1,66 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// publicHTTPClient returns a client for fetching URLs users supply, such as
+// job postings. It only connects to public addresses, so a URL can't be used
+// to reach the server's own network; the check runs on the resolved address
+// of every connection, redirects included.
+func publicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("refusing to connect to non-public address %s", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+var (
+	// sharedAddressSpace is carrier-grade NAT space (RFC 6598), which the
+	// net.IP predicates consider global
+	sharedAddressSpace = mustParseCIDR("100.64.0.0/10")
+	// nat64Prefix addresses embed an IPv4 address in their last four bytes,
+	// reached through a translator (RFC 6052)
+	nat64Prefix = mustParseCIDR("64:ff9b::/96")
+	// localNAT64Prefix is for NAT64 within a network (RFC 8215)
+	localNAT64Prefix = mustParseCIDR("64:ff9b:1::/48")
+)
+
+func isPublicIP(ip net.IP) bool {
+	// IPv4-mapped addresses (::ffff:0:0/96) are judged by their IPv4 address
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	} else if nat64Prefix.Contains(ip) {
+		ip = ip[12:16]
+	} else if localNAT64Prefix.Contains(ip) {
+		return false
+	}
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
@@ -0,0 +1,426 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/xlsx"
+)
+
+const (
+	ImportModePreview = "preview"
+	ImportModeCommit  = "commit"
+
+	ImportFormatCSV  = "csv"
+	ImportFormatJSON = "json"
+	ImportFormatXLSX = "xlsx"
+
+	MaxImportFileSize int64 = 10 * 1024 * 1024 // 10MB
+	MaxImportRows           = 5000
+)
+
+// importFieldAliases lists, per target field, the column headers used by
+// common trackers (our own export, Huntr, Teal, LinkedIn "Applied jobs",
+// generic spreadsheets). Headers are matched case-insensitively after
+// stripping spaces, underscores and dashes.
+var importFieldAliases = map[string][]string{
+	"job_title":    {"job_title", "title", "job title", "position", "job position", "role"},
+	"company_name": {"company_name", "company", "company name", "employer", "organization"},
+	"location":     {"location", "job location", "city"},
+	"job_url":      {"job_url", "url", "job url", "job link", "link", "job post url", "posting url"},
+	"description":  {"description", "job description"},
+	"salary":       {"salary", "compensation", "pay", "salary range"},
+	"job_type":     {"job_type", "job type", "employment type"},
+	"is_remote":    {"is_remote", "remote"},
+	"source":       {"source", "board", "job board"},
+	"status":       {"status", "stage", "list", "application status"},
+	"notes":        {"notes", "note", "comments"},
+	"created_at":   {"created_at", "application date", "date applied", "applied on", "date added", "date saved", "created"},
+	"interview_at": {"interview_at", "interview date"},
+	"follow_up_at": {"follow_up_at", "follow up", "follow-up date", "reminder"},
+	"deadline_at":  {"deadline_at", "deadline", "application deadline"},
+}
+
+// importStatusAliases maps external status names to ours. Statuses without a
+// counterpart are imported as "new" and preserved in the job notes.
+var importStatusAliases = map[string]domain.JobStatus{
+	"new":          domain.StatusNew,
+	"wishlist":     domain.StatusNew,
+	"bookmarked":   domain.StatusNew,
+	"saved":        domain.StatusNew,
+	"viewed":       domain.StatusViewed,
+	"applying":     domain.StatusShortlisted,
+	"shortlisted":  domain.StatusShortlisted,
+	"applied":      domain.StatusApplied,
+	"submitted":    domain.StatusApplied,
+	"interviewing": domain.StatusApplied,
+	"interview":    domain.StatusApplied,
+	"rejected":     domain.StatusRejected,
+	"declined":     domain.StatusRejected,
+	"archived":     domain.StatusRejected,
+	"not selected": domain.StatusRejected,
+}
+
+var importDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"01/02/2006 15:04",
+	"01/02/2006",
+	"1/2/06, 3:04 PM",
+	"1/2/06",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"02 Jan 2006",
+}
+
+type ImportOptions struct {
+	Mode     string
+	Format   string // csv, json or xlsx; detected from FileName when empty
+	FileName string
+	Mapping  map[string]string // target field -> source column; merged over detected aliases
+	Source   string            // default source for rows that have none
+}
+
+type ImportService struct {
+	repo *repository.JobRepository
+}
+
+func NewImportService(repo *repository.JobRepository) *ImportService {
+	return &ImportService{repo: repo}
+}
+
+// Import parses data according to opts. In preview mode nothing is written;
+// in commit mode every valid, non-duplicate row is saved as a job.
+func (s *ImportService) Import(userID string, data []byte, opts *ImportOptions) (*domain.ImportResult, error) {
+	if opts.Mode == "" {
+		opts.Mode = ImportModePreview
+	}
+	if opts.Mode != ImportModePreview && opts.Mode != ImportModeCommit {
+		return nil, fmt.Errorf("%w: mode must be 'preview' or 'commit'", appErrors.ErrInvalidInput)
+	}
+	for field := range opts.Mapping {
+		if _, ok := importFieldAliases[field]; !ok {
+			return nil, fmt.Errorf("%w: unknown mapping target %q", appErrors.ErrInvalidInput, field)
+		}
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(opts.FileName)), ".")
+	}
+
+	var records []map[string]string
+	var err error
+	switch format {
+	case ImportFormatCSV:
+		records, err = parseCSVRecords(data)
+	case ImportFormatJSON:
+		records, err = parseJSONRecords(data)
+	case ImportFormatXLSX:
+		records, err = parseXLSXRecords(data)
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q (allowed: csv, json, xlsx)", appErrors.ErrInvalidInput, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidInput, err)
+	}
+	if len(records) > MaxImportRows {
+		return nil, fmt.Errorf("%w: too many rows (max %d)", appErrors.ErrInvalidInput, MaxImportRows)
+	}
+
+	mapping := resolveMapping(records, opts.Mapping)
+	result := &domain.ImportResult{
+		Mode:    opts.Mode,
+		Mapping: mapping,
+		Total:   len(records),
+		Rows:    make([]domain.ImportRow, 0, len(records)),
+	}
+
+	seenURLs := make(map[string]bool)
+	for i, record := range records {
+		row := domain.ImportRow{Row: i + 1}
+		job, err := buildImportedJob(userID, record, mapping, opts.Source)
+		if err != nil {
+			row.Error = err.Error()
+			result.Rows = append(result.Rows, row)
+			continue
+		}
+		row.Job = job
+		result.Valid++
+
+		if job.JobURL != "" {
+			exists, err := s.repo.ExistsByURL(job.JobURL, userID)
+			if err != nil {
+				return nil, err
+			}
+			if exists || seenURLs[job.JobURL] {
+				row.Duplicate = true
+				result.Duplicates++
+			}
+			seenURLs[job.JobURL] = true
+		}
+
+		if opts.Mode == ImportModeCommit && !row.Duplicate {
+			if err := s.repo.Create(job); err != nil {
+				row.Error = err.Error()
+			} else {
+				row.Created = true
+				result.Created++
+				change := &domain.StatusChange{
+					JobID:     job.ID,
+					UserID:    userID,
+					ToStatus:  job.Status,
+					ChangedAt: job.CreatedAt,
+				}
+				if err := s.repo.CreateStatusChange(change); err != nil {
+					row.Error = fmt.Sprintf("job created but status history not recorded: %v", err)
+				}
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, nil
+}
+
+// resolveMapping returns the source column for each target field: explicit
+// entries win, the rest are detected from the headers.
+func resolveMapping(records []map[string]string, explicit map[string]string) map[string]string {
+	headers := make(map[string]string)
+	for _, record := range records {
+		for col := range record {
+			headers[normalizeHeader(col)] = col
+		}
+	}
+
+	mapping := make(map[string]string)
+	for field, aliases := range importFieldAliases {
+		if col, ok := explicit[field]; ok {
+			if col != "" {
+				mapping[field] = col
+			}
+			continue
+		}
+		for _, alias := range aliases {
+			if col, ok := headers[normalizeHeader(alias)]; ok {
+				mapping[field] = col
+				break
+			}
+		}
+	}
+	return mapping
+}
+
+func buildImportedJob(userID string, record, mapping map[string]string, defaultSource string) (*domain.Job, error) {
+	get := func(field string) string {
+		return strings.TrimSpace(record[mapping[field]])
+	}
+
+	job := &domain.Job{
+		UserID:      userID,
+		JobTitle:    get("job_title"),
+		CompanyName: get("company_name"),
+		Location:    get("location"),
+		JobURL:      get("job_url"),
+		Salary:      get("salary"),
+		JobType:     get("job_type"),
+		Source:      get("source"),
+		Notes:       get("notes"),
+	}
+	if job.JobTitle == "" {
+		return nil, fmt.Errorf("missing job title")
+	}
+	if job.Source == "" {
+		job.Source = defaultSource
+	}
+	if job.Source == "" {
+		job.Source = "import"
+	}
+	if v := get("is_remote"); v != "" {
+		job.IsRemote = parseImportBool(v)
+	}
+
+	status, known := mapImportStatus(get("status"))
+	job.Status = string(status)
+	if !known {
+		note := fmt.Sprintf("Imported status: %s", get("status"))
+		if job.Notes != "" {
+			note = job.Notes + "\n" + note
+		}
+		job.Notes = note
+	}
+
+	createdAt, err := parseOptionalImportDate(get("created_at"))
+	if err != nil {
+		return nil, fmt.Errorf("created_at: %v", err)
+	}
+	if createdAt != nil {
+		job.CreatedAt = *createdAt
+	}
+	if job.InterviewAt, err = parseOptionalImportDate(get("interview_at")); err != nil {
+		return nil, fmt.Errorf("interview_at: %v", err)
+	}
+	if job.FollowUpAt, err = parseOptionalImportDate(get("follow_up_at")); err != nil {
+		return nil, fmt.Errorf("follow_up_at: %v", err)
+	}
+	if job.DeadlineAt, err = parseOptionalImportDate(get("deadline_at")); err != nil {
+		return nil, fmt.Errorf("deadline_at: %v", err)
+	}
+	setDescription(job, get("description"))
+	analyzeJob(job)
+	return job, nil
+}
+
+func mapImportStatus(value string) (domain.JobStatus, bool) {
+	if value == "" {
+		return domain.StatusNew, true
+	}
+	if status, ok := importStatusAliases[strings.ToLower(strings.TrimSpace(value))]; ok {
+		return status, true
+	}
+	return domain.StatusNew, false
+}
+
+func parseOptionalImportDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	return parseImportDate(value)
+}
+
+func parseImportDate(value string) (*time.Time, error) {
+	for _, layout := range importDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("unrecognized date %q", value)
+}
+
+func parseImportBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "1", "true", "yes", "y", "remote":
+		return true
+	}
+	return false
+}
+
+func normalizeHeader(h string) string {
+	h = strings.ToLower(strings.TrimSpace(h))
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(h)
+}
+
+func parseCSVRecords(data []byte) ([]map[string]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return rowsToRecords(rows), nil
+}
+
+func parseXLSXRecords(data []byte) ([]map[string]string, error) {
+	rows, err := xlsx.ReadRows(data)
+	if err != nil {
+		return nil, err
+	}
+	return rowsToRecords(rows), nil
+}
+
+// rowsToRecords keys each data row by the header row, skipping blank rows.
+func rowsToRecords(rows [][]string) []map[string]string {
+	if len(rows) == 0 {
+		return nil
+	}
+	header := rows[0]
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	var records []map[string]string
+	for _, row := range rows[1:] {
+		record := make(map[string]string, len(header))
+		empty := true
+		for i, col := range header {
+			if i < len(row) {
+				record[col] = row[i]
+				if strings.TrimSpace(row[i]) != "" {
+					empty = false
+				}
+			}
+		}
+		if !empty {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// parseJSONRecords accepts an array of objects or an object wrapping one in
+// "jobs" or "data" (the shape of our own API and export output).
+func parseJSONRecords(data []byte) ([]map[string]string, error) {
+	var raw []map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		var wrapped struct {
+			Jobs []map[string]interface{} `json:"jobs"`
+			Data []map[string]interface{} `json:"data"`
+		}
+		if err2 := json.Unmarshal(data, &wrapped); err2 != nil {
+			return nil, err
+		}
+		raw = wrapped.Jobs
+		if raw == nil {
+			raw = wrapped.Data
+		}
+	}
+
+	records := make([]map[string]string, 0, len(raw))
+	for _, obj := range raw {
+		record := make(map[string]string, len(obj))
+		for k, v := range obj {
+			record[k] = stringifyJSONValue(v)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func stringifyJSONValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
+
+// ReadImportFile reads an uploaded import file, enforcing MaxImportFileSize.
+func ReadImportFile(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxImportFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > MaxImportFileSize {
+		return nil, fmt.Errorf("%w: file too large: max size is 10MB", appErrors.ErrInvalidInput)
+	}
+	return data, nil
+}
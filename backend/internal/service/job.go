@@ -1,28 +1,31 @@
 package service
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"log"
+	"time"
 
 	"job-tracker-backend/internal/domain"
 	"job-tracker-backend/internal/repository"
+	"job-tracker-backend/internal/scan"
+	"job-tracker-backend/internal/storage"
 	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/prefmodel"
 )
 
 type JobService struct {
-	repo         *repository.JobRepository
-	mcpServerURL string
-	httpClient   *http.Client
+	repo      *repository.JobRepository
+	blobs     *storage.Manager
+	scanner   scan.Scanner
+	providers []SearchProvider
 }
 
-func NewJobService(repo *repository.JobRepository, mcpServerURL string) *JobService {
+func NewJobService(repo *repository.JobRepository, blobs *storage.Manager, scanner scan.Scanner, providers ...SearchProvider) *JobService {
 	return &JobService{
-		repo:         repo,
-		mcpServerURL: mcpServerURL,
-		httpClient:   &http.Client{},
+		repo:      repo,
+		blobs:     blobs,
+		scanner:   scanner,
+		providers: providers,
 	}
 }
 
@@ -37,45 +40,9 @@ type MCPSearchParams struct {
 	HoursOld      int    `json:"hours_old"`
 	IsRemote      bool   `json:"is_remote"`
 	Format        string `json:"format"`
-}
-
-type MCPSearchRequest struct {
-	Method string          `json:"method"`
-	Params MCPSearchParams `json:"params"`
-}
 
-type MCPSearchResponse struct {
-	Count   int      `json:"count"`
-	Message string   `json:"message"`
-	Jobs    []MCPJob `json:"jobs"`
-}
-
-type MCPJob struct {
-	JobTitle        string  `json:"jobTitle"`
-	JobSummary      string  `json:"jobSummary"`
-	Description     string  `json:"description"`
-	JobURL          string  `json:"jobUrl"`
-	JobURLDirect    string  `json:"jobUrlDirect"`
-	Location        string  `json:"location"`
-	Country         string  `json:"country"`
-	State           string  `json:"state"`
-	City            string  `json:"city"`
-	DatePosted      string  `json:"datePosted"`
-	JobType         string  `json:"jobType"`
-	Salary          string  `json:"salary"`
-	SalaryPeriod    string  `json:"salaryPeriod"`
-	MinAmount       float64 `json:"minAmount"`
-	MaxAmount       float64 `json:"maxAmount"`
-	IsRemote        bool    `json:"isRemote"`
-	CompanyName     string  `json:"companyName"`
-	CompanyIndustry string  `json:"companyIndustry"`
-	CompanyURL      string  `json:"companyUrl"`
-	CompanyLogo     string  `json:"companyLogo"`
-	Title           string  `json:"title"`
-	Summary         string  `json:"summary"`
-	URL             string  `json:"url"`
-	Company         string  `json:"company"`
-	Source          string  `json:"source"`
+	// Providers selects the search providers to query, all when empty
+	Providers []string `json:"providers,omitempty"`
 }
 
 func (s *JobService) CreateJob(userID string, input *domain.JobCreateInput) (*domain.Job, error) {
@@ -93,7 +60,6 @@ func (s *JobService) CreateJob(userID string, input *domain.JobCreateInput) (*do
 		CompanyName: input.CompanyName,
 		Location:    input.Location,
 		JobURL:      input.JobURL,
-		Description: input.Description,
 		Salary:      input.Salary,
 		JobType:     input.JobType,
 		IsRemote:    input.IsRemote,
@@ -107,15 +73,21 @@ func (s *JobService) CreateJob(userID string, input *domain.JobCreateInput) (*do
 		}(),
 		Status:      string(domain.StatusNew),
 		Notes:       input.Notes,
+		InterviewAt: input.InterviewAt,
+		FollowUpAt:  input.FollowUpAt,
+		DeadlineAt:  input.DeadlineAt,
 	}
 
 	if job.Source == "" {
 		job.Source = "manual"
 	}
+	setDescription(job, input.Description)
+	analyzeJob(job)
 
 	if err := s.repo.Create(job); err != nil {
 		return nil, err
 	}
+	s.recordStatusChange(job, "")
 	return job, nil
 }
 
@@ -127,11 +99,29 @@ func (s *JobService) GetAllJobs(userID string, filter *domain.JobFilter) ([]doma
 	if filter == nil {
 		filter = &domain.JobFilter{}
 	}
+	if err := validateJobFilter(filter); err != nil {
+		return nil, err
+	}
 	filter.UserID = userID
 	return s.repo.GetAll(filter)
 }
 
+// validateJobStatus rejects statuses the app doesn't track, which no view
+// would show the job under.
+func validateJobStatus(status string) error {
+	switch domain.JobStatus(status) {
+	case domain.StatusNew, domain.StatusViewed, domain.StatusApplied, domain.StatusRejected, domain.StatusShortlisted:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown status %q (allowed: new, viewed, applied, rejected, shortlisted)", appErrors.ErrInvalidInput, status)
+}
+
 func (s *JobService) UpdateJob(userID, id string, input *domain.JobUpdateInput) (*domain.Job, error) {
+	if input.Status != "" {
+		if err := validateJobStatus(input.Status); err != nil {
+			return nil, err
+		}
+	}
 	job, err := s.repo.GetByID(id, userID)
 	if err != nil {
 		return nil, err
@@ -146,11 +136,15 @@ func (s *JobService) UpdateJob(userID, id string, input *domain.JobUpdateInput)
 	if input.Location != "" {
 		job.Location = input.Location
 	}
-	if input.JobURL != "" {
+	if input.JobURL != "" && input.JobURL != job.JobURL {
 		job.JobURL = input.JobURL
+		// The liveness of the old posting says nothing about the new one.
+		job.LastCheckedAt, job.ClosedAt, job.LastCheckResult = nil, nil, ""
 	}
-	if input.Description != "" {
-		job.Description = input.Description
+	// Clients that send back the description they were served mustn't
+	// replace the raw original with its sanitized form.
+	if input.Description != "" && input.Description != job.Description {
+		setDescription(job, input.Description)
 	}
 	if input.Salary != "" {
 		job.Salary = input.Salary
@@ -161,209 +155,115 @@ func (s *JobService) UpdateJob(userID, id string, input *domain.JobUpdateInput)
 	if input.Source != "" {
 		job.Source = input.Source
 	}
+	previousStatus := job.Status
 	if input.Status != "" {
 		job.Status = input.Status
 	}
 	if input.Notes != "" {
 		job.Notes = input.Notes
 	}
+	if input.InterviewAt.Set {
+		job.InterviewAt = input.InterviewAt.Time
+	}
+	if input.FollowUpAt.Set {
+		job.FollowUpAt = input.FollowUpAt.Time
+	}
+	if input.DeadlineAt.Set {
+		job.DeadlineAt = input.DeadlineAt.Time
+	}
 	job.IsRemote = input.IsRemote
 	job.EasyApply = input.EasyApply
 	if input.ViaRecruiter != nil {
 		job.ViaRecruiter = *input.ViaRecruiter
 	}
+	if input.JobTitle != "" || input.Description != "" {
+		analyzeJob(job)
+	}
 
 	if err := s.repo.Update(job); err != nil {
 		return nil, err
 	}
+	s.recordStatusChange(job, previousStatus)
 	return job, nil
 }
 
 func (s *JobService) UpdateJobStatus(userID, id string, status string) (*domain.Job, error) {
+	if err := validateJobStatus(status); err != nil {
+		return nil, err
+	}
 	job, err := s.repo.GetByID(id, userID)
 	if err != nil {
 		return nil, err
 	}
+	previousStatus := job.Status
 	job.Status = status
 
 	if err := s.repo.Update(job); err != nil {
 		return nil, err
 	}
+	s.recordStatusChange(job, previousStatus)
 	return job, nil
 }
 
-func (s *JobService) DeleteJob(userID, id string) error {
-	return s.repo.Delete(id, userID)
-}
-
-type SearchResult struct {
-	domain.Job
-	IsSaved bool `json:"is_saved"`
-}
-
-func (s *JobService) SearchJobs(userID string, params MCPSearchParams) ([]SearchResult, error) {
-	reqBody := MCPSearchRequest{
-		Method: "search_jobs",
-		Params: params,
+// recordStatusChange appends to the job's status history when its status
+// differs from previousStatus. History is best-effort and never fails the
+// update that triggered it.
+func (s *JobService) recordStatusChange(job *domain.Job, previousStatus string) {
+	if job.Status == previousStatus {
+		return
 	}
-
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, err
+	change := &domain.StatusChange{
+		JobID:      job.ID,
+		UserID:     job.UserID,
+		FromStatus: previousStatus,
+		ToStatus:   job.Status,
 	}
-
-	mcpURL := fmt.Sprintf("%s/api", s.mcpServerURL)
-	resp, err := s.httpClient.Post(mcpURL, "application/json", bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to call MCP server: %w", err)
+	if err := s.repo.CreateStatusChange(change); err != nil {
+		log.Printf("failed to record status change for job %s: %v", job.ID, err)
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("MCP server returned status %d: %s", resp.StatusCode, string(body))
+// DeleteJob deletes one of the user's jobs with its attachments. The
+// contents of attachments holding their own files are removed from storage
+// first; those linking to a document version stay with the document.
+func (s *JobService) DeleteJob(userID, id string) error {
+	if _, err := s.repo.GetByID(id, userID); err != nil {
+		return err
 	}
-
-	bodyBytes, err := io.ReadAll(resp.Body)
+	attachments, err := s.repo.GetAttachmentsByJobID(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var mcpResp MCPSearchResponse
-	if err := json.Unmarshal(bodyBytes, &mcpResp); err != nil {
-		return nil, fmt.Errorf("failed to decode MCP response: %w", err)
+		return err
 	}
-
-	var results []SearchResult
-	seenURLs := make(map[string]bool)
-	for _, mcpJob := range mcpResp.Jobs {
-		jobURL := mcpJob.JobURL
-		if jobURL == "" {
-			jobURL = mcpJob.JobURLDirect
-		}
-		if jobURL == "" {
-			jobURL = mcpJob.URL
-		}
-
-		if jobURL != "" {
-			if seenURLs[jobURL] {
-				continue
-			}
-			seenURLs[jobURL] = true
-		}
-
-		jobTitle := mcpJob.JobTitle
-		if jobTitle == "" {
-			jobTitle = mcpJob.Title
-		}
-		if jobTitle == "" {
-			jobTitle = mcpJob.Summary
-		}
-
-		if jobTitle == "" {
+	for _, att := range attachments {
+		if att.DocumentVersionID != nil {
 			continue
 		}
-
-		companyName := mcpJob.CompanyName
-		if companyName == "" {
-			companyName = mcpJob.Company
+		store, err := s.blobs.Store(att.StorageBackend)
+		if err != nil {
+			return err
 		}
-
-		salary := mcpJob.Salary
-		if salary == "" && (mcpJob.MinAmount > 0 || mcpJob.MaxAmount > 0) {
-			salary = fmt.Sprintf("%.0f-%.0f", mcpJob.MinAmount, mcpJob.MaxAmount)
+		if err := store.Delete(att.ID); err != nil {
+			return fmt.Errorf("failed to delete attachment contents: %w", err)
 		}
-
-		isSaved := false
-		if jobURL != "" {
-			exists, err := s.repo.ExistsByURL(jobURL, userID)
-			if err == nil && exists {
-				isSaved = true
-			}
-		}
-
-		job := domain.Job{
-			JobTitle:    jobTitle,
-			CompanyName: companyName,
-			Location:    mcpJob.Location,
-			JobURL:      jobURL,
-			Description: mcpJob.Description,
-			Salary:      salary,
-			JobType:     mcpJob.JobType,
-			IsRemote:    mcpJob.IsRemote,
-			Source:      mcpJob.Source,
-			Status:      string(domain.StatusNew),
-		}
-		results = append(results, SearchResult{Job: job, IsSaved: isSaved})
-	}
-
-	return results, nil
-}
-
-// Attachment constants
-const (
-	MaxFileSize                int64 = 10 * 1024 * 1024 // 10MB
-	AllowedFileTypeResume            = "resume"
-	AllowedFileTypeCoverLetter       = "cover_letter"
-)
-
-var allowedMIMETypes = map[string]bool{
-	"application/pdf":    true,
-	"application/msword": true,
-	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": true,
-}
-
-type AttachmentInput struct {
-	JobID    string
-	UserID   string
-	FileName string
-	FileType string // "resume" or "cover_letter"
-	MIMEType string
-	Data     []byte
-}
-
-func (s *JobService) CreateAttachment(input *AttachmentInput) (*domain.Attachment, error) {
-	if input.FileType != AllowedFileTypeResume && input.FileType != AllowedFileTypeCoverLetter {
-		return nil, fmt.Errorf("invalid file type: %s (must be 'resume' or 'cover_letter')", input.FileType)
-	}
-
-	if !allowedMIMETypes[input.MIMEType] {
-		return nil, fmt.Errorf("invalid MIME type: %s (allowed: application/pdf, application/msword, application/vnd.openxmlformats-officedocument.wordprocessingml.document)", input.MIMEType)
 	}
-
-	if int64(len(input.Data)) > MaxFileSize {
-		return nil, fmt.Errorf("file too large: max size is 10MB")
-	}
-
-	// Verify job exists and belongs to this user
-	_, err := s.repo.GetByID(input.JobID, input.UserID)
-	if err != nil {
-		return nil, fmt.Errorf("job not found: %w", err)
-	}
-
-	attachment := &domain.Attachment{
-		JobID:    input.JobID,
-		FileName: input.FileName,
-		FileType: input.FileType,
-		MIMEType: input.MIMEType,
-		Data:     input.Data,
-		FileSize: int64(len(input.Data)),
-	}
-
-	if err := s.repo.CreateAttachment(attachment); err != nil {
-		return nil, err
-	}
-	return attachment, nil
-}
-
-func (s *JobService) GetAttachment(id string) (*domain.Attachment, error) {
-	return s.repo.GetAttachmentByID(id)
-}
-
-func (s *JobService) GetAttachmentsByJobID(jobID string) ([]domain.Attachment, error) {
-	return s.repo.GetAttachmentsByJobID(jobID)
+	return s.repo.Delete(id, userID)
 }
 
-func (s *JobService) DeleteAttachment(id string) error {
-	return s.repo.DeleteAttachment(id)
+type SearchResult struct {
+	domain.Job
+	IsSaved bool `json:"is_saved"`
+	// MatchScore is set when results are ranked against a resume
+	MatchScore *int       `json:"match_score,omitempty"`
+	PostedAt   *time.Time `json:"posted_at,omitempty"`
+	// Score and ScoreReasons are set when results are ranked by the user's
+	// ranking profile
+	Score        *int                `json:"score,omitempty"`
+	ScoreReasons []domain.RankReason `json:"score_reasons,omitempty"`
+
+	// PreferenceScore and PreferenceTerms are set once the user has a
+	// trained preference model
+	PreferenceScore *int                     `json:"preference_score,omitempty"`
+	PreferenceTerms []prefmodel.Contribution `json:"preference_terms,omitempty"`
+
+	salary salaryRange
 }
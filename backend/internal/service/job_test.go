@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"job-tracker-backend/internal/repository"
+	"job-tracker-backend/internal/scan"
+	"job-tracker-backend/internal/storage"
+	appErrors "job-tracker-backend/pkg/errors"
+)
+
+func TestDeleteJob(t *testing.T) {
+	db, mock := newMockDB(t)
+	store, err := storage.NewFilesystemStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs, err := storage.NewManager(storage.BackendFilesystem, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"att-1", "att-2", "att-other"} {
+		if err := store.Put(key, strings.NewReader("contents"), 8, "text/plain"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := NewJobService(repository.NewJobRepository(db), blobs, scan.Nop{})
+
+	mock.ExpectQuery(`SELECT \* FROM "jobs" WHERE id = \$1 AND user_id = \$2`).
+		WithArgs("job-1", "user-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "job_title"}).AddRow("job-1", "user-1", "Go Developer"))
+	mock.ExpectQuery(`SELECT .* FROM "attachments" WHERE job_id = \$1`).
+		WithArgs("job-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "job_id", "storage_backend", "document_version_id"}).
+			AddRow("att-1", "job-1", storage.BackendFilesystem, nil).
+			AddRow("att-2", "job-1", storage.BackendFilesystem, nil).
+			AddRow("att-linked", "job-1", storage.BackendDatabase, "version-1"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "attachments" WHERE job_id IN \(SELECT "id" FROM "jobs" WHERE id = \$1 AND user_id = \$2\)`).
+		WithArgs("job-1", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`DELETE FROM "status_changes" WHERE job_id IN \(SELECT "id" FROM "jobs" WHERE id = \$1 AND user_id = \$2\)`).
+		WithArgs("job-1", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`DELETE FROM "jobs" WHERE id = \$1 AND user_id = \$2`).
+		WithArgs("job-1", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := s.DeleteJob("user-1", "job-1"); err != nil {
+		t.Fatalf("DeleteJob() error = %v", err)
+	}
+	for _, key := range []string{"att-1", "att-2"} {
+		if f, err := store.Open(key); err == nil {
+			f.Close()
+			t.Errorf("contents of %s are still stored", key)
+		}
+	}
+	f, err := store.Open("att-other")
+	if err != nil {
+		t.Fatalf("contents of another job's attachment were removed: %v", err)
+	}
+	f.Close()
+}
+
+func TestDeleteJobNotFound(t *testing.T) {
+	db, mock := newMockDB(t)
+	blobs, err := storage.NewManager(storage.BackendDatabase, storage.NewDatabaseStore(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewJobService(repository.NewJobRepository(db), blobs, scan.Nop{})
+
+	// Another user's job reads as missing, and nothing is deleted
+	mock.ExpectQuery(`SELECT \* FROM "jobs" WHERE id = \$1 AND user_id = \$2`).
+		WithArgs("job-1", "user-2", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	if err := s.DeleteJob("user-2", "job-1"); !errors.Is(err, appErrors.ErrNotFound) {
+		t.Fatalf("DeleteJob() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDeleteJobRollsBack(t *testing.T) {
+	db, mock := newMockDB(t)
+	blobs, err := storage.NewManager(storage.BackendDatabase, storage.NewDatabaseStore(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewJobService(repository.NewJobRepository(db), blobs, scan.Nop{})
+
+	mock.ExpectQuery(`SELECT \* FROM "jobs"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow("job-1", "user-1"))
+	mock.ExpectQuery(`SELECT .* FROM "attachments"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "attachments"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM "status_changes"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM "jobs"`).WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	if err := s.DeleteJob("user-1", "job-1"); err == nil {
+		t.Fatal("DeleteJob() succeeded although deleting the job failed")
+	}
+}
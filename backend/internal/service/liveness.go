@@ -0,0 +1,447 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/robots"
+)
+
+const (
+	// livenessBatch is how many postings one run checks at most.
+	livenessBatch = 1000
+	// livenessWorkers is how many hosts are checked at once; the postings
+	// of one host are always checked one after another.
+	livenessWorkers = 8
+	// maxLivenessRedirects bounds the redirects followed from a job URL.
+	maxLivenessRedirects = 5
+	// maxLivenessPageSize bounds how much of a posting page is read.
+	maxLivenessPageSize = 512 << 10
+	// maxRobotsSize is the least RFC 9309 asks crawlers to read.
+	maxRobotsSize = 500 << 10
+	robotsTTL     = 24 * time.Hour
+	// robotsRetryTTL is how long an unreachable robots.txt blocks a host.
+	robotsRetryTTL = time.Hour
+	// maxInteractiveWait bounds how long a check a user asked for waits for
+	// a host's next request slot.
+	maxInteractiveWait = 10 * time.Second
+	// livenessPruneInterval is how often lapsed robots.txt rules and
+	// request slots are forgotten.
+	livenessPruneInterval = 10 * time.Minute
+)
+
+// HostBusyError is returned by CheckJob when the posting's host can't be
+// asked again before Until without breaking its crawl delay.
+type HostBusyError struct {
+	Host  string
+	Until time.Time
+}
+
+func (e *HostBusyError) Error() string {
+	return fmt.Sprintf("%s was checked moments ago; try again after %s", e.Host, e.Until.Format(time.RFC3339))
+}
+
+// closedPhrases are the texts with which job pages commonly say a posting
+// is closed.
+var closedPhrases = []string{
+	"no longer accepting applications",
+	"no longer accepting candidates",
+	"this job has expired",
+	"this job posting has expired",
+	"job is no longer available",
+	"job posting is no longer available",
+	"position is no longer available",
+	"position has been filled",
+	"position has been closed",
+	"this posting has been closed",
+	"this job is closed",
+	"job you are looking for is no longer",
+}
+
+// boardClosedPhrases are further texts of particular job boards, keyed by
+// the host's registered domain.
+var boardClosedPhrases = map[string][]string{
+	"linkedin.com":        {"no longer accepting applications"},
+	"indeed.com":          {"this job has expired on indeed", "job has expired"},
+	"ashbyhq.com":         {"job not found", "this job posting is no longer"},
+	"workable.com":        {"this job is no longer available", "job not found"},
+	"myworkdayjobs.com":   {"the page you are looking for doesn't exist", "job posting is no longer available"},
+	"smartrecruiters.com": {"sorry, this job has expired", "job ad is no longer available"},
+}
+
+var validThroughPattern = regexp.MustCompile(`"validThrough"\s*:\s*"([^"]+)"`)
+
+// LivenessService checks whether the postings saved jobs link to are still
+// open. It fetches pages the way a polite crawler does: it obeys the
+// robots.txt of every host and leaves at least the host delay, or the
+// site's crawl-delay if longer, between two requests to a host.
+type LivenessService struct {
+	jobs          *repository.JobRepository
+	notifications *repository.NotificationRepository
+	httpClient    *http.Client
+	// robotsClient follows redirects, as RFC 9309 asks for robots.txt
+	robotsClient *http.Client
+	userAgent    string
+	hostDelay    time.Duration
+
+	mu       sync.Mutex
+	robots   map[string]robotsEntry
+	nextSlot map[string]time.Time
+	prunedAt time.Time
+}
+
+type robotsEntry struct {
+	rules   *robots.Rules
+	expires time.Time
+}
+
+func NewLivenessService(jobs *repository.JobRepository, notifications *repository.NotificationRepository, userAgent string, hostDelay time.Duration) *LivenessService {
+	httpClient := publicHTTPClient(30 * time.Second)
+	// Redirects are followed by hand, each subject to robots.txt.
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	return &LivenessService{
+		jobs:          jobs,
+		notifications: notifications,
+		httpClient:    httpClient,
+		robotsClient:  publicHTTPClient(30 * time.Second),
+		userAgent:     userAgent,
+		hostDelay:     hostDelay,
+		robots:        make(map[string]robotsEntry),
+		nextSlot:      make(map[string]time.Time),
+	}
+}
+
+type livenessVerdict int
+
+const (
+	postingUnknown livenessVerdict = iota
+	postingOpen
+	postingClosed
+)
+
+// CheckJob checks one of the user's postings now. Unlike background checks
+// it also rechecks closed postings, reopening them if they are back. Rather
+// than wait long for the host's crawl delay it fails with a HostBusyError.
+func (s *LivenessService) CheckJob(userID, id string) (*domain.Job, error) {
+	job, err := s.jobs.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := url.ParseRequestURI(job.JobURL); err != nil || !strings.HasPrefix(job.JobURL, "http") {
+		return nil, fmt.Errorf("%w: job has no posting URL to check", appErrors.ErrInvalidInput)
+	}
+	if err := s.check(job, true); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// CheckDue checks every open posting not checked within maxAge. It returns
+// the number of postings found closed.
+func (s *LivenessService) CheckDue(maxAge time.Duration) (int, error) {
+	jobs, err := s.jobs.GetDueForCheck(time.Now().Add(-maxAge), livenessBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	byHost := make(map[string][]*domain.Job)
+	for i := range jobs {
+		u, err := url.Parse(jobs[i].JobURL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		host := strings.ToLower(u.Host)
+		byHost[host] = append(byHost[host], &jobs[i])
+	}
+
+	hosts := make(chan []*domain.Job)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	closed := 0
+	for range min(livenessWorkers, len(byHost)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range hosts {
+				for _, job := range batch {
+					if err := s.check(job, false); err != nil {
+						log.Printf("failed to record liveness of job %s: %v", job.ID, err)
+						continue
+					}
+					if job.ClosedAt != nil {
+						mu.Lock()
+						closed++
+						mu.Unlock()
+					}
+				}
+			}
+		}()
+	}
+	for _, batch := range byHost {
+		hosts <- batch
+	}
+	close(hosts)
+	wg.Wait()
+	return closed, nil
+}
+
+// CheckEvery runs CheckDue now and then every interval, forever. Run it in
+// its own goroutine.
+func (s *LivenessService) CheckEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// Half the interval, as for company watches, so a posting checked
+		// late in the previous run is due again.
+		if n, err := s.CheckDue(interval / 2); err != nil {
+			log.Printf("failed to check saved postings: %v", err)
+		} else if n > 0 {
+			log.Printf("Found %d saved postings closed", n)
+		}
+		<-ticker.C
+	}
+}
+
+// check fetches a job's posting and records the outcome on the job. An
+// inconclusive check leaves whether the posting is closed as it was. A
+// posting newly found closed notifies the user unless they rejected the
+// job already. An interactive check gives up with a HostBusyError, leaving
+// the job as it was, instead of waiting long for the host.
+func (s *LivenessService) check(job *domain.Job, interactive bool) error {
+	verdict, result, err := s.fetch(job.JobURL, interactive)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	wasClosed := job.ClosedAt != nil
+	switch verdict {
+	case postingOpen:
+		job.ClosedAt = nil
+	case postingClosed:
+		if !wasClosed {
+			job.ClosedAt = &now
+		}
+	}
+	result = truncateRunes(result, 500)
+	job.LastCheckedAt = &now
+	job.LastCheckResult = result
+	if err := s.jobs.UpdateLiveness(job.ID, now, job.ClosedAt, result); err != nil {
+		return err
+	}
+
+	if verdict != postingClosed || wasClosed || job.Status == string(domain.StatusRejected) {
+		return nil
+	}
+	jobID := job.ID
+	return s.notifications.Create(&domain.Notification{
+		UserID:  job.UserID,
+		Kind:    domain.NotificationPostingClosed,
+		Title:   job.JobTitle,
+		Company: job.CompanyName,
+		URL:     job.JobURL,
+		JobID:   &jobID,
+	})
+}
+
+// fetch requests a posting, following redirects, and judges from the
+// response whether it is still open. The result explains the verdict. The
+// only error is a HostBusyError of an interactive fetch.
+func (s *LivenessService) fetch(rawURL string, interactive bool) (livenessVerdict, string, error) {
+	start, err := url.Parse(rawURL)
+	if err != nil {
+		return postingUnknown, "invalid URL", nil
+	}
+	current := start
+	for range maxLivenessRedirects + 1 {
+		if current.Scheme != "http" && current.Scheme != "https" {
+			return postingUnknown, "unsupported URL scheme " + current.Scheme, nil
+		}
+		rules, err := s.robotsFor(current, interactive)
+		if err != nil {
+			return postingUnknown, "", err
+		}
+		if !rules.Allowed(current.RequestURI()) {
+			return postingUnknown, "disallowed by robots.txt of " + current.Host, nil
+		}
+		resp, err := s.get(s.httpClient, current, rules.CrawlDelay(), interactive)
+		var busy *HostBusyError
+		if errors.As(err, &busy) {
+			return postingUnknown, "", err
+		}
+		if err != nil {
+			return postingUnknown, "request failed: " + err.Error(), nil
+		}
+
+		if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+			resp.Body.Close()
+			next, err := current.Parse(resp.Header.Get("Location"))
+			if err != nil || resp.Header.Get("Location") == "" {
+				return postingUnknown, fmt.Sprintf("HTTP %d without a valid redirect", resp.StatusCode), nil
+			}
+			if redirectedAway(start, next) {
+				return postingClosed, "redirected to " + next.String(), nil
+			}
+			current = next
+			continue
+		}
+
+		defer resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+			return postingClosed, fmt.Sprintf("HTTP %d", resp.StatusCode), nil
+		case resp.StatusCode != http.StatusOK:
+			return postingUnknown, fmt.Sprintf("HTTP %d", resp.StatusCode), nil
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxLivenessPageSize))
+		if err != nil {
+			return postingUnknown, "reading page failed: " + err.Error(), nil
+		}
+		if reason := closedPageReason(current.Hostname(), string(body)); reason != "" {
+			return postingClosed, reason, nil
+		}
+		return postingOpen, "open", nil
+	}
+	return postingUnknown, "too many redirects", nil
+}
+
+// redirectedAway reports whether a redirect from a posting's URL to next
+// means the posting is gone: boards send visitors of closed postings to
+// the company's listing, which on Greenhouse carries error=true.
+func redirectedAway(start, next *url.URL) bool {
+	if next.Query().Get("error") == "true" && strings.HasSuffix(next.Hostname(), "greenhouse.io") {
+		return true
+	}
+	if !strings.EqualFold(next.Hostname(), start.Hostname()) {
+		return false
+	}
+	from := strings.TrimRight(start.Path, "/")
+	to := strings.TrimRight(next.Path, "/")
+	return len(to) < len(from) && strings.HasPrefix(from, to+"/")
+}
+
+// closedPageReason returns why a posting page reads as closed, or "" if it
+// doesn't: a schema.org JobPosting whose validThrough date has passed, or
+// one of the closed phrases of the board or of job pages in general.
+func closedPageReason(host, page string) string {
+	if m := validThroughPattern.FindStringSubmatch(page); m != nil {
+		if t := parsePostedDate(m[1]); t != nil && t.Before(time.Now()) {
+			return "posting expired " + t.Format("2006-01-02")
+		}
+	}
+	text := strings.ToLower(strings.Join(strings.Fields(plainDescription(page)), " "))
+	phrases := closedPhrases
+	for board, extra := range boardClosedPhrases {
+		if host == board || strings.HasSuffix(host, "."+board) {
+			phrases = append(extra[:len(extra):len(extra)], phrases...)
+		}
+	}
+	for _, phrase := range phrases {
+		if strings.Contains(text, phrase) {
+			return fmt.Sprintf("page says %q", phrase)
+		}
+	}
+	return ""
+}
+
+// get requests u once the host's delay since the previous request to it
+// has passed.
+func (s *LivenessService) get(client *http.Client, u *url.URL, crawlDelay time.Duration, interactive bool) (*http.Response, error) {
+	if err := s.waitForHost(u.Host, crawlDelay, interactive); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", s.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	return client.Do(req)
+}
+
+// waitForHost reserves the host's next request slot and sleeps until it.
+// An interactive request doesn't reserve a slot further off than
+// maxInteractiveWait; it returns a HostBusyError instead.
+func (s *LivenessService) waitForHost(host string, crawlDelay time.Duration, interactive bool) error {
+	delay := max(s.hostDelay, crawlDelay)
+	s.mu.Lock()
+	now := time.Now()
+	s.pruneLocked(now)
+	slot := s.nextSlot[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	if interactive && slot.Sub(now) > maxInteractiveWait {
+		s.mu.Unlock()
+		return &HostBusyError{Host: host, Until: slot}
+	}
+	s.nextSlot[host] = slot.Add(delay)
+	s.mu.Unlock()
+	time.Sleep(time.Until(slot))
+	return nil
+}
+
+// pruneLocked forgets the request slots that have passed and the robots.txt
+// rules that have expired, every livenessPruneInterval. s.mu must be held.
+func (s *LivenessService) pruneLocked(now time.Time) {
+	if now.Sub(s.prunedAt) < livenessPruneInterval {
+		return
+	}
+	s.prunedAt = now
+	for host, slot := range s.nextSlot {
+		if slot.Before(now) {
+			delete(s.nextSlot, host)
+		}
+	}
+	for key, entry := range s.robots {
+		if !now.Before(entry.expires) {
+			delete(s.robots, key)
+		}
+	}
+}
+
+// robotsFor returns the robots.txt rules of u's host for the checker's user
+// agent, fetching them at most once a day. As RFC 9309 asks, a missing
+// robots.txt allows everything and an unreachable one nothing. The only
+// error is a HostBusyError of an interactive request.
+func (s *LivenessService) robotsFor(u *url.URL, interactive bool) (*robots.Rules, error) {
+	key := u.Scheme + "://" + strings.ToLower(u.Host)
+	s.mu.Lock()
+	entry, ok := s.robots[key]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.rules, nil
+	}
+
+	rules, ttl := robots.DisallowAll, robotsRetryTTL
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	resp, err := s.get(s.robotsClient, robotsURL, 0, interactive)
+	var busy *HostBusyError
+	if errors.As(err, &busy) {
+		return nil, err
+	}
+	if err == nil {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+		resp.Body.Close()
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300 && err == nil:
+			rules, ttl = robots.Parse(body, s.userAgent), robotsTTL
+		case resp.StatusCode >= 400 && resp.StatusCode < 500:
+			rules, ttl = robots.AllowAll, robotsTTL
+		}
+	}
+	s.mu.Lock()
+	s.robots[key] = robotsEntry{rules: rules, expires: time.Now().Add(ttl)}
+	s.mu.Unlock()
+	return rules, nil
+}
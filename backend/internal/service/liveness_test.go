@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"job-tracker-backend/pkg/robots"
+)
+
+func TestWaitForHostInteractive(t *testing.T) {
+	s := NewLivenessService(nil, nil, "JobTrackerBot/1.0", 0)
+
+	// A background request takes the host for the longest crawl delay
+	if err := s.waitForHost("jobs.example", robots.MaxCrawlDelay, false); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	err := s.waitForHost("jobs.example", 0, true)
+	var busy *HostBusyError
+	if !errors.As(err, &busy) {
+		t.Fatalf("waitForHost() error = %v, want a HostBusyError", err)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("waitForHost() slept %v before giving up", waited)
+	}
+	if until := time.Until(busy.Until); until < robots.MaxCrawlDelay-time.Second || until > robots.MaxCrawlDelay {
+		t.Errorf("HostBusyError.Until is %v away, want about %v", until, robots.MaxCrawlDelay)
+	}
+	if slot := s.nextSlot["jobs.example"]; !slot.Equal(busy.Until) {
+		t.Errorf("the refused request moved the host's slot to %v", slot)
+	}
+
+	// Other hosts are free
+	if err := s.waitForHost("careers.example", 0, true); err != nil {
+		t.Errorf("waitForHost() of another host error = %v", err)
+	}
+}
+
+func TestWaitForHostPrunes(t *testing.T) {
+	s := NewLivenessService(nil, nil, "JobTrackerBot/1.0", 0)
+	past := time.Now().Add(-time.Hour)
+	s.nextSlot["old.example"] = past
+	s.nextSlot["busy.example"] = time.Now().Add(time.Minute)
+	s.robots["https://old.example"] = robotsEntry{rules: robots.AllowAll, expires: past}
+	s.robots["https://busy.example"] = robotsEntry{rules: robots.AllowAll, expires: time.Now().Add(time.Hour)}
+
+	if err := s.waitForHost("new.example", 0, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.nextSlot["old.example"]; ok {
+		t.Error("a passed request slot was kept")
+	}
+	if _, ok := s.robots["https://old.example"]; ok {
+		t.Error("expired robots.txt rules were kept")
+	}
+	if _, ok := s.nextSlot["busy.example"]; !ok {
+		t.Error("an upcoming request slot was dropped")
+	}
+	if _, ok := s.robots["https://busy.example"]; !ok {
+		t.Error("current robots.txt rules were dropped")
+	}
+}
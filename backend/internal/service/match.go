@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"job-tracker-backend/internal/domain"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/richtext"
+	"job-tracker-backend/pkg/textmatch"
+)
+
+// matchCorpusSize is how many of the user's recent job descriptions are used
+// to weight terms, so words common to all of their postings count for less.
+const matchCorpusSize = 500
+
+// MatchReport is the result of comparing a resume attachment with a job.
+type MatchReport struct {
+	JobID        string `json:"job_id"`
+	AttachmentID string `json:"attachment_id"`
+	FileName     string `json:"file_name"`
+	textmatch.Report
+}
+
+// MatchResume scores how well a resume fits a job. The resume may be
+// attached to any of the user's jobs; without an attachment ID the job's
+// most recent resume is used.
+func (s *JobService) MatchResume(userID, jobID, attachmentID string) (*MatchReport, error) {
+	job, err := s.repo.GetByID(jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Description == "" {
+		return nil, fmt.Errorf("%w: job has no description to match against", appErrors.ErrInvalidInput)
+	}
+
+	if attachmentID == "" {
+		attachmentID, err = s.latestResumeID(jobID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	attachment, text, err := s.resumeText(userID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	corpus, err := s.matchCorpus(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MatchReport{
+		JobID:        job.ID,
+		AttachmentID: attachment.ID,
+		FileName:     attachment.FileName,
+		Report:       *textmatch.Match(text, richtext.ToText(job.Description), corpus),
+	}, nil
+}
+
+// RankSearchResults scores each result's description against a resume and
+// orders the results best match first.
+func (s *JobService) RankSearchResults(userID, attachmentID string, results []SearchResult) error {
+	_, text, err := s.resumeText(userID, attachmentID)
+	if err != nil {
+		return err
+	}
+	corpus, err := s.matchCorpus(userID)
+	if err != nil {
+		return err
+	}
+	for i := range results {
+		score := textmatch.Match(text, richtext.ToText(results[i].Description), corpus).Score
+		results[i].MatchScore = &score
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return *results[i].MatchScore > *results[j].MatchScore
+	})
+	return nil
+}
+
+func (s *JobService) latestResumeID(jobID string) (string, error) {
+	attachments, err := s.repo.GetAttachmentsByJobID(jobID)
+	if err != nil {
+		return "", err
+	}
+	for _, att := range attachments {
+		if att.FileType == AllowedFileTypeResume {
+			return att.ID, nil
+		}
+	}
+	return "", fmt.Errorf("%w: job has no resume attached; pass attachment_id", appErrors.ErrInvalidInput)
+}
+
+// resumeText loads one of the user's attachments and its extracted text.
+func (s *JobService) resumeText(userID, attachmentID string) (*domain.Attachment, string, error) {
+	attachment, err := s.repo.GetAttachmentByID(attachmentID)
+	if err != nil {
+		if errors.Is(err, appErrors.ErrNotFound) {
+			return nil, "", fmt.Errorf("%w: attachment not found", appErrors.ErrInvalidInput)
+		}
+		return nil, "", err
+	}
+	if _, err := s.repo.GetByID(attachment.JobID, userID); err != nil {
+		return nil, "", fmt.Errorf("%w: attachment not found", appErrors.ErrInvalidInput)
+	}
+	text, err := s.GetAttachmentText(attachment)
+	if err != nil {
+		return nil, "", err
+	}
+	if text.Text == "" {
+		return nil, "", fmt.Errorf("%w: no text could be extracted from %s", appErrors.ErrInvalidInput, attachment.FileName)
+	}
+	return attachment, text.Text, nil
+}
+
+func (s *JobService) matchCorpus(userID string) (*textmatch.Corpus, error) {
+	descriptions, err := s.repo.GetDescriptions(userID, matchCorpusSize)
+	if err != nil {
+		return nil, err
+	}
+	corpus := textmatch.NewCorpus(nil)
+	for _, d := range descriptions {
+		corpus.Add(richtext.ToText(d))
+	}
+	return corpus, nil
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+)
+
+// notificationLimit is how many notifications are listed at most.
+const notificationLimit = 200
+
+type NotificationService struct {
+	repo *repository.NotificationRepository
+}
+
+func NewNotificationService(repo *repository.NotificationRepository) *NotificationService {
+	return &NotificationService{repo: repo}
+}
+
+// NotificationList is a page of notifications with the user's unread count.
+type NotificationList struct {
+	Notifications []domain.Notification `json:"notifications"`
+	Unread        int64                 `json:"unread"`
+}
+
+func (s *NotificationService) List(userID string, unreadOnly bool) (*NotificationList, error) {
+	notifications, err := s.repo.GetAll(userID, unreadOnly, notificationLimit)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return nil, err
+	}
+	if notifications == nil {
+		notifications = []domain.Notification{}
+	}
+	return &NotificationList{Notifications: notifications, Unread: unread}, nil
+}
+
+func (s *NotificationService) MarkRead(userID, id string) error {
+	return s.repo.MarkRead(id, userID, time.Now())
+}
+
+func (s *NotificationService) MarkAllRead(userID string) error {
+	return s.repo.MarkAllRead(userID, time.Now())
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/prefmodel"
+	"job-tracker-backend/pkg/richtext"
+)
+
+// Statuses that record a decision about a job: shortlisting or applying
+// means the user liked it, rejecting that they didn't.
+var (
+	likedStatuses    = []string{string(domain.StatusShortlisted), string(domain.StatusApplied)}
+	dislikedStatuses = []string{string(domain.StatusRejected)}
+)
+
+// Number of terms explaining a search result's preference score and
+// summarizing a model.
+const (
+	preferenceResultTerms  = 5
+	preferenceSummaryTerms = 15
+)
+
+type PreferenceService struct {
+	jobs  *repository.JobRepository
+	users *repository.UserRepository
+}
+
+func NewPreferenceService(jobs *repository.JobRepository, users *repository.UserRepository) *PreferenceService {
+	return &PreferenceService{jobs: jobs, users: users}
+}
+
+// PreferenceModelSummary describes a user's preference model and the terms
+// it weighs most.
+type PreferenceModelSummary struct {
+	domain.PreferenceModel
+	// Trained is false until the user has decided on MinExamples liked and
+	// disliked jobs
+	Trained       bool                     `json:"trained"`
+	MinExamples   int                      `json:"min_examples"`
+	LikedTerms    []prefmodel.Contribution `json:"liked_terms"`
+	DislikedTerms []prefmodel.Contribution `json:"disliked_terms"`
+}
+
+// GetModel returns the user's preference model, training it first if it
+// never has been.
+func (s *PreferenceService) GetModel(userID string) (*PreferenceModelSummary, error) {
+	record, err := s.users.GetPreferenceModel(userID)
+	if errors.Is(err, appErrors.ErrNotFound) {
+		return s.Train(userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return summarizePreferenceModel(record)
+}
+
+// Train retrains the user's preference model from their current decisions.
+// With too few of them the model is saved untrained, so it isn't retried
+// until the user's jobs change.
+func (s *PreferenceService) Train(userID string) (*PreferenceModelSummary, error) {
+	trainedAt := time.Now()
+	jobs, err := s.jobs.GetByStatuses(userID, append(append([]string{}, likedStatuses...), dislikedStatuses...))
+	if err != nil {
+		return nil, err
+	}
+
+	record := &domain.PreferenceModel{UserID: userID, TrainedAt: trainedAt}
+	examples := make([]prefmodel.Example, 0, len(jobs))
+	for _, job := range jobs {
+		liked := job.Status != string(domain.StatusRejected)
+		if liked {
+			record.Liked++
+		} else {
+			record.Disliked++
+		}
+		examples = append(examples, prefmodel.Example{
+			Document: preferenceDocument(job.JobTitle, job.CompanyName, job.Description),
+			Liked:    liked,
+		})
+	}
+
+	model, err := prefmodel.Train(examples)
+	switch {
+	case errors.Is(err, prefmodel.ErrNotEnoughData):
+	case err != nil:
+		return nil, err
+	default:
+		b, err := json.Marshal(model)
+		if err != nil {
+			return nil, err
+		}
+		record.Model = string(b)
+	}
+
+	if err := s.users.SavePreferenceModel(record); err != nil {
+		return nil, err
+	}
+	return summarizePreferenceModel(record)
+}
+
+func summarizePreferenceModel(record *domain.PreferenceModel) (*PreferenceModelSummary, error) {
+	summary := &PreferenceModelSummary{
+		PreferenceModel: *record,
+		MinExamples:     prefmodel.MinExamples,
+		LikedTerms:      []prefmodel.Contribution{},
+		DislikedTerms:   []prefmodel.Contribution{},
+	}
+	model, err := decodePreferenceModel(record)
+	if err != nil || model == nil {
+		return summary, err
+	}
+	summary.Trained = true
+	summary.LikedTerms, summary.DislikedTerms = model.TopTerms(preferenceSummaryTerms)
+	if summary.LikedTerms == nil {
+		summary.LikedTerms = []prefmodel.Contribution{}
+	}
+	if summary.DislikedTerms == nil {
+		summary.DislikedTerms = []prefmodel.Contribution{}
+	}
+	return summary, nil
+}
+
+// decodePreferenceModel returns the stored model, or nil if it is untrained.
+func decodePreferenceModel(record *domain.PreferenceModel) (*prefmodel.Model, error) {
+	if record.Model == "" {
+		return nil, nil
+	}
+	var model prefmodel.Model
+	if err := json.Unmarshal([]byte(record.Model), &model); err != nil {
+		return nil, fmt.Errorf("decode preference model for user %s: %w", record.UserID, err)
+	}
+	return &model, nil
+}
+
+// preferenceDocument is what the model sees of a job. Companies are compared
+// loosely, the same way as for preferred companies.
+func preferenceDocument(title, company, description string) prefmodel.Document {
+	return prefmodel.Document{
+		Title:       title,
+		Company:     normalizeCompany(company),
+		Description: richtext.ToText(description),
+	}
+}
+
+// Score sets the preference score of each result, the 0-100 likelihood that
+// the user will like it, with the terms that weighed most. Results are left
+// unscored until the user has a trained model.
+func (s *PreferenceService) Score(userID string, results []SearchResult) error {
+	record, err := s.users.GetPreferenceModel(userID)
+	if errors.Is(err, appErrors.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	model, err := decodePreferenceModel(record)
+	if err != nil || model == nil {
+		return err
+	}
+
+	for i := range results {
+		r := &results[i]
+		prediction := model.Predict(preferenceDocument(r.JobTitle, r.CompanyName, r.Description), preferenceResultTerms)
+		score := int(math.Round(prediction.Probability * 100))
+		r.PreferenceScore = &score
+		r.PreferenceTerms = prediction.Terms
+	}
+	return nil
+}
+
+// RetrainChanged retrains the models of users whose jobs changed since their
+// model was last trained and returns how many were retrained. A failure for
+// one user is logged and doesn't stop the others.
+func (s *PreferenceService) RetrainChanged() (int, error) {
+	userIDs, err := s.jobs.GetUsersChangedSinceTraining()
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	for _, userID := range userIDs {
+		if _, err := s.Train(userID); err != nil {
+			log.Printf("failed to train preference model for user %s: %v", userID, err)
+			continue
+		}
+		done++
+	}
+	return done, nil
+}
+
+// RetrainEvery runs RetrainChanged now and then every interval, forever. Run
+// it in its own goroutine.
+func (s *PreferenceService) RetrainEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.RetrainChanged(); err != nil {
+			log.Printf("failed to retrain preference models: %v", err)
+		} else if n > 0 {
+			log.Printf("Retrained %d preference models", n)
+		}
+		<-ticker.C
+	}
+}
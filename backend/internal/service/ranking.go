@@ -0,0 +1,361 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/richtext"
+)
+
+// Points awarded by the ranking profile. Keyword points come from the
+// keyword's own weight.
+const (
+	defaultKeywordWeight  = 10
+	maxKeywordWeight      = 100
+	excludedKeywordPoints = -100
+	preferredCompanyBonus = 25
+	salaryMeetsFloorBonus = 15
+	salaryReachesFloor    = 5
+	salaryBelowFloorMalus = -40
+	remoteBonus           = 15
+	notRemoteMalus        = -100
+	tooOldMalus           = -30
+	// matchScoreDivisor turns a 0-100 resume match into 0-20 points.
+	matchScoreDivisor = 5
+	// preferencePoints spreads a 0-100 learned preference over -20 to +20.
+	preferencePoints = 40
+)
+
+type RankingService struct {
+	users *repository.UserRepository
+}
+
+func NewRankingService(users *repository.UserRepository) *RankingService {
+	return &RankingService{users: users}
+}
+
+// GetProfile returns the user's ranking profile, or an empty one if they
+// haven't saved any.
+func (s *RankingService) GetProfile(userID string) (*domain.RankingProfile, error) {
+	profile, err := s.users.GetRankingProfile(userID)
+	if errors.Is(err, appErrors.ErrNotFound) {
+		return &domain.RankingProfile{UserID: userID, Keywords: domain.KeywordWeights{}, ExcludedKeywords: domain.StringList{}, PreferredCompanies: domain.StringList{}}, nil
+	}
+	return profile, err
+}
+
+func (s *RankingService) UpdateProfile(userID string, input *domain.RankingProfileInput) (*domain.RankingProfile, error) {
+	profile := &domain.RankingProfile{
+		UserID:             userID,
+		Keywords:           domain.KeywordWeights{},
+		ExcludedKeywords:   cleanTerms(input.ExcludedKeywords),
+		PreferredCompanies: cleanTerms(input.PreferredCompanies),
+		MinSalary:          input.MinSalary,
+		RemotePreference:   input.RemotePreference,
+		MaxAgeDays:         input.MaxAgeDays,
+		MinScore:           input.MinScore,
+	}
+	seen := make(map[string]bool)
+	for _, k := range input.Keywords {
+		term := strings.TrimSpace(k.Term)
+		if term == "" || seen[strings.ToLower(term)] {
+			continue
+		}
+		seen[strings.ToLower(term)] = true
+		if k.Weight == 0 {
+			k.Weight = defaultKeywordWeight
+		}
+		if k.Weight < -maxKeywordWeight || k.Weight > maxKeywordWeight {
+			return nil, fmt.Errorf("%w: keyword %q weight must be between -%d and %d", appErrors.ErrInvalidInput, term, maxKeywordWeight, maxKeywordWeight)
+		}
+		profile.Keywords = append(profile.Keywords, domain.KeywordWeight{Term: term, Weight: k.Weight})
+	}
+	switch profile.RemotePreference {
+	case domain.RemoteAny, domain.RemotePreferred, domain.RemoteRequired:
+	default:
+		return nil, fmt.Errorf("%w: remote_preference must be empty, %q or %q", appErrors.ErrInvalidInput, domain.RemotePreferred, domain.RemoteRequired)
+	}
+	if profile.MinSalary < 0 || profile.MaxAgeDays < 0 {
+		return nil, fmt.Errorf("%w: min_salary and max_age_days must not be negative", appErrors.ErrInvalidInput)
+	}
+
+	if err := s.users.SaveRankingProfile(profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func cleanTerms(terms []string) domain.StringList {
+	cleaned := domain.StringList{}
+	seen := make(map[string]bool)
+	for _, t := range terms {
+		t = strings.TrimSpace(t)
+		if t != "" && !seen[strings.ToLower(t)] {
+			seen[strings.ToLower(t)] = true
+			cleaned = append(cleaned, t)
+		}
+	}
+	return cleaned
+}
+
+// Rank scores results against the user's ranking profile, sorts them best
+// first and drops those scoring below minScore, which defaults to the
+// profile's. Without a saved profile the results are returned unchanged.
+func (s *RankingService) Rank(userID string, results []SearchResult, minScore *int) ([]SearchResult, error) {
+	profile, err := s.users.GetRankingProfile(userID)
+	if errors.Is(err, appErrors.ErrNotFound) {
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if minScore == nil {
+		minScore = profile.MinScore
+	}
+
+	scorer := newResultScorer(profile, time.Now())
+	for i := range results {
+		score, reasons := scorer.score(&results[i])
+		results[i].Score = &score
+		results[i].ScoreReasons = reasons
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return *results[i].Score > *results[j].Score
+	})
+	if minScore != nil {
+		kept := results[:0]
+		for _, r := range results {
+			if *r.Score >= *minScore {
+				kept = append(kept, r)
+			}
+		}
+		results = kept
+	}
+	return results, nil
+}
+
+type weightedPattern struct {
+	term    string
+	weight  int
+	pattern *regexp.Regexp
+}
+
+type resultScorer struct {
+	profile   *domain.RankingProfile
+	now       time.Time
+	keywords  []weightedPattern
+	excluded  []weightedPattern
+	companies []string
+}
+
+func newResultScorer(profile *domain.RankingProfile, now time.Time) *resultScorer {
+	sc := &resultScorer{profile: profile, now: now}
+	for _, k := range profile.Keywords {
+		sc.keywords = append(sc.keywords, weightedPattern{k.Term, k.Weight, termPattern(k.Term)})
+	}
+	for _, t := range profile.ExcludedKeywords {
+		sc.excluded = append(sc.excluded, weightedPattern{t, excludedKeywordPoints, termPattern(t)})
+	}
+	for _, c := range profile.PreferredCompanies {
+		sc.companies = append(sc.companies, normalizeCompany(c))
+	}
+	return sc
+}
+
+// termPattern matches term as a whole word, case-insensitively. Word
+// boundaries are letters and digits rather than \b, so terms such as "c++"
+// and ".net" match too.
+func termPattern(term string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(?:^|[^\pL\pN])` + regexp.QuoteMeta(term) + `(?:$|[^\pL\pN])`)
+}
+
+func (sc *resultScorer) score(r *SearchResult) (int, []domain.RankReason) {
+	reasons := []domain.RankReason{}
+	add := func(points int, format string, args ...interface{}) {
+		reasons = append(reasons, domain.RankReason{Points: points, Reason: fmt.Sprintf(format, args...)})
+	}
+	body := r.CompanyName + "\n" + richtext.ToText(r.Description)
+
+	for _, k := range sc.keywords {
+		switch {
+		case k.pattern.MatchString(r.JobTitle):
+			add(2*k.weight, "title mentions %q", k.term)
+		case k.pattern.MatchString(body):
+			add(k.weight, "description mentions %q", k.term)
+		}
+	}
+	for _, k := range sc.excluded {
+		if k.pattern.MatchString(r.JobTitle) || k.pattern.MatchString(body) {
+			add(k.weight, "mentions excluded keyword %q", k.term)
+		}
+	}
+
+	company := normalizeCompany(r.CompanyName)
+	for i, c := range sc.companies {
+		if company != "" && company == c {
+			add(preferredCompanyBonus, "preferred company %s", sc.profile.PreferredCompanies[i])
+			break
+		}
+	}
+
+	if floor := float64(sc.profile.MinSalary); floor > 0 {
+		if low, high, ok := r.annualSalary(); ok {
+			switch {
+			case high < floor:
+				add(salaryBelowFloorMalus, "salary up to %s is below %s", formatAmount(high), formatAmount(floor))
+			case low >= floor:
+				add(salaryMeetsFloorBonus, "salary from %s meets %s", formatAmount(low), formatAmount(floor))
+			default:
+				add(salaryReachesFloor, "salary range reaches %s", formatAmount(floor))
+			}
+		}
+	}
+
+	switch sc.profile.RemotePreference {
+	case domain.RemotePreferred:
+		if r.IsRemote {
+			add(remoteBonus, "remote")
+		}
+	case domain.RemoteRequired:
+		if !r.IsRemote {
+			add(notRemoteMalus, "not remote")
+		}
+	}
+
+	if sc.profile.MaxAgeDays > 0 && r.PostedAt != nil {
+		if days := int(sc.now.Sub(*r.PostedAt).Hours() / 24); days > sc.profile.MaxAgeDays {
+			add(tooOldMalus, "posted %d days ago", days)
+		}
+	}
+
+	if r.MatchScore != nil {
+		add(*r.MatchScore/matchScoreDivisor, "resume match %d%%", *r.MatchScore)
+	}
+
+	if r.PreferenceScore != nil {
+		add((*r.PreferenceScore-50)*preferencePoints/100, "learned preference %d%%", *r.PreferenceScore)
+	}
+
+	total := 0
+	for _, reason := range reasons {
+		total += reason.Points
+	}
+	return total, reasons
+}
+
+var companySuffixPattern = regexp.MustCompile(`(?i)[,.]?\s+(?:inc|llc|ltd|limited|gmbh|corp|corporation|co|plc|s\.?a|b\.?v)\.?$`)
+
+// normalizeCompany compares company names loosely: "Acme, Inc." is "acme".
+func normalizeCompany(name string) string {
+	name = strings.TrimSpace(name)
+	for {
+		trimmed := companySuffixPattern.ReplaceAllString(name, "")
+		if trimmed == name {
+			break
+		}
+		name = strings.TrimSpace(trimmed)
+	}
+	return strings.ToLower(name)
+}
+
+// salaryRange is the pay reported by the scraper.
+type salaryRange struct {
+	Min, Max float64
+	Period   string
+}
+
+// Hours and days in a working year, for annualizing hourly and daily pay.
+const (
+	workHoursPerYear = 2080
+	workDaysPerYear  = 260
+)
+
+func annualize(amount float64, period string) float64 {
+	switch strings.ToLower(period) {
+	case "hourly", "hour", "hr":
+		return amount * workHoursPerYear
+	case "daily", "day":
+		return amount * workDaysPerYear
+	case "weekly", "week":
+		return amount * 52
+	case "monthly", "month":
+		return amount * 12
+	default:
+		return amount
+	}
+}
+
+var (
+	salaryAmountPattern = regexp.MustCompile(`(?i)(\d[\d,]*(?:\.\d+)?)\s*(k)?`)
+	salaryPeriodPattern = regexp.MustCompile(`(?i)(?:/|per\s+|an?\s+)(hour|hr|day|week|month|year|yr)\b|\b(hourly|daily|weekly|monthly|yearly|annually)\b`)
+)
+
+// annualSalary is the result's salary range per year, from the scraper's
+// amounts or, failing that, the salary text ("$120k - $150k", "$45/hr").
+func (r *SearchResult) annualSalary() (float64, float64, bool) {
+	low, high, period := r.salary.Min, r.salary.Max, r.salary.Period
+	if low == 0 && high == 0 {
+		var amounts []float64
+		for _, m := range salaryAmountPattern.FindAllStringSubmatch(r.Salary, 2) {
+			n, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+			if err != nil {
+				continue
+			}
+			if m[2] != "" {
+				n *= 1000
+			}
+			amounts = append(amounts, n)
+		}
+		if len(amounts) == 0 {
+			return 0, 0, false
+		}
+		low, high = amounts[0], amounts[len(amounts)-1]
+		if m := salaryPeriodPattern.FindStringSubmatch(r.Salary); m != nil {
+			switch strings.ToLower(m[1] + m[2]) {
+			case "hourly", "hour", "hr":
+				period = "hourly"
+			case "daily", "day":
+				period = "daily"
+			case "weekly", "week":
+				period = "weekly"
+			case "monthly", "month":
+				period = "monthly"
+			default:
+				period = "yearly"
+			}
+		}
+	}
+	if high == 0 {
+		high = low
+	}
+	if low == 0 {
+		low = high
+	}
+	return annualize(low, period), annualize(high, period), true
+}
+
+func formatAmount(amount float64) string {
+	if amount >= 1000 {
+		return fmt.Sprintf("%.0fk", amount/1000)
+	}
+	return fmt.Sprintf("%.0f", amount)
+}
+
+// parsePostedDate reads the scraper's posting date, which is a date or a
+// timestamp.
+func parsePostedDate(s string) *time.Time {
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/jobanalysis"
+)
+
+// analyzeJob extracts the job's requirements from its title and description.
+func analyzeJob(job *domain.Job) {
+	a := jobanalysis.Analyze(job.JobTitle, job.Description)
+	now := time.Now()
+	job.Requirements = domain.JobRequirements{
+		Skills:              a.Skills,
+		MinYearsExperience:  a.MinYears,
+		Seniority:           a.Seniority,
+		VisaSponsorship:     a.Sponsorship,
+		CitizenshipRequired: a.CitizenshipRequired,
+		Clearance:           a.Clearance,
+		Education:           a.Education,
+		AnalyzedAt:          &now,
+	}
+}
+
+var seniorityLevels = map[string]bool{
+	jobanalysis.SeniorityIntern:    true,
+	jobanalysis.SeniorityJunior:    true,
+	jobanalysis.SeniorityMid:       true,
+	jobanalysis.SenioritySenior:    true,
+	jobanalysis.SeniorityLead:      true,
+	jobanalysis.SeniorityStaff:     true,
+	jobanalysis.SeniorityPrincipal: true,
+}
+
+// validateJobFilter rejects requirement filters with values the analyzer
+// never produces, which would otherwise silently match nothing.
+func validateJobFilter(filter *domain.JobFilter) error {
+	if filter.MaxYears != nil && *filter.MaxYears < 0 {
+		return fmt.Errorf("%w: max_years must not be negative", appErrors.ErrInvalidInput)
+	}
+	for _, level := range filter.Seniority {
+		if !seniorityLevels[level] {
+			return fmt.Errorf("%w: unknown seniority %q (allowed: intern, junior, mid, senior, lead, staff, principal)", appErrors.ErrInvalidInput, level)
+		}
+	}
+	for _, exclude := range filter.Exclude {
+		switch exclude {
+		case domain.ExcludeCitizenship, domain.ExcludeClearance, domain.ExcludeNoSponsorship:
+		default:
+			return fmt.Errorf("%w: unknown exclude %q (allowed: citizenship, clearance, no_sponsorship)", appErrors.ErrInvalidInput, exclude)
+		}
+	}
+	return nil
+}
+
+// AnalyzeJobs re-extracts the requirements of every job, for jobs saved
+// before the analyzer existed or after it has been improved. progress, if
+// non-nil, is called after each job.
+func (s *JobService) AnalyzeJobs(progress func(done int)) (int, error) {
+	done := 0
+	err := s.repo.Stream(&domain.JobFilter{}, exportBatchSize, func(jobs []domain.Job) error {
+		for i := range jobs {
+			analyzeJob(&jobs[i])
+			if err := s.repo.UpdateRequirements(jobs[i].ID, &jobs[i].Requirements); err != nil {
+				return fmt.Errorf("job %s: %w", jobs[i].ID, err)
+			}
+			done++
+			if progress != nil {
+				progress(done)
+			}
+		}
+		return nil
+	})
+	return done, err
+}
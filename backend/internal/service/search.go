@@ -0,0 +1,351 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"job-tracker-backend/internal/config"
+	"job-tracker-backend/internal/domain"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/richtext"
+	"job-tracker-backend/pkg/textmatch"
+)
+
+// Names of the search providers besides the job boards, which are named
+// after their applicant tracking system.
+const (
+	ProviderJobSpy = "jobspy"
+	ProviderFeeds  = "feeds"
+	ProviderStatic = "static"
+)
+
+// SearchProvider finds job postings in one source. Providers whose source
+// can't be queried, such as feeds and job boards, filter their postings with
+// matchesSearch. A provider may return postings along with an error when
+// only part of its source failed.
+type SearchProvider interface {
+	Name() string
+	Search(params MCPSearchParams) ([]Posting, error)
+}
+
+// Posting is a job as found by a search provider. Description may be HTML,
+// Markdown or plain text.
+type Posting struct {
+	Title        string     `json:"title"`
+	Company      string     `json:"company"`
+	Location     string     `json:"location"`
+	URL          string     `json:"url"`
+	Description  string     `json:"description"`
+	Salary       string     `json:"salary"`
+	SalaryMin    float64    `json:"salary_min"`
+	SalaryMax    float64    `json:"salary_max"`
+	SalaryPeriod string     `json:"salary_period"`
+	JobType      string     `json:"job_type"`
+	IsRemote     bool       `json:"is_remote"`
+	Source       string     `json:"source"`
+	PostedAt     *time.Time `json:"posted_at"`
+}
+
+// ProviderReport tells how one provider fared in a search.
+type ProviderReport struct {
+	Provider   string `json:"provider"`
+	Count      int    `json:"count"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// NewSearchProviders returns the providers enabled in cfg, in the order
+// their results are merged. Job boards are read with ats.
+func NewSearchProviders(cfg *config.Config, ats *ATSClient) ([]SearchProvider, error) {
+	timeout := time.Duration(cfg.SearchTimeoutSeconds) * time.Second
+	var providers []SearchProvider
+	if cfg.MCPServerURL != "" {
+		providers = append(providers, NewJobSpyProvider(cfg.MCPServerURL, timeout))
+	}
+	if feeds := splitList(cfg.SearchFeeds); len(feeds) > 0 {
+		for _, f := range feeds {
+			if u, err := url.Parse(f); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				return nil, fmt.Errorf("invalid feed URL %q", f)
+			}
+		}
+		providers = append(providers, NewFeedProvider(feeds, timeout))
+	}
+	for _, b := range []struct{ ats, boards string }{
+		{ATSGreenhouse, cfg.SearchGreenhouseBoards},
+		{ATSLever, cfg.SearchLeverCompanies},
+		{ATSAshby, cfg.SearchAshbyBoards},
+		{ATSWorkable, cfg.SearchWorkableAccounts},
+	} {
+		boards := splitList(b.boards)
+		for _, board := range boards {
+			if err := ValidateBoard(b.ats, board); err != nil {
+				return nil, err
+			}
+		}
+		if len(boards) > 0 {
+			providers = append(providers, NewBoardProvider(b.ats, boards, ats))
+		}
+	}
+	if cfg.SearchStaticFile != "" {
+		providers = append(providers, NewStaticProvider(cfg.SearchStaticFile))
+	}
+	return providers, nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// SearchProviderNames lists the enabled providers.
+func (s *JobService) SearchProviderNames() []string {
+	names := make([]string, len(s.providers))
+	for i, p := range s.providers {
+		names[i] = p.Name()
+	}
+	return names
+}
+
+// selectProviders returns the providers named in names, or all of them when
+// names is empty.
+func (s *JobService) selectProviders(names []string) ([]SearchProvider, error) {
+	if len(names) == 0 {
+		return s.providers, nil
+	}
+	var selected []SearchProvider
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		found := false
+		for _, p := range s.providers {
+			if p.Name() == name {
+				selected = append(selected, p)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: unknown search provider %q (enabled: %s)", appErrors.ErrInvalidInput, name, strings.Join(s.SearchProviderNames(), ", "))
+		}
+	}
+	return selected, nil
+}
+
+// SearchJobs runs the search on the selected providers concurrently and
+// merges their postings, dropping duplicates. A failing provider is reported
+// without failing the search, unless every provider failed.
+func (s *JobService) SearchJobs(userID string, params MCPSearchParams) ([]SearchResult, []ProviderReport, error) {
+	providers, err := s.selectProviders(params.Providers)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(providers) == 0 {
+		return nil, nil, errors.New("no search providers are configured")
+	}
+
+	postings := make([][]Posting, len(providers))
+	reports := make([]ProviderReport, len(providers))
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			found, err := p.Search(params)
+			reports[i] = ProviderReport{Provider: p.Name(), Count: len(found), DurationMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				reports[i].Error = err.Error()
+			}
+			postings[i] = found
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	var errs []string
+	for _, r := range reports {
+		if r.Error != "" && r.Count == 0 {
+			failed++
+			errs = append(errs, r.Provider+": "+r.Error)
+		}
+	}
+	if failed == len(providers) {
+		return nil, reports, errors.New(strings.Join(errs, "; "))
+	}
+
+	var results []SearchResult
+	seen := make(map[string]bool)
+	for _, found := range postings {
+		for _, p := range found {
+			if p.Title == "" {
+				continue
+			}
+			var keys []string
+			if p.Company != "" {
+				keys = append(keys, postingIdentity(p))
+			}
+			if p.URL != "" {
+				keys = append(keys, canonicalJobURL(p.URL))
+			}
+			duplicate := false
+			for _, k := range keys {
+				duplicate = duplicate || seen[k]
+				seen[k] = true
+			}
+			if duplicate {
+				continue
+			}
+			results = append(results, s.searchResult(userID, p))
+		}
+	}
+	return results, reports, nil
+}
+
+func (s *JobService) searchResult(userID string, p Posting) SearchResult {
+	salary := p.Salary
+	if salary == "" && (p.SalaryMin > 0 || p.SalaryMax > 0) {
+		salary = fmt.Sprintf("%.0f-%.0f", p.SalaryMin, p.SalaryMax)
+	}
+
+	isSaved := false
+	if p.URL != "" {
+		exists, err := s.repo.ExistsByURL(p.URL, userID)
+		if err == nil && exists {
+			isSaved = true
+		}
+	}
+
+	job := domain.Job{
+		JobTitle:    p.Title,
+		CompanyName: p.Company,
+		Location:    p.Location,
+		JobURL:      p.URL,
+		Salary:      salary,
+		JobType:     p.JobType,
+		IsRemote:    p.IsRemote,
+		Source:      p.Source,
+		Status:      string(domain.StatusNew),
+	}
+	setDescription(&job, p.Description)
+	analyzeJob(&job)
+	return SearchResult{
+		Job:      job,
+		IsSaved:  isSaved,
+		PostedAt: p.PostedAt,
+		salary:   salaryRange{Min: p.SalaryMin, Max: p.SalaryMax, Period: p.SalaryPeriod},
+	}
+}
+
+// trackingParams are query parameters that don't change which posting a URL
+// points to.
+var trackingParams = regexp.MustCompile(`^(?:utm_.*|ref|source|src|gh_src|lever-source.*|trk.*)$`)
+
+// canonicalJobURL lets the same posting linked from different providers be
+// recognised: scheme, host case, trailing slashes and tracking parameters
+// are ignored.
+func canonicalJobURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return "url:" + raw
+	}
+	q := u.Query()
+	for k := range q {
+		if trackingParams.MatchString(strings.ToLower(k)) {
+			q.Del(k)
+		}
+	}
+	return "url:" + strings.ToLower(u.Host) + strings.TrimRight(u.Path, "/") + "?" + q.Encode()
+}
+
+// postingIdentity recognises a posting listed by several providers under
+// different URLs by its title, company and location.
+func postingIdentity(p Posting) string {
+	return "job:" + strings.Join([]string{
+		strings.Join(strings.Fields(strings.ToLower(p.Title)), " "),
+		normalizeCompany(p.Company),
+		strings.Join(strings.Fields(strings.ToLower(p.Location)), " "),
+	}, "|")
+}
+
+var nonLetters = regexp.MustCompile(`[^a-z]`)
+
+// matchesSearch reports whether a posting from a provider that can't be
+// queried satisfies the search: every search term must appear in its title
+// or description, and the location, remote, job type and age filters must
+// hold where the posting says enough to tell.
+func matchesSearch(p Posting, params MCPSearchParams, now time.Time) bool {
+	if terms := textmatch.Terms(params.SearchTerm); len(terms) > 0 {
+		have := make(map[string]bool)
+		for _, t := range textmatch.Terms(p.Title + "\n" + p.Company + "\n" + plainDescription(p.Description)) {
+			have[t] = true
+		}
+		for _, t := range terms {
+			if !have[t] {
+				return false
+			}
+		}
+	}
+	if params.IsRemote && !p.IsRemote {
+		return false
+	}
+	if params.Location != "" && p.Location != "" && !p.IsRemote {
+		// "San Francisco, CA" matches postings in "San Francisco".
+		place := strings.ToLower(strings.TrimSpace(strings.Split(params.Location, ",")[0]))
+		if place != "" && !strings.Contains(strings.ToLower(p.Location), place) {
+			return false
+		}
+	}
+	if params.JobType != "" && p.JobType != "" &&
+		nonLetters.ReplaceAllString(strings.ToLower(params.JobType), "") != nonLetters.ReplaceAllString(strings.ToLower(p.JobType), "") {
+		return false
+	}
+	if params.HoursOld > 0 && p.PostedAt != nil && now.Sub(*p.PostedAt) > time.Duration(params.HoursOld)*time.Hour {
+		return false
+	}
+	return true
+}
+
+// filterPostings keeps the postings matching the search, newest first, up
+// to the number of results wanted.
+func filterPostings(postings []Posting, params MCPSearchParams) []Posting {
+	now := time.Now()
+	var kept []Posting
+	for _, p := range postings {
+		if matchesSearch(p, params, now) {
+			kept = append(kept, p)
+		}
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		a, b := kept[i].PostedAt, kept[j].PostedAt
+		return a != nil && (b == nil || a.After(*b))
+	})
+	if params.ResultsWanted > 0 && len(kept) > params.ResultsWanted {
+		kept = kept[:params.ResultsWanted]
+	}
+	return kept
+}
+
+func plainDescription(description string) string {
+	sanitized, _ := richtext.Normalize(description)
+	return richtext.ToText(sanitized)
+}
+
+// isRemoteText reports whether a location or title says the job is remote.
+func isRemoteText(s string) bool {
+	s = strings.ToLower(s)
+	return strings.Contains(s, "remote") || strings.Contains(s, "anywhere")
+}
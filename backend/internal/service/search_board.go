@@ -0,0 +1,35 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// BoardProvider searches the job boards of a configured list of companies
+// on one applicant tracking system. Boards list every open position, so
+// postings are filtered locally.
+type BoardProvider struct {
+	ats    string
+	boards []string
+	client *ATSClient
+}
+
+func NewBoardProvider(ats string, boards []string, client *ATSClient) *BoardProvider {
+	return &BoardProvider{ats: ats, boards: boards, client: client}
+}
+
+func (p *BoardProvider) Name() string { return p.ats }
+
+func (p *BoardProvider) Search(params MCPSearchParams) ([]Posting, error) {
+	var postings []Posting
+	var errs []error
+	for _, board := range p.boards {
+		found, err := p.client.FetchBoard(p.ats, board)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", board, err))
+			continue
+		}
+		postings = append(postings, found...)
+	}
+	return filterPostings(postings, params), errors.Join(errs...)
+}
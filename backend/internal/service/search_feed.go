@@ -0,0 +1,219 @@
+package service
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxFeedSize bounds how much of a feed is read.
+const maxFeedSize = 10 << 20
+
+// FeedProvider searches RSS and Atom job feeds. Feeds can't be queried, so
+// their items are filtered locally; a feed URL may include {search_term} and
+// {location}, which are replaced with the search's values for feeds that do
+// accept a query.
+type FeedProvider struct {
+	feeds      []string
+	httpClient *http.Client
+}
+
+func NewFeedProvider(feeds []string, timeout time.Duration) *FeedProvider {
+	return &FeedProvider{feeds: feeds, httpClient: &http.Client{Timeout: timeout}}
+}
+
+func (p *FeedProvider) Name() string { return ProviderFeeds }
+
+func (p *FeedProvider) Search(params MCPSearchParams) ([]Posting, error) {
+	var postings []Posting
+	var errs []error
+	for _, feed := range p.feeds {
+		feedURL := strings.NewReplacer(
+			"{search_term}", url.QueryEscape(params.SearchTerm),
+			"{location}", url.QueryEscape(params.Location),
+		).Replace(feed)
+		found, err := p.fetch(feedURL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", feedURL, err))
+			continue
+		}
+		postings = append(postings, found...)
+	}
+	return filterPostings(postings, params), errors.Join(errs...)
+}
+
+func (p *FeedProvider) fetch(feedURL string) ([]Posting, error) {
+	resp, err := p.httpClient.Get(feedURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize))
+	if err != nil {
+		return nil, err
+	}
+	source := feedURL
+	if u, err := url.Parse(feedURL); err == nil {
+		source = strings.TrimPrefix(u.Hostname(), "www.")
+	}
+	return parseFeed(body, source)
+}
+
+// rssFeed and atomFeed hold the parts of RSS 2.0 and Atom documents a job
+// posting is built from. Job boards put the location in non-standard
+// elements, matched here by local name.
+type rssFeed struct {
+	Items []struct {
+		Title       string   `xml:"title"`
+		Link        string   `xml:"link"`
+		GUID        string   `xml:"guid"`
+		Description string   `xml:"description"`
+		Content     string   `xml:"encoded"`
+		PubDate     string   `xml:"pubDate"`
+		Creator     string   `xml:"creator"`
+		Company     string   `xml:"company"`
+		Location    string   `xml:"location"`
+		Region      string   `xml:"region"`
+		JobType     string   `xml:"type"`
+		Categories  []string `xml:"category"`
+	} `xml:"channel>item"`
+}
+
+type atomFeed struct {
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		ID        string `xml:"id"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Author    string `xml:"author>name"`
+		Location  string `xml:"location"`
+	} `xml:"entry"`
+}
+
+func parseFeed(body []byte, source string) ([]Posting, error) {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(body, &root); err != nil {
+		return nil, fmt.Errorf("invalid feed: %w", err)
+	}
+
+	var postings []Posting
+	switch root.XMLName.Local {
+	case "rss":
+		var feed rssFeed
+		if err := xml.Unmarshal(body, &feed); err != nil {
+			return nil, fmt.Errorf("invalid RSS feed: %w", err)
+		}
+		for _, item := range feed.Items {
+			link := item.Link
+			if link == "" && strings.HasPrefix(item.GUID, "http") {
+				link = item.GUID
+			}
+			description := item.Content
+			if description == "" {
+				description = item.Description
+			}
+			location := firstNonEmpty(item.Location, item.Region)
+			title, company := splitFeedTitle(item.Title)
+			company = firstNonEmpty(item.Company, company, item.Creator)
+			postings = append(postings, Posting{
+				Title:       title,
+				Company:     company,
+				Location:    location,
+				URL:         link,
+				Description: description,
+				JobType:     item.JobType,
+				IsRemote:    isRemoteText(location) || isRemoteText(item.Title) || containsRemote(item.Categories),
+				Source:      source,
+				PostedAt:    parseFeedDate(item.PubDate),
+			})
+		}
+	case "feed":
+		var feed atomFeed
+		if err := xml.Unmarshal(body, &feed); err != nil {
+			return nil, fmt.Errorf("invalid Atom feed: %w", err)
+		}
+		for _, entry := range feed.Entries {
+			var link string
+			for _, l := range entry.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					link = l.Href
+					break
+				}
+			}
+			if link == "" && strings.HasPrefix(entry.ID, "http") {
+				link = entry.ID
+			}
+			title, company := splitFeedTitle(entry.Title)
+			postings = append(postings, Posting{
+				Title:       title,
+				Company:     firstNonEmpty(company, entry.Author),
+				Location:    entry.Location,
+				URL:         link,
+				Description: firstNonEmpty(entry.Content, entry.Summary),
+				IsRemote:    isRemoteText(entry.Location) || isRemoteText(entry.Title),
+				Source:      source,
+				PostedAt:    parseFeedDate(firstNonEmpty(entry.Published, entry.Updated)),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("not an RSS or Atom feed: <%s>", root.XMLName.Local)
+	}
+	return postings, nil
+}
+
+// splitFeedTitle separates the company from titles written "Title at
+// Company" or "Company: Title", the two conventions job feeds use.
+func splitFeedTitle(title string) (string, string) {
+	title = strings.TrimSpace(title)
+	if i := strings.LastIndex(title, " at "); i > 0 {
+		return strings.TrimSpace(title[:i]), strings.TrimSpace(title[i+4:])
+	}
+	if i := strings.Index(title, ": "); i > 0 {
+		return strings.TrimSpace(title[i+2:]), strings.TrimSpace(title[:i])
+	}
+	return title, ""
+}
+
+func parseFeedDate(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST", time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return parsePostedDate(s)
+}
+
+func containsRemote(values []string) bool {
+	for _, v := range values {
+		if isRemoteText(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// JobSpyProvider searches the job boards scraped by the JobSpy MCP server.
+type JobSpyProvider struct {
+	serverURL  string
+	httpClient *http.Client
+}
+
+func NewJobSpyProvider(serverURL string, timeout time.Duration) *JobSpyProvider {
+	return &JobSpyProvider{serverURL: serverURL, httpClient: &http.Client{Timeout: timeout}}
+}
+
+func (p *JobSpyProvider) Name() string { return ProviderJobSpy }
+
+type MCPSearchRequest struct {
+	Method string          `json:"method"`
+	Params MCPSearchParams `json:"params"`
+}
+
+type MCPSearchResponse struct {
+	Count   int      `json:"count"`
+	Message string   `json:"message"`
+	Jobs    []MCPJob `json:"jobs"`
+}
+
+type MCPJob struct {
+	JobTitle        string  `json:"jobTitle"`
+	JobSummary      string  `json:"jobSummary"`
+	Description     string  `json:"description"`
+	JobURL          string  `json:"jobUrl"`
+	JobURLDirect    string  `json:"jobUrlDirect"`
+	Location        string  `json:"location"`
+	Country         string  `json:"country"`
+	State           string  `json:"state"`
+	City            string  `json:"city"`
+	DatePosted      string  `json:"datePosted"`
+	JobType         string  `json:"jobType"`
+	Salary          string  `json:"salary"`
+	SalaryPeriod    string  `json:"salaryPeriod"`
+	MinAmount       float64 `json:"minAmount"`
+	MaxAmount       float64 `json:"maxAmount"`
+	IsRemote        bool    `json:"isRemote"`
+	CompanyName     string  `json:"companyName"`
+	CompanyIndustry string  `json:"companyIndustry"`
+	CompanyURL      string  `json:"companyUrl"`
+	CompanyLogo     string  `json:"companyLogo"`
+	Title           string  `json:"title"`
+	Summary         string  `json:"summary"`
+	URL             string  `json:"url"`
+	Company         string  `json:"company"`
+	Source          string  `json:"source"`
+}
+
+func (p *JobSpyProvider) Search(params MCPSearchParams) ([]Posting, error) {
+	params.Providers = nil
+	reqBody := MCPSearchRequest{
+		Method: "search_jobs",
+		Params: params,
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	mcpURL := fmt.Sprintf("%s/api", p.serverURL)
+	resp, err := p.httpClient.Post(mcpURL, "application/json", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to call MCP server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("MCP server returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var mcpResp MCPSearchResponse
+	if err := json.Unmarshal(bodyBytes, &mcpResp); err != nil {
+		return nil, fmt.Errorf("failed to decode MCP response: %w", err)
+	}
+
+	postings := make([]Posting, 0, len(mcpResp.Jobs))
+	for _, mcpJob := range mcpResp.Jobs {
+		jobURL := mcpJob.JobURL
+		if jobURL == "" {
+			jobURL = mcpJob.JobURLDirect
+		}
+		if jobURL == "" {
+			jobURL = mcpJob.URL
+		}
+
+		jobTitle := mcpJob.JobTitle
+		if jobTitle == "" {
+			jobTitle = mcpJob.Title
+		}
+		if jobTitle == "" {
+			jobTitle = mcpJob.Summary
+		}
+
+		companyName := mcpJob.CompanyName
+		if companyName == "" {
+			companyName = mcpJob.Company
+		}
+
+		postings = append(postings, Posting{
+			Title:        jobTitle,
+			Company:      companyName,
+			Location:     mcpJob.Location,
+			URL:          jobURL,
+			Description:  mcpJob.Description,
+			Salary:       mcpJob.Salary,
+			SalaryMin:    mcpJob.MinAmount,
+			SalaryMax:    mcpJob.MaxAmount,
+			SalaryPeriod: mcpJob.SalaryPeriod,
+			JobType:      mcpJob.JobType,
+			IsRemote:     mcpJob.IsRemote,
+			Source:       mcpJob.Source,
+			PostedAt:     parsePostedDate(mcpJob.DatePosted),
+		})
+	}
+	return postings, nil
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// StaticProvider searches postings read from a JSON file, an array of
+// Posting objects. It is meant for tests and demos: the file is read on
+// every search, so it can be edited while the server runs.
+type StaticProvider struct {
+	path string
+}
+
+func NewStaticProvider(path string) *StaticProvider {
+	return &StaticProvider{path: path}
+}
+
+func (p *StaticProvider) Name() string { return ProviderStatic }
+
+func (p *StaticProvider) Search(params MCPSearchParams) ([]Posting, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+	var postings []Posting
+	if err := json.Unmarshal(data, &postings); err != nil {
+		return nil, fmt.Errorf("invalid postings file %s: %w", p.path, err)
+	}
+	for i := range postings {
+		if postings[i].Source == "" {
+			postings[i].Source = ProviderStatic
+		}
+	}
+	return filterPostings(postings, params), nil
+}
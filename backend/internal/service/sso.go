@@ -0,0 +1,238 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"job-tracker-backend/internal/auth"
+	"job-tracker-backend/internal/config"
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+	appErrors "job-tracker-backend/pkg/errors"
+)
+
+const (
+	// ssoStateTTL bounds how long a user may spend at the identity provider.
+	ssoStateTTL = 10 * time.Minute
+	// ssoLoginTTL is how long the frontend has to redeem a finished sign-in.
+	ssoLoginTTL = 2 * time.Minute
+)
+
+var ssoProviderName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// errSSOEmailUnverified is shown to a user whose identity provider doesn't
+// vouch for their email, which is what links them to an account.
+var errSSOEmailUnverified = errors.New("your identity provider did not confirm your email address")
+
+type ssoProvider struct {
+	domain.SSOProvider
+	rp *auth.OIDCProvider
+}
+
+// SSOService signs users in through OpenID Connect providers. The first
+// sign-in links the provider's identity to the account with the same,
+// provider-verified, email, creating the account if there is none.
+type SSOService struct {
+	providers   map[string]*ssoProvider
+	order       []domain.SSOProvider
+	users       *repository.UserRepository
+	identities  *repository.ExternalIdentityRepository
+	auth        *AuthService
+	stateSecret string
+	appBaseURL  string
+	// secure marks the state cookie Secure when the API is served over
+	// HTTPS
+	secure bool
+}
+
+func NewSSOService(cfg *config.Config, users *repository.UserRepository, identities *repository.ExternalIdentityRepository, authService *AuthService) (*SSOService, error) {
+	s := &SSOService{
+		providers:   map[string]*ssoProvider{},
+		order:       []domain.SSOProvider{},
+		users:       users,
+		identities:  identities,
+		auth:        authService,
+		stateSecret: cfg.JWTSecret,
+		appBaseURL:  strings.TrimRight(cfg.AppBaseURL, "/"),
+		secure:      strings.HasPrefix(cfg.PublicBaseURL, "https://"),
+	}
+	client := &http.Client{Timeout: 15 * time.Second}
+	for _, p := range cfg.OIDCProviders {
+		if !ssoProviderName.MatchString(p.Name) {
+			return nil, fmt.Errorf("invalid OIDC provider name %q", p.Name)
+		}
+		if _, dup := s.providers[p.Name]; dup {
+			return nil, fmt.Errorf("OIDC provider %s is listed twice", p.Name)
+		}
+		if p.Issuer == "" || p.ClientID == "" {
+			return nil, fmt.Errorf("OIDC provider %s: issuer and client ID are required", p.Name)
+		}
+		if u, err := url.Parse(p.Issuer); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("OIDC provider %s: invalid issuer %q", p.Name, p.Issuer)
+		}
+		info := domain.SSOProvider{Name: p.Name, DisplayName: p.DisplayName}
+		s.providers[p.Name] = &ssoProvider{
+			SSOProvider: info,
+			rp: auth.NewOIDCProvider(auth.OIDCConfig{
+				Issuer:       p.Issuer,
+				ClientID:     p.ClientID,
+				ClientSecret: p.ClientSecret,
+				RedirectURL:  strings.TrimRight(cfg.PublicBaseURL, "/") + "/api/auth/sso/" + p.Name + "/callback",
+				Scopes:       splitList(p.Scopes),
+			}, client),
+		}
+		s.order = append(s.order, info)
+	}
+	return s, nil
+}
+
+// Providers lists the identity providers to offer, in configured order.
+func (s *SSOService) Providers() []domain.SSOProvider {
+	return s.order
+}
+
+// Begin starts a sign-in with a provider. It returns the provider's page to
+// send the user to, and the signed state to keep in their browser until
+// they come back.
+func (s *SSOService) Begin(name string) (authURL, state string, err error) {
+	p, ok := s.providers[name]
+	if !ok {
+		return "", "", appErrors.ErrNotFound
+	}
+	st := &auth.OIDCState{Provider: name}
+	for _, v := range []*string{&st.State, &st.Nonce} {
+		if *v, err = auth.GenerateRandomToken(16); err != nil {
+			return "", "", err
+		}
+	}
+	if st.Verifier, err = auth.NewPKCEVerifier(); err != nil {
+		return "", "", err
+	}
+	authURL, err = p.rp.AuthCodeURL(st.State, st.Nonce, st.Verifier)
+	if err != nil {
+		return "", "", err
+	}
+	state, err = auth.SignOIDCState(st, s.stateSecret, ssoStateTTL)
+	return authURL, state, err
+}
+
+// Callback finishes a sign-in when the provider sends the user back with
+// query, given the state Begin returned. It returns the frontend page to
+// send the user on to, carrying a code to redeem for a session with
+// AuthService.RedeemSSOLogin, or an error to show.
+func (s *SSOService) Callback(name string, query url.Values, state string) string {
+	code, err := s.finish(name, query, state)
+	if err != nil {
+		message := "Sign-in failed. Please try again."
+		switch {
+		case errors.Is(err, errSSOEmailUnverified):
+			message = err.Error()
+		case query.Get("error") == "access_denied":
+			message = "Sign-in was cancelled."
+		}
+		log.Printf("SSO sign-in with %s failed: %v", name, err)
+		return s.FailureURL(message)
+	}
+	return s.appBaseURL + "/sso/callback?" + url.Values{"code": {code}}.Encode()
+}
+
+// FailureURL returns the frontend page that shows a failed sign-in.
+func (s *SSOService) FailureURL(message string) string {
+	return s.appBaseURL + "/sso/callback?" + url.Values{"error": {message}}.Encode()
+}
+
+// SecureCookies reports whether cookies should only travel over HTTPS.
+func (s *SSOService) SecureCookies() bool {
+	return s.secure
+}
+
+func (s *SSOService) finish(name string, query url.Values, state string) (string, error) {
+	p, ok := s.providers[name]
+	if !ok {
+		return "", appErrors.ErrNotFound
+	}
+	if e := query.Get("error"); e != "" {
+		return "", fmt.Errorf("provider returned %s: %s", e, query.Get("error_description"))
+	}
+	st, err := auth.ParseOIDCState(state, s.stateSecret)
+	if err != nil {
+		return "", errors.New("missing or expired state cookie")
+	}
+	if st.Provider != name || st.State == "" || query.Get("state") != st.State {
+		return "", errors.New("state mismatch")
+	}
+	identity, err := p.rp.Exchange(query.Get("code"), st.Verifier, st.Nonce)
+	if err != nil {
+		return "", err
+	}
+	user, err := s.link(name, identity)
+	if err != nil {
+		return "", err
+	}
+	return s.auth.issueToken(user, domain.TokenSSOLogin, ssoLoginTTL)
+}
+
+// link returns the user of an identity, linking it on first sign-in to the
+// account with its verified email, or to a new account.
+func (s *SSOService) link(provider string, id *auth.IDToken) (*domain.User, error) {
+	email := strings.ToLower(strings.TrimSpace(id.Email))
+	now := time.Now()
+	existing, err := s.identities.Get(provider, id.Subject)
+	if err == nil {
+		if err := s.identities.Touch(existing.ID, email, now); err != nil {
+			return nil, err
+		}
+		return s.users.GetByID(existing.UserID)
+	}
+	if !errors.Is(err, appErrors.ErrNotFound) {
+		return nil, err
+	}
+
+	if email == "" || !id.EmailVerified {
+		return nil, errSSOEmailUnverified
+	}
+	user, err := s.users.GetByEmail(email)
+	if errors.Is(err, appErrors.ErrNotFound) {
+		// No password: the account signs in through the provider, or sets
+		// one with a password reset
+		user = &domain.User{Email: email, EmailVerifiedAt: &now}
+		err = s.users.Create(user)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.users.MarkEmailVerified(user.ID, now); err != nil {
+		return nil, err
+	}
+	err = s.identities.Create(&domain.ExternalIdentity{
+		UserID:      user.ID,
+		Provider:    provider,
+		Subject:     id.Subject,
+		Email:       email,
+		LastLoginAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// RedeemSSOLogin exchanges the code from a finished identity provider
+// sign-in for a session, or a two-factor challenge if the account has it.
+func (s *AuthService) RedeemSSOLogin(input *domain.SSOExchangeInput, client domain.ClientInfo) (*domain.AuthResponse, error) {
+	token, err := s.redeemToken(input.Code, domain.TokenSSOLogin)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.signIn(user, client, domain.LoginSSO)
+}
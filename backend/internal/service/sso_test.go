@@ -0,0 +1,341 @@
+package service
+
+import (
+	"database/sql/driver"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt/v5"
+
+	"job-tracker-backend/internal/auth"
+	"job-tracker-backend/internal/auth/oidctest"
+	"job-tracker-backend/internal/config"
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+)
+
+func TestSSOCallback(t *testing.T) {
+	tests := []struct {
+		name string
+		// claims edits the ID token the provider issues for the sign-in
+		claims func(c jwt.MapClaims)
+		// query edits the callback's query string
+		query func(q url.Values)
+		// lookup is whether the identity is looked up before the sign-in
+		// is refused
+		lookup bool
+		want   string
+	}{
+		{
+			name:   "unverified email",
+			claims: func(c jwt.MapClaims) { c["email_verified"] = false },
+			lookup: true,
+			want:   errSSOEmailUnverified.Error(),
+		},
+		{
+			name:   "unverified email as a string",
+			claims: func(c jwt.MapClaims) { c["email_verified"] = "false" },
+			lookup: true,
+			want:   errSSOEmailUnverified.Error(),
+		},
+		{
+			name:   "no email",
+			claims: func(c jwt.MapClaims) { delete(c, "email") },
+			lookup: true,
+			want:   errSSOEmailUnverified.Error(),
+		},
+		{
+			name:   "nonce mismatch",
+			claims: func(c jwt.MapClaims) { c["nonce"] = "replayed" },
+			want:   "Sign-in failed. Please try again.",
+		},
+		{
+			name:   "wrong audience",
+			claims: func(c jwt.MapClaims) { c["aud"] = "other-client" },
+			want:   "Sign-in failed. Please try again.",
+		},
+		{
+			name:   "expired",
+			claims: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+			want:   "Sign-in failed. Please try again.",
+		},
+		{
+			name:  "state mismatch",
+			query: func(q url.Values) { q.Set("state", "forged") },
+			want:  "Sign-in failed. Please try again.",
+		},
+		{
+			name: "cancelled",
+			query: func(q url.Values) {
+				q.Del("code")
+				q.Set("error", "access_denied")
+			},
+			want: "Sign-in was cancelled.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := oidctest.New(t)
+			s, _, mock := newSSOTestServices(t, idp)
+			if tt.lookup {
+				expectIdentity(mock)
+			}
+
+			got := ssoSignIn(t, s, idp, tt.claims, tt.query)
+			if got.Query().Get("code") != "" {
+				t.Fatalf("Callback() signed in: %s", got)
+			}
+			if msg := got.Query().Get("error"); msg != tt.want {
+				t.Errorf("Callback() error = %q, want %q", msg, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSOSignIn(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims func(c jwt.MapClaims)
+		// expect sets the statements that link the identity, returning the
+		// user it ends up signing in
+		expect func(mock sqlmock.Sqlmock) *captured
+	}{
+		{
+			name: "creates an account",
+			expect: func(mock sqlmock.Sqlmock) *captured {
+				userID := &captured{}
+				expectIdentity(mock)
+				mock.ExpectQuery(`SELECT \* FROM "users" WHERE email = \$1`).
+					WithArgs("jane@example.com", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO "users" \("id","email",`).
+					WithArgs(userCreateArgs(userID, "jane@example.com")...).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				expectLink(mock, userID)
+				return userID
+			},
+		},
+		{
+			name: "links the account with the verified email",
+			claims: func(c jwt.MapClaims) {
+				// Providers may keep the case it was typed in
+				c["email"] = "Jane@Example.com"
+			},
+			expect: func(mock sqlmock.Sqlmock) *captured {
+				expectIdentity(mock)
+				mock.ExpectQuery(`SELECT \* FROM "users" WHERE email = \$1`).
+					WithArgs("jane@example.com", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow("user-7", "jane@example.com"))
+				userID := &captured{value: "user-7"}
+				expectLink(mock, userID)
+				return userID
+			},
+		},
+		{
+			name: "signs in a linked identity",
+			// Its email no longer matters once linked
+			claims: func(c jwt.MapClaims) {
+				c["email"] = "jane@elsewhere.example"
+				c["email_verified"] = false
+			},
+			expect: func(mock sqlmock.Sqlmock) *captured {
+				expectIdentity(mock, "identity-1", "user-7")
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE "external_identities" SET "email"=\$1,"last_login_at"=\$2 WHERE id = \$3`).
+					WithArgs("jane@elsewhere.example", sqlmock.AnyArg(), "identity-1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery(`SELECT \* FROM "users" WHERE id = \$1`).
+					WithArgs("user-7", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow("user-7", "jane@example.com"))
+				return &captured{value: "user-7"}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := oidctest.New(t)
+			s, authService, mock := newSSOTestServices(t, idp)
+			userID := tt.expect(mock)
+			tokenHash := &captured{}
+			mock.ExpectBegin()
+			mock.ExpectExec(`DELETE FROM "account_tokens" WHERE user_id = \$1 AND purpose = \$2`).
+				WithArgs(userID, domain.TokenSSOLogin).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectExec(`INSERT INTO "account_tokens"`).
+				WithArgs(sqlmock.AnyArg(), userID, domain.TokenSSOLogin, tokenHash, sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			got := ssoSignIn(t, s, idp, tt.claims, nil)
+			code := got.Query().Get("code")
+			if code == "" {
+				t.Fatalf("Callback() = %s, want a code to sign in with", got)
+			}
+			if auth.HashToken(code) != tokenHash.value {
+				t.Fatal("Callback() code is not the one stored")
+			}
+
+			// The frontend redeems the code for a session
+			mock.ExpectQuery(`SELECT \* FROM "account_tokens" WHERE token_hash = \$1 AND purpose = \$2 AND used_at IS NULL`).
+				WithArgs(tokenHash.value, domain.TokenSSOLogin, sqlmock.AnyArg(), 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "purpose"}).AddRow("token-1", userID.value, domain.TokenSSOLogin))
+			mock.ExpectBegin()
+			mock.ExpectExec(`UPDATE "account_tokens" SET "used_at"=\$1 WHERE id = \$2 AND used_at IS NULL`).
+				WithArgs(sqlmock.AnyArg(), "token-1").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+			mock.ExpectQuery(`SELECT \* FROM "users" WHERE id = \$1`).
+				WithArgs(userID.value, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(userID.value, "jane@example.com"))
+			mock.ExpectBegin()
+			mock.ExpectExec(`UPDATE "users" SET "failed_logins"=\$1,"locked_until"=\$2`).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectExec(`DELETE FROM "sessions" WHERE user_id = \$1 AND expires_at <= \$2`).
+				WithArgs(userID.value, sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectExec(`INSERT INTO "sessions"`).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectExec(`INSERT INTO "login_attempts"`).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectExec(`DELETE FROM "login_attempts" WHERE created_at < \$1`).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectCommit()
+
+			resp, err := authService.RedeemSSOLogin(&domain.SSOExchangeInput{Code: code}, domain.ClientInfo{IP: "203.0.113.7"})
+			if err != nil {
+				t.Fatalf("RedeemSSOLogin() error = %v", err)
+			}
+			if resp.Token == "" || resp.RefreshToken == "" {
+				t.Errorf("RedeemSSOLogin() = %+v, want a session", resp)
+			}
+			if resp.User.ID != userID.value {
+				t.Errorf("RedeemSSOLogin() signed in %v, want %v", resp.User.ID, userID.value)
+			}
+		})
+	}
+}
+
+// ssoSignIn goes through a sign-in with idp, issuing the ID token with
+// claims edited by editClaims and coming back with the query edited by
+// editQuery, and returns the frontend page Callback sends the user to.
+func ssoSignIn(t *testing.T, s *SSOService, idp *oidctest.Provider, editClaims func(jwt.MapClaims), editQuery func(url.Values)) *url.URL {
+	t.Helper()
+	authURL, state, err := s.Begin("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Stand in for the provider's sign-in page
+	claims := idp.Claims(u.Query().Get("nonce"))
+	if editClaims != nil {
+		editClaims(claims)
+	}
+	idp.SetIDToken(idp.Sign(t, claims))
+	query := url.Values{"code": {"code-1"}, "state": {u.Query().Get("state")}}
+	if editQuery != nil {
+		editQuery(query)
+	}
+
+	got, err := url.Parse(s.Callback("test", query, state))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+// newSSOTestServices returns an SSOService signing in with idp as provider
+// "test", and the AuthService redeeming its sign-ins, over a mock database.
+func newSSOTestServices(t *testing.T, idp *oidctest.Provider) (*SSOService, *AuthService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := newMockDB(t)
+	cfg := &config.Config{
+		JWTSecret:              "test-secret",
+		JWTExpiration:          15 * time.Minute,
+		RefreshTokenExpiration: time.Hour,
+		PublicBaseURL:          "http://api.example",
+		AppBaseURL:             "http://app.example",
+		OIDCProviders: []config.OIDCProviderConfig{{
+			Name:     "test",
+			Issuer:   idp.Issuer(),
+			ClientID: oidctest.ClientID,
+		}},
+	}
+	users := repository.NewUserRepository(db)
+	authService := NewAuthService(cfg, users, repository.NewSessionRepository(db), repository.NewAccountTokenRepository(db),
+		repository.NewLoginAttemptRepository(db), repository.NewRecoveryCodeRepository(db), nil)
+	s, err := NewSSOService(cfg, users, repository.NewExternalIdentityRepository(db), authService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, authService, mock
+}
+
+// expectIdentity expects the identity of the test sign-in to be looked up,
+// finding the one with id and userID, or none if not given.
+func expectIdentity(mock sqlmock.Sqlmock, idAndUserID ...string) {
+	rows := sqlmock.NewRows([]string{"id", "user_id", "provider", "subject"})
+	if len(idAndUserID) == 2 {
+		rows.AddRow(idAndUserID[0], idAndUserID[1], "test", "user-1")
+	}
+	mock.ExpectQuery(`SELECT \* FROM "external_identities" WHERE provider = \$1 AND subject = \$2`).
+		WithArgs("test", "user-1", 1).
+		WillReturnRows(rows)
+}
+
+// expectLink expects the test sign-in's identity to be linked to the user,
+// after their email is marked verified.
+func expectLink(mock sqlmock.Sqlmock, userID *captured) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "users" SET "email_verified_at"=COALESCE\(email_verified_at, \$1\),"updated_at"=\$2 WHERE id = \$3`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "external_identities" \("id","user_id","provider","subject","email","created_at","last_login_at"\)`).
+		WithArgs(sqlmock.AnyArg(), userID, "test", "user-1", "jane@example.com", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+// userCreateArgs matches the arguments creating a user with email, whose
+// ID is captured in id.
+func userCreateArgs(id *captured, email string) []driver.Value {
+	args := []driver.Value{id, email}
+	for range 13 {
+		args = append(args, sqlmock.AnyArg())
+	}
+	return args
+}
+
+// captured is a statement argument that records the first value it is
+// given, and then matches only that value.
+type captured struct {
+	value driver.Value
+}
+
+func (c *captured) Match(v driver.Value) bool {
+	if c.value == nil {
+		c.value = v
+	}
+	return v == c.value
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"job-tracker-backend/internal/auth"
+	"job-tracker-backend/internal/domain"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/totp"
+)
+
+const (
+	// totpIssuer names the account in authenticator apps.
+	totpIssuer = "Job Tracker"
+	// totpSkew accepts codes one step either side of now, for clock drift.
+	totpSkew = 1
+
+	// loginChallengeTTL is how long after the password the second factor
+	// may follow.
+	loginChallengeTTL = 5 * time.Minute
+
+	recoveryCodeCount = 10
+	// recoveryCodeAlphabet leaves out look-alikes such as 0/o and 1/l.
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// CompleteLogin finishes a sign-in that Login answered with a challenge,
+// given an authenticator code or an unused recovery code. Wrong codes count
+// towards the account's lockout like wrong passwords.
+func (s *AuthService) CompleteLogin(input *domain.TwoFactorLoginInput, client domain.ClientInfo) (*domain.AuthResponse, error) {
+	expired := fmt.Errorf("%w: the sign-in has expired; sign in again", appErrors.ErrInvalidInput)
+	if input.ChallengeToken == "" {
+		return nil, expired
+	}
+	now := time.Now()
+	challenge, err := s.tokens.GetUsable(auth.HashToken(input.ChallengeToken), domain.TokenLoginChallenge, now)
+	if errors.Is(err, appErrors.ErrNotFound) {
+		return nil, expired
+	}
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkEnabled(user, client); err != nil {
+		return nil, err
+	}
+	if user.LockedUntil != nil && user.LockedUntil.After(now) {
+		s.recordLogin(user.ID, user.Email, client, domain.LoginLocked)
+		return nil, &LockedError{Until: *user.LockedUntil}
+	}
+
+	ok, err := s.checkSecondFactor(user, input.Code, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.recordLogin(user.ID, user.Email, client, domain.LoginBadCode)
+		if err := s.userRepo.RecordLoginFailure(user.ID, s.lockUntil(user.FailedLogins+1, now)); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: the code is incorrect", appErrors.ErrInvalidInput)
+	}
+	if err := s.tokens.Use(challenge.ID, now); err != nil {
+		if errors.Is(err, appErrors.ErrNotFound) {
+			return nil, expired
+		}
+		return nil, err
+	}
+
+	if err := s.userRepo.ResetLoginFailures(user.ID); err != nil {
+		return nil, err
+	}
+	resp, err := s.startSession(user, client)
+	if err != nil {
+		return nil, err
+	}
+	s.recordLogin(user.ID, user.Email, client, domain.LoginSucceeded)
+	return resp, nil
+}
+
+// TwoFactorStatus reports whether two-factor sign-in is on and how many
+// recovery codes are left.
+func (s *AuthService) TwoFactorStatus(userID string) (*domain.TwoFactorStatus, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &domain.TwoFactorStatus{Enabled: user.TOTPEnabledAt != nil}
+	if status.Enabled {
+		status.RecoveryCodesLeft, err = s.recoveryCodes.CountUnused(userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// SetupTwoFactor starts enrollment with a new authenticator secret. It takes
+// effect once ConfirmTwoFactor proves the app has it; until then, starting
+// over replaces it.
+func (s *AuthService) SetupTwoFactor(userID string) (*domain.TwoFactorSetup, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, fmt.Errorf("%w: two-factor authentication is already on", appErrors.ErrAlreadyExists)
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetTOTP(userID, secret, nil); err != nil {
+		return nil, err
+	}
+	return &domain.TwoFactorSetup{Secret: secret, URI: totp.URI(totpIssuer, user.Email, secret)}, nil
+}
+
+// ConfirmTwoFactor turns two-factor sign-in on with a code from the app set
+// up by SetupTwoFactor, and returns the first recovery codes.
+func (s *AuthService) ConfirmTwoFactor(userID string, input *domain.TwoFactorCodeInput) (*domain.RecoveryCodes, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, fmt.Errorf("%w: two-factor authentication is already on", appErrors.ErrAlreadyExists)
+	}
+	if user.TOTPSecret == "" {
+		return nil, fmt.Errorf("%w: start two-factor setup first", appErrors.ErrInvalidInput)
+	}
+	now := time.Now()
+	if _, ok := totp.Validate(user.TOTPSecret, input.Code, now, totpSkew); !ok {
+		return nil, fmt.Errorf("%w: the code is incorrect", appErrors.ErrInvalidInput)
+	}
+	if err := s.userRepo.SetTOTP(userID, user.TOTPSecret, &now); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(userID)
+}
+
+// DisableTwoFactor turns two-factor sign-in off, given the current password.
+func (s *AuthService) DisableTwoFactor(userID string, input *domain.PasswordInput) error {
+	if _, err := s.checkPassword(userID, input.Password); err != nil {
+		return err
+	}
+	if err := s.userRepo.SetTOTP(userID, "", nil); err != nil {
+		return err
+	}
+	return s.recoveryCodes.DeleteAll(userID)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes, given the current
+// password.
+func (s *AuthService) RegenerateRecoveryCodes(userID string, input *domain.PasswordInput) (*domain.RecoveryCodes, error) {
+	user, err := s.checkPassword(userID, input.Password)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt == nil {
+		return nil, fmt.Errorf("%w: two-factor authentication is off", appErrors.ErrInvalidInput)
+	}
+	return s.newRecoveryCodes(userID)
+}
+
+// checkSecondFactor reports whether code is a valid authenticator code not
+// used before, or an unused recovery code, spending it.
+func (s *AuthService) checkSecondFactor(user *domain.User, code string, now time.Time) (bool, error) {
+	if step, ok := totp.Validate(user.TOTPSecret, code, now, totpSkew); ok {
+		return s.userRepo.UseTOTPStep(user.ID, step)
+	}
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return false, nil
+	}
+	err := s.recoveryCodes.Use(user.ID, auth.HashToken(normalized), now)
+	if errors.Is(err, appErrors.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *AuthService) checkPassword(userID, password string) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.CheckPasswordHash(password, user.PasswordHash) {
+		return nil, fmt.Errorf("%w: password is incorrect", appErrors.ErrInvalidInput)
+	}
+	return user, nil
+}
+
+// newRecoveryCodes replaces the user's recovery codes and returns them,
+// formatted as xxxxx-xxxxx.
+func (s *AuthService) newRecoveryCodes(userID string) (*domain.RecoveryCodes, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		for j := range b {
+			// 256 isn't a multiple of the alphabet's length, so this slightly
+			// favours some letters; at 10 characters that costs well under a
+			// bit of entropy.
+			b[j] = recoveryCodeAlphabet[int(b[j])%len(recoveryCodeAlphabet)]
+		}
+		codes[i] = string(b[:5]) + "-" + string(b[5:])
+		hashes[i] = auth.HashToken(string(b))
+	}
+	if err := s.recoveryCodes.Replace(userID, hashes); err != nil {
+		return nil, err
+	}
+	return &domain.RecoveryCodes{Codes: codes}, nil
+}
+
+// normalizeRecoveryCode drops the separators and case a user may type a
+// recovery code with.
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', ' ':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"job-tracker-backend/internal/scan"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/filetype"
+	"job-tracker-backend/pkg/textextract"
+)
+
+var ErrScanFailed = errors.New("virus scan failed")
+
+// genericMIMETypes are Content-Types clients send when they don't know
+// better; the sniffed type is used for them instead of rejecting the upload.
+var genericMIMETypes = map[string]bool{
+	"":                         true,
+	"application/octet-stream": true,
+}
+
+// stagedUpload is an uploaded file that has been spooled to a temporary
+// file, identified from its contents and scanned. Close removes the file.
+type stagedUpload struct {
+	*os.File
+	size     int64
+	hash     string
+	mimeType string
+}
+
+func (u *stagedUpload) Close() error {
+	u.File.Close()
+	return os.Remove(u.File.Name())
+}
+
+// stageUpload copies r to a temporary file, enforcing MaxFileSize, then
+// checks that the content really is a PDF, DOC or DOCX matching declared,
+// and runs it past scanner. Nothing is stored unless all of that passes.
+func stageUpload(r io.Reader, declared string, scanner scan.Scanner) (*stagedUpload, error) {
+	tmp, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	u := &stagedUpload{File: tmp}
+
+	content := newLimitedHashReader(r, MaxFileSize)
+	_, err = io.Copy(tmp, content)
+	if content.exceeded {
+		err = ErrFileTooLarge
+	}
+	if err == nil {
+		u.size = content.n
+		u.hash = content.sum()
+		err = u.validate(declared, scanner)
+	}
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		u.Close()
+		return nil, err
+	}
+	return u, nil
+}
+
+func (u *stagedUpload) validate(declared string, scanner scan.Scanner) error {
+	detected, err := filetype.Detect(u.File, u.size)
+	if err != nil {
+		if errors.Is(err, filetype.ErrUnrecognized) {
+			return fmt.Errorf("%w: file is not a PDF, DOC or DOCX document", appErrors.ErrInvalidInput)
+		}
+		if errors.Is(err, filetype.ErrEncrypted) || errors.Is(err, filetype.ErrMalformed) {
+			return fmt.Errorf("%w: %v", appErrors.ErrInvalidInput, err)
+		}
+		return err
+	}
+	if !genericMIMETypes[declared] && declared != detected {
+		return fmt.Errorf("%w: file content is %s but was uploaded as %s", appErrors.ErrInvalidInput, detected, declared)
+	}
+	u.mimeType = detected
+
+	if _, err := u.File.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := scanner.Scan(u.File); err != nil {
+		if errors.Is(err, scan.ErrInfected) {
+			log.Printf("upload rejected by %s scanner: %v", scanner.Name(), err)
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+	return nil
+}
+
+// extractText returns the searchable text of a document. Failures are
+// logged and yield no text rather than failing the upload.
+func extractText(mimeType string, r io.ReaderAt, size int64) string {
+	text, err := textextract.Extract(mimeType, r, size)
+	if err != nil {
+		if !errors.Is(err, textextract.ErrUnsupported) {
+			log.Printf("text extraction from %s failed: %v", mimeType, err)
+		}
+		return ""
+	}
+	return text
+}
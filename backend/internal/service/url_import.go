@@ -0,0 +1,120 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	appErrors "job-tracker-backend/pkg/errors"
+	"job-tracker-backend/pkg/jobpage"
+)
+
+// maxJobPageSize bounds how much of a posting page is read for import.
+const maxJobPageSize = 5 << 20
+
+// SourceURL is the source of jobs imported from a page that isn't on a
+// known job board.
+const SourceURL = "url"
+
+// URLImportService creates jobs from the URL of their posting, reading the
+// job's fields from the page.
+type URLImportService struct {
+	jobs       *JobService
+	httpClient *http.Client
+	userAgent  string
+}
+
+func NewURLImportService(jobs *JobService, userAgent string, timeout time.Duration) *URLImportService {
+	return &URLImportService{jobs: jobs, httpClient: publicHTTPClient(timeout), userAgent: userAgent}
+}
+
+// ImportURL fetches the posting at input.URL and extracts a job from it. In
+// preview mode the job is only returned, for the user to check and adjust
+// before saving it through CreateJob; in commit mode it is saved as is.
+func (s *URLImportService) ImportURL(userID string, input *domain.JobFromURLInput) (*domain.JobFromURLResult, error) {
+	mode := input.Mode
+	if mode == "" {
+		mode = ImportModePreview
+	}
+	if mode != ImportModePreview && mode != ImportModeCommit {
+		return nil, fmt.Errorf("%w: mode must be 'preview' or 'commit'", appErrors.ErrInvalidInput)
+	}
+	rawURL := strings.TrimSpace(input.URL)
+	pageURL, err := url.Parse(rawURL)
+	if err != nil || (pageURL.Scheme != "http" && pageURL.Scheme != "https") || pageURL.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an http or https URL", appErrors.ErrInvalidInput)
+	}
+
+	body, finalURL, err := s.fetch(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not fetch the page: %v", appErrors.ErrInvalidInput, err)
+	}
+	posting := jobpage.Extract(finalURL, body)
+
+	create := &domain.JobCreateInput{
+		JobTitle:    posting.Title,
+		CompanyName: posting.Company,
+		Location:    posting.Location,
+		JobURL:      rawURL,
+		Description: posting.Description,
+		Salary:      posting.Salary,
+		JobType:     posting.JobType,
+		IsRemote:    posting.IsRemote,
+		Source:      firstNonEmpty(posting.Board, SourceURL),
+		DeadlineAt:  posting.ValidThrough,
+	}
+	result := &domain.JobFromURLResult{Mode: mode, Input: create, Extractors: posting.Extractors}
+	if result.Extractors == nil {
+		result.Extractors = []string{}
+	}
+	result.Duplicate, err = s.jobs.repo.ExistsByURL(rawURL, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mode == ImportModePreview {
+		return result, nil
+	}
+
+	if create.JobTitle == "" {
+		return nil, fmt.Errorf("%w: no job title found on the page", appErrors.ErrInvalidInput)
+	}
+	if result.Duplicate {
+		return nil, appErrors.ErrAlreadyExists
+	}
+	result.Job, err = s.jobs.CreateJob(userID, create)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// fetch returns the HTML of the page at u and its URL after redirects.
+func (s *URLImportService) fetch(u *url.URL) ([]byte, *url.URL, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", s.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "" && !strings.Contains(mediaType, "html") {
+		return nil, nil, fmt.Errorf("not an HTML page (%s)", mediaType)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxJobPageSize))
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, resp.Request.URL, nil
+}
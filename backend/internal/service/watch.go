@@ -0,0 +1,235 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"job-tracker-backend/internal/domain"
+	"job-tracker-backend/internal/repository"
+	appErrors "job-tracker-backend/pkg/errors"
+)
+
+type WatchService struct {
+	watches       *repository.WatchRepository
+	notifications *repository.NotificationRepository
+	jobs          *JobService
+	ats           *ATSClient
+}
+
+func NewWatchService(watches *repository.WatchRepository, notifications *repository.NotificationRepository, jobs *JobService, ats *ATSClient) *WatchService {
+	return &WatchService{watches: watches, notifications: notifications, jobs: jobs, ats: ats}
+}
+
+// WatchPollResult reports what a poll of a company watch found.
+type WatchPollResult struct {
+	Watch       *domain.CompanyWatch `json:"watch"`
+	NewOpenings int                  `json:"new_openings"`
+	SavedJobs   int                  `json:"saved_jobs"`
+}
+
+func (s *WatchService) ListWatches(userID string) ([]domain.CompanyWatch, error) {
+	return s.watches.GetAll(userID)
+}
+
+// CreateWatch starts watching a company's board. The board is polled right
+// away to record the postings already open, which don't raise
+// notifications; a failure to reach it is kept in LastError and retried on
+// the next poll.
+func (s *WatchService) CreateWatch(userID string, input *domain.CompanyWatchInput) (*domain.CompanyWatch, error) {
+	ats := strings.ToLower(strings.TrimSpace(input.ATS))
+	boardID := strings.TrimSpace(input.BoardID)
+	if err := ValidateBoard(ats, boardID); err != nil {
+		return nil, fmt.Errorf("%w: %v", appErrors.ErrInvalidInput, err)
+	}
+	exists, err := s.watches.ExistsForBoard(userID, ats, boardID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, appErrors.ErrAlreadyExists
+	}
+
+	watch := &domain.CompanyWatch{
+		UserID:   userID,
+		Company:  firstNonEmpty(input.Company, boardID),
+		ATS:      ats,
+		BoardID:  boardID,
+		AutoSave: input.AutoSave,
+	}
+	if err := s.watches.Create(watch); err != nil {
+		return nil, err
+	}
+	postings, fetchErr := s.ats.FetchBoard(ats, boardID)
+	if _, err := s.record(watch, postings, fetchErr); err != nil {
+		return nil, err
+	}
+	return watch, nil
+}
+
+// UpdateWatch renames a watch or toggles auto-saving. Watching another board
+// takes a new watch.
+func (s *WatchService) UpdateWatch(userID, id string, input *domain.CompanyWatchInput) (*domain.CompanyWatch, error) {
+	watch, err := s.watches.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if company := strings.TrimSpace(input.Company); company != "" {
+		watch.Company = company
+	}
+	watch.AutoSave = input.AutoSave
+	if err := s.watches.Update(watch); err != nil {
+		return nil, err
+	}
+	return watch, nil
+}
+
+func (s *WatchService) DeleteWatch(userID, id string) error {
+	return s.watches.Delete(id, userID)
+}
+
+// PollWatch polls one of the user's watches now.
+func (s *WatchService) PollWatch(userID, id string) (*WatchPollResult, error) {
+	watch, err := s.watches.GetByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	postings, fetchErr := s.ats.FetchBoard(watch.ATS, watch.BoardID)
+	return s.record(watch, postings, fetchErr)
+}
+
+// PollDue polls every watch not polled successfully within maxAge. Boards
+// watched by several users are fetched once. It returns the number of new
+// openings found.
+func (s *WatchService) PollDue(maxAge time.Duration) (int, error) {
+	watches, err := s.watches.GetDue(time.Now().Add(-maxAge))
+	if err != nil {
+		return 0, err
+	}
+	type board struct {
+		postings []Posting
+		err      error
+	}
+	boards := make(map[string]*board)
+	found := 0
+	for i := range watches {
+		watch := &watches[i]
+		key := watch.ATS + "/" + watch.BoardID
+		b, ok := boards[key]
+		if !ok {
+			b = &board{}
+			b.postings, b.err = s.ats.FetchBoard(watch.ATS, watch.BoardID)
+			boards[key] = b
+		}
+		result, err := s.record(watch, b.postings, b.err)
+		if err != nil {
+			log.Printf("failed to record poll of watch %s: %v", watch.ID, err)
+			continue
+		}
+		found += result.NewOpenings
+	}
+	return found, nil
+}
+
+// PollEvery runs PollDue now and then every interval, forever. Run it in its
+// own goroutine.
+func (s *WatchService) PollEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// Half the interval, so a watch polled during the previous run is
+		// due again even if that run finished late.
+		if n, err := s.PollDue(interval / 2); err != nil {
+			log.Printf("failed to poll company watches: %v", err)
+		} else if n > 0 {
+			log.Printf("Found %d new openings at watched companies", n)
+		}
+		<-ticker.C
+	}
+}
+
+// record compares a poll's postings with those the watch has seen. Unseen
+// postings raise a notification and, with AutoSave, become jobs; on the
+// first successful poll they are only recorded. A failed fetch is kept in
+// LastError, leaving the watch due for another poll.
+func (s *WatchService) record(watch *domain.CompanyWatch, postings []Posting, fetchErr error) (*WatchPollResult, error) {
+	result := &WatchPollResult{Watch: watch}
+	if fetchErr != nil {
+		watch.LastError = fetchErr.Error()
+		return result, s.watches.Update(watch)
+	}
+
+	seen, err := s.watches.GetSeenKeys(watch.ID)
+	if err != nil {
+		return nil, err
+	}
+	baseline := watch.LastPolledAt == nil
+	now := time.Now()
+	var newKeys []string
+	for _, p := range postings {
+		key := watchedPostingKey(p)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		newKeys = append(newKeys, key)
+		if baseline {
+			continue
+		}
+
+		notification := &domain.Notification{
+			UserID:  watch.UserID,
+			Kind:    domain.NotificationNewOpening,
+			Title:   p.Title,
+			Company: watch.Company,
+			URL:     p.URL,
+			WatchID: &watch.ID,
+		}
+		if watch.AutoSave && p.URL != "" {
+			job, err := s.jobs.CreateJob(watch.UserID, &domain.JobCreateInput{
+				JobTitle:    p.Title,
+				CompanyName: watch.Company,
+				Location:    p.Location,
+				JobURL:      p.URL,
+				Description: p.Description,
+				Salary:      p.Salary,
+				JobType:     p.JobType,
+				IsRemote:    p.IsRemote,
+				Source:      watch.ATS,
+			})
+			switch {
+			case err == nil:
+				notification.JobID = &job.ID
+				result.SavedJobs++
+			case !errors.Is(err, appErrors.ErrAlreadyExists):
+				log.Printf("failed to save opening %s for watch %s: %v", p.URL, watch.ID, err)
+			}
+		}
+		if err := s.notifications.Create(notification); err != nil {
+			return nil, err
+		}
+		result.NewOpenings++
+	}
+
+	if err := s.watches.AddSeen(watch.ID, newKeys, now); err != nil {
+		return nil, err
+	}
+	watch.LastPolledAt = &now
+	watch.LastError = ""
+	watch.OpenPostings = len(postings)
+	return result, s.watches.Update(watch)
+}
+
+// watchedPostingKey identifies a posting across polls by its URL, or by its
+// title when it has none.
+func watchedPostingKey(p Posting) string {
+	if p.URL != "" {
+		return canonicalJobURL(p.URL)
+	}
+	if title := strings.TrimSpace(p.Title); title != "" {
+		return "title:" + strings.ToLower(title)
+	}
+	return ""
+}
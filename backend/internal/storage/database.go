@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"job-tracker-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DatabaseStore keeps blobs in the blobs table. Attachments uploaded before
+// blobs were split out still have their bytes in attachments.data, which is
+// read as a fallback and cleared on Delete.
+type DatabaseStore struct {
+	db *gorm.DB
+}
+
+func NewDatabaseStore(db *gorm.DB) *DatabaseStore {
+	return &DatabaseStore{db: db}
+}
+
+func (s *DatabaseStore) Name() string { return BackendDatabase }
+
+func (s *DatabaseStore) Put(key string, r io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return fmt.Errorf("blob %s: expected %d bytes, got %d", key, size, len(data))
+	}
+	blob := &domain.Blob{Key: key, Data: data}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data"}),
+	}).Create(blob).Error
+}
+
+func (s *DatabaseStore) Open(key string) (io.ReadSeekCloser, error) {
+	var blob domain.Blob
+	err := s.db.First(&blob, "key = ?", key).Error
+	if err == nil {
+		return nopSeekCloser{bytes.NewReader(blob.Data)}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var attachment domain.Attachment
+	err = s.db.Select("id", "data").Where("data IS NOT NULL").First(&attachment, "id = ?", key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return nopSeekCloser{bytes.NewReader(attachment.Data)}, nil
+}
+
+func (s *DatabaseStore) Delete(key string) error {
+	if err := s.db.Delete(&domain.Blob{}, "key = ?", key).Error; err != nil {
+		return err
+	}
+	return s.db.Model(&domain.Attachment{}).Where("id = ? AND data IS NOT NULL", key).Update("data", nil).Error
+}
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
@@ -0,0 +1,106 @@
+// Package storage holds attachment contents outside of the attachment
+// metadata rows. Each attachment records the name of the BlobStore that holds
+// its bytes, so stores can be switched without rewriting existing data.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"job-tracker-backend/internal/config"
+
+	"gorm.io/gorm"
+)
+
+const (
+	BackendDatabase   = "database"
+	BackendFilesystem = "filesystem"
+	BackendS3         = "s3"
+)
+
+var (
+	ErrNotFound       = errors.New("blob not found")
+	ErrInvalidKey     = errors.New("invalid blob key")
+	ErrUnknownBackend = errors.New("unknown storage backend")
+)
+
+// BlobStore stores opaque blobs by key. Keys are attachment IDs.
+type BlobStore interface {
+	// Name is the backend identifier recorded on attachments.
+	Name() string
+	// Put stores size bytes read from r under key, replacing any existing blob.
+	Put(key string, r io.Reader, size int64, contentType string) error
+	// Open returns a seekable reader over the blob.
+	Open(key string) (io.ReadSeekCloser, error)
+	Delete(key string) error
+}
+
+// Manager resolves stores by name and designates the one new blobs go to.
+type Manager struct {
+	stores  map[string]BlobStore
+	primary string
+}
+
+func NewManager(primary string, stores ...BlobStore) (*Manager, error) {
+	m := &Manager{stores: make(map[string]BlobStore), primary: primary}
+	for _, s := range stores {
+		m.stores[s.Name()] = s
+	}
+	if _, ok := m.stores[primary]; !ok {
+		return nil, fmt.Errorf("%w: %q is not configured", ErrUnknownBackend, primary)
+	}
+	return m, nil
+}
+
+// NewManagerFromConfig registers the database store plus any store that has
+// enough configuration to be used, and makes cfg.StorageBackend primary.
+func NewManagerFromConfig(cfg *config.Config, db *gorm.DB) (*Manager, error) {
+	stores := []BlobStore{NewDatabaseStore(db)}
+	if cfg.StorageFSRoot != "" {
+		fs, err := NewFilesystemStore(cfg.StorageFSRoot)
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, fs)
+	}
+	if cfg.S3Bucket != "" {
+		s3, err := NewS3Store(S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			Prefix:          cfg.S3Prefix,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			UsePathStyle:    cfg.S3UsePathStyle,
+		})
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, s3)
+	}
+	return NewManager(cfg.StorageBackend, stores...)
+}
+
+// Primary returns the store new blobs are written to.
+func (m *Manager) Primary() BlobStore {
+	return m.stores[m.primary]
+}
+
+// Store returns the store registered under name.
+func (m *Manager) Store(name string) (BlobStore, error) {
+	s, ok := m.stores[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBackend, name)
+	}
+	return s, nil
+}
+
+// validateKey rejects keys that could escape a directory or bucket prefix.
+func validateKey(key string) error {
+	if key == "" || strings.ContainsAny(key, `/\`) || strings.Contains(key, "..") {
+		return ErrInvalidKey
+	}
+	return nil
+}
@@ -8,4 +8,5 @@ var (
 	ErrNotFound      = errors.New("record not found")
 	ErrAlreadyExists = errors.New("record already exists")
 	ErrInvalidInput  = errors.New("invalid input")
+	ErrInUse         = errors.New("record is in use")
 )
@@ -0,0 +1,208 @@
+// Package filetype identifies uploaded documents from their contents rather
+// than from the name or Content-Type the client sent, and rejects files that
+// are encrypted or structurally broken.
+package filetype
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+const (
+	PDF  = "application/pdf"
+	DOC  = "application/msword"
+	DOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+)
+
+var (
+	ErrUnrecognized = errors.New("unrecognized file format")
+	ErrMalformed    = errors.New("malformed file")
+	ErrEncrypted    = errors.New("file is encrypted or password protected")
+)
+
+var (
+	pdfMagic = []byte("%PDF-")
+	cfbMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+	zipMagic = []byte("PK\x03\x04")
+)
+
+// pdfHeaderWindow is how far into the file the %PDF- header may start;
+// readers tolerate a little leading garbage and so do we.
+const pdfHeaderWindow = 1024
+
+// Detect returns the MIME type of a PDF, DOC or DOCX file, checking that its
+// structure is sound and that it isn't encrypted.
+func Detect(r io.ReaderAt, size int64) (string, error) {
+	head := make([]byte, pdfHeaderWindow)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, cfbMagic):
+		return DOC, checkCFB(r, size)
+	case bytes.HasPrefix(head, zipMagic):
+		return DOCX, checkDOCX(r, size)
+	case bytes.Contains(head, pdfMagic):
+		return PDF, checkPDF(r, size)
+	}
+	return "", ErrUnrecognized
+}
+
+// checkPDF requires an end-of-file marker near the end and rejects files
+// that reference an encryption dictionary.
+func checkPDF(r io.ReaderAt, size int64) error {
+	tail := make([]byte, min(size, 2048))
+	if _, err := r.ReadAt(tail, size-int64(len(tail))); err != nil && err != io.EOF {
+		return err
+	}
+	if !bytes.Contains(tail, []byte("%%EOF")) {
+		return fmt.Errorf("%w: PDF has no end-of-file marker", ErrMalformed)
+	}
+	if !bytes.Contains(tail, []byte("startxref")) {
+		return fmt.Errorf("%w: PDF has no cross-reference table", ErrMalformed)
+	}
+
+	found, err := contains(io.NewSectionReader(r, 0, size), []byte("/Encrypt"))
+	if err != nil {
+		return err
+	}
+	if found {
+		return ErrEncrypted
+	}
+	return nil
+}
+
+// contains reports whether needle occurs in r, reading in chunks that
+// overlap by len(needle)-1 bytes.
+func contains(r io.Reader, needle []byte) (bool, error) {
+	buf := make([]byte, 64*1024)
+	keep := 0
+	for {
+		n, err := r.Read(buf[keep:])
+		total := keep + n
+		if bytes.Contains(buf[:total], needle) {
+			return true, nil
+		}
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		keep = min(len(needle)-1, total)
+		copy(buf, buf[total-keep:total])
+	}
+}
+
+// checkDOCX requires the parts every WordprocessingML package has.
+func checkDOCX(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	var contentTypes, document bool
+	for _, f := range zr.File {
+		switch f.Name {
+		case "[Content_Types].xml":
+			contentTypes = true
+		case "word/document.xml":
+			document = true
+		}
+	}
+	if !contentTypes || !document {
+		return fmt.Errorf("%w: not a Word document", ErrUnrecognized)
+	}
+	return nil
+}
+
+// checkCFB walks the directory of an OLE compound file (the container used by
+// .doc) looking for the WordDocument stream. Password-protected .docx files
+// are also compound files, holding an EncryptedPackage stream instead.
+func checkCFB(r io.ReaderAt, size int64) error {
+	names, err := cfbStreamNames(r, size)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if names["EncryptedPackage"] {
+		return ErrEncrypted
+	}
+	if !names["WordDocument"] {
+		return fmt.Errorf("%w: not a Word document", ErrUnrecognized)
+	}
+	return nil
+}
+
+const (
+	cfbEndOfChain   = 0xFFFFFFFE
+	cfbFreeSect     = 0xFFFFFFFF
+	cfbDirEntrySize = 128
+	cfbMaxSectors   = 1 << 16
+)
+
+func cfbStreamNames(r io.ReaderAt, size int64) (map[string]bool, error) {
+	header := make([]byte, 512)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, errors.New("truncated header")
+	}
+	shift := binary.LittleEndian.Uint16(header[30:])
+	if shift != 9 && shift != 12 {
+		return nil, fmt.Errorf("invalid sector size 2^%d", shift)
+	}
+	sectorSize := int64(1) << shift
+	firstDirSector := binary.LittleEndian.Uint32(header[48:])
+
+	// The first 109 FAT sector locations live in the header; that covers
+	// files of several megabytes with 512-byte sectors and far more with
+	// 4096-byte ones. Larger files chain further DIFAT sectors we don't read.
+	var fat []uint32
+	for i := 0; i < 109; i++ {
+		sect := binary.LittleEndian.Uint32(header[76+4*i:])
+		if sect == cfbFreeSect || sect == cfbEndOfChain {
+			break
+		}
+		buf := make([]byte, sectorSize)
+		if _, err := r.ReadAt(buf, (int64(sect)+1)*sectorSize); err != nil {
+			return nil, errors.New("truncated FAT")
+		}
+		for j := int64(0); j < sectorSize; j += 4 {
+			fat = append(fat, binary.LittleEndian.Uint32(buf[j:]))
+		}
+	}
+
+	names := make(map[string]bool)
+	buf := make([]byte, sectorSize)
+	sect := firstDirSector
+	for visited := 0; sect != cfbEndOfChain; visited++ {
+		if visited > cfbMaxSectors || (int64(sect)+2)*sectorSize > size {
+			return nil, errors.New("invalid directory chain")
+		}
+		if _, err := r.ReadAt(buf, (int64(sect)+1)*sectorSize); err != nil {
+			return nil, errors.New("truncated directory")
+		}
+		for off := int64(0); off+cfbDirEntrySize <= sectorSize; off += cfbDirEntrySize {
+			entry := buf[off : off+cfbDirEntrySize]
+			nameLen := int(binary.LittleEndian.Uint16(entry[64:]))
+			if nameLen < 2 || nameLen > 64 {
+				continue
+			}
+			u := make([]uint16, nameLen/2-1) // drop the terminating NUL
+			for i := range u {
+				u[i] = binary.LittleEndian.Uint16(entry[2*i:])
+			}
+			names[string(utf16.Decode(u))] = true
+		}
+		if int(sect) >= len(fat) {
+			break
+		}
+		sect = fat[sect]
+	}
+	return names, nil
+}
@@ -0,0 +1,155 @@
+// Package ical writes minimal RFC 5545 iCalendar documents.
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	dateTimeFormat = "20060102T150405Z"
+	dateFormat     = "20060102"
+	maxLineOctets  = 75
+)
+
+type Alarm struct {
+	Before      time.Duration
+	Description string
+}
+
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Categories  []string
+	Alarm       *Alarm
+}
+
+type Calendar struct {
+	ProdID string
+	Name   string
+	Events []Event
+}
+
+// WriteTo serializes the calendar with CRLF line endings and folded lines.
+func (c *Calendar) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	stamp := time.Now().UTC().Format(dateTimeFormat)
+
+	writeLine(&buf, "BEGIN:VCALENDAR")
+	writeLine(&buf, "VERSION:2.0")
+	writeLine(&buf, "PRODID:"+escapeText(c.ProdID))
+	writeLine(&buf, "CALSCALE:GREGORIAN")
+	writeLine(&buf, "METHOD:PUBLISH")
+	if c.Name != "" {
+		writeLine(&buf, "X-WR-CALNAME:"+escapeText(c.Name))
+	}
+
+	for _, e := range c.Events {
+		writeLine(&buf, "BEGIN:VEVENT")
+		writeLine(&buf, "UID:"+escapeText(e.UID))
+		writeLine(&buf, "DTSTAMP:"+stamp)
+		if e.AllDay {
+			// Dates are taken in UTC, so the day doesn't depend on the zone
+			// the time was read in
+			start, end := e.Start.UTC(), e.End.UTC()
+			if !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			writeLine(&buf, "DTSTART;VALUE=DATE:"+start.Format(dateFormat))
+			writeLine(&buf, "DTEND;VALUE=DATE:"+end.Format(dateFormat))
+		} else {
+			writeLine(&buf, "DTSTART:"+e.Start.UTC().Format(dateTimeFormat))
+			if !e.End.IsZero() {
+				writeLine(&buf, "DTEND:"+e.End.UTC().Format(dateTimeFormat))
+			}
+		}
+		writeLine(&buf, "SUMMARY:"+escapeText(e.Summary))
+		if e.Description != "" {
+			writeLine(&buf, "DESCRIPTION:"+escapeText(e.Description))
+		}
+		if e.Location != "" {
+			writeLine(&buf, "LOCATION:"+escapeText(e.Location))
+		}
+		if u, ok := webURL(e.URL); ok {
+			writeLine(&buf, "URL:"+u)
+		}
+		if len(e.Categories) > 0 {
+			escaped := make([]string, len(e.Categories))
+			for i, cat := range e.Categories {
+				escaped[i] = escapeText(cat)
+			}
+			writeLine(&buf, "CATEGORIES:"+strings.Join(escaped, ","))
+		}
+		if e.Alarm != nil {
+			writeLine(&buf, "BEGIN:VALARM")
+			writeLine(&buf, "ACTION:DISPLAY")
+			writeLine(&buf, "DESCRIPTION:"+escapeText(e.Alarm.Description))
+			writeLine(&buf, fmt.Sprintf("TRIGGER:-PT%dM", int(e.Alarm.Before.Minutes())))
+			writeLine(&buf, "END:VALARM")
+		}
+		writeLine(&buf, "END:VEVENT")
+	}
+
+	writeLine(&buf, "END:VCALENDAR")
+	return buf.WriteTo(w)
+}
+
+// escapeText escapes a TEXT value per RFC 5545 section 3.3.11.
+func escapeText(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	)
+	return r.Replace(s)
+}
+
+// webURL returns s re-encoded if it is an absolute http(s) URL. URI values
+// aren't escaped like TEXT, so anything else, such as a URL carrying line
+// breaks, is left out rather than written into the feed.
+func webURL(s string) (string, bool) {
+	if s == "" {
+		return "", false
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", false
+	}
+	return u.String(), true
+}
+
+// writeLine folds content lines longer than 75 octets without splitting
+// UTF-8 sequences, as required by RFC 5545 section 3.1.
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// continuation lines lose one octet to the leading space
+		limit = maxLineOctets - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,57 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteToURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string // the URL line, or empty for none
+	}{
+		{name: "https", url: "https://jobs.example/posting?id=1", want: "URL:https://jobs.example/posting?id=1"},
+		{name: "spaces encoded", url: "http://jobs.example/a b", want: "URL:http://jobs.example/a%20b"},
+		{name: "line breaks", url: "https://jobs.example/\r\nEND:VEVENT\r\nBEGIN:VEVENT\r\nSUMMARY:Injected"},
+		{name: "line feed", url: "https://jobs.example/\nATTACH:https://evil.example"},
+		{name: "javascript", url: "javascript:alert(1)"},
+		{name: "relative", url: "/jobs/1"},
+		{name: "empty", url: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cal := &Calendar{ProdID: "-//test//EN", Events: []Event{{
+				UID:     "1@test",
+				Summary: "Interview",
+				URL:     tt.url,
+				Start:   time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC),
+			}}}
+			var buf bytes.Buffer
+			if _, err := cal.WriteTo(&buf); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			var urlLines []string
+			for _, line := range strings.Split(out, "\r\n") {
+				if strings.HasPrefix(line, "URL:") {
+					urlLines = append(urlLines, line)
+				}
+			}
+			switch {
+			case tt.want == "" && len(urlLines) > 0:
+				t.Errorf("URL lines = %q, want none", urlLines)
+			case tt.want != "" && (len(urlLines) != 1 || urlLines[0] != tt.want):
+				t.Errorf("URL lines = %q, want %q", urlLines, tt.want)
+			}
+			if n := strings.Count(out, "BEGIN:VEVENT"); n != 1 {
+				t.Errorf("feed has %d events, want 1:\n%s", n, out)
+			}
+			if strings.Count(out, "\n") != strings.Count(out, "\r\n") {
+				t.Errorf("feed has a bare line break:\n%q", out)
+			}
+		})
+	}
+}
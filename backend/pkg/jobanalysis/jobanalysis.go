@@ -0,0 +1,267 @@
+// Package jobanalysis reads a job posting and extracts the requirements a
+// candidate would screen it by: skills, years of experience, seniority,
+// visa sponsorship, citizenship and clearance, and education.
+package jobanalysis
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"job-tracker-backend/pkg/textmatch"
+)
+
+// Seniority levels, from least to most senior.
+const (
+	SeniorityIntern    = "intern"
+	SeniorityJunior    = "junior"
+	SeniorityMid       = "mid"
+	SenioritySenior    = "senior"
+	SeniorityLead      = "lead"
+	SeniorityStaff     = "staff"
+	SeniorityPrincipal = "principal"
+)
+
+// Visa sponsorship stances. An empty Sponsorship means the posting doesn't say.
+const (
+	SponsorshipAvailable   = "available"
+	SponsorshipUnavailable = "unavailable"
+)
+
+// Clearance levels. ClearanceRequired is used when the posting asks for a
+// clearance without naming the level.
+const (
+	ClearancePublicTrust = "public_trust"
+	ClearanceSecret      = "secret"
+	ClearanceTopSecret   = "top_secret"
+	ClearanceTSSCI       = "ts_sci"
+	ClearanceRequired    = "required"
+)
+
+// Education levels, from lowest to highest.
+const (
+	EducationHighSchool = "high_school"
+	EducationAssociate  = "associate"
+	EducationBachelor   = "bachelor"
+	EducationMaster     = "master"
+	EducationDoctorate  = "doctorate"
+)
+
+// maxYears bounds the experience figures taken from a posting; larger numbers
+// are company history ("30 years of innovation"), not requirements.
+const maxYears = 20
+
+// Analysis is what a posting asks of a candidate. Fields the posting doesn't
+// mention are left at their zero value.
+type Analysis struct {
+	Skills []string
+	// MinYears is the largest minimum experience the posting requires,
+	// ignoring figures in preferred or nice-to-have sentences.
+	MinYears            *int
+	Seniority           string
+	Sponsorship         string
+	CitizenshipRequired bool
+	Clearance           string
+	// Education is the lowest degree the posting accepts.
+	Education string
+}
+
+// Analyze extracts the requirements from a job's title and description. The
+// description may be plain text or HTML.
+func Analyze(title, description string) *Analysis {
+	text := PlainText(description)
+	a := &Analysis{
+		Skills:              textmatch.ExtractSkills(text),
+		MinYears:            minYears(text),
+		Sponsorship:         sponsorship(text),
+		CitizenshipRequired: citizenshipPattern.MatchString(text),
+		Clearance:           clearance(text),
+		Education:           education(text),
+	}
+	a.Seniority = seniority(title)
+	if a.Seniority == "" {
+		a.Seniority = seniorityFromYears(a.MinYears)
+	}
+	return a
+}
+
+var (
+	blockTagPattern = regexp.MustCompile(`(?i)<\s*/?\s*(?:p|div|br|li|ul|ol|h[1-6]|tr|td|th|table|section|article|header|footer|blockquote|pre)\b[^>]*>`)
+	tagPattern      = regexp.MustCompile(`<[^>]*>`)
+	scriptPattern   = regexp.MustCompile(`(?is)<(script|style)\b.*?</(?:script|style)\s*>`)
+	blankPattern    = regexp.MustCompile(`[ \t\r\f\v\x{a0}]+`)
+)
+
+// PlainText turns an HTML fragment into text, with block elements on their
+// own lines. Plain text passes through with only whitespace normalised.
+func PlainText(s string) string {
+	if strings.Contains(s, "<") {
+		s = scriptPattern.ReplaceAllString(s, " ")
+		s = blockTagPattern.ReplaceAllString(s, "\n")
+		s = tagPattern.ReplaceAllString(s, " ")
+	}
+	s = html.UnescapeString(s)
+	s = blankPattern.ReplaceAllString(s, " ")
+	return strings.TrimSpace(s)
+}
+
+var (
+	// yearsPattern matches "5+ years", "3-5 yrs", "at least 7 years" and
+	// "five years"; group 1 is the lower bound.
+	yearsPattern = regexp.MustCompile(`(?i)\b(\d{1,2}|one|two|three|four|five|six|seven|eight|nine|ten|twelve|fifteen)\s*(?:\+|plus)?\s*(?:(?:-|–|to|or)\s*(?:\d{1,2}|one|two|three|four|five|six|seven|eight|nine|ten|twelve|fifteen)\s*\+?\s*)?(?:years?|yrs?)\b`)
+	// experiencePattern must appear near a years figure for it to count.
+	experiencePattern = regexp.MustCompile(`(?i)\b(?:experience|exp|experienced|professional|industry|hands-on|track record)\b`)
+	// optionalPattern marks sentences describing a bonus rather than a
+	// requirement.
+	optionalPattern = regexp.MustCompile(`(?i)\b(?:preferred|nice to have|nice-to-have|bonus|a plus|is a plus|ideally|desirable|advantageous)\b`)
+	sentenceSplit   = regexp.MustCompile(`[\n;•·]|\.\s`)
+)
+
+var numberWords = map[string]int{
+	"one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7,
+	"eight": 8, "nine": 9, "ten": 10, "twelve": 12, "fifteen": 15,
+}
+
+// experienceWindow is how many characters either side of a years figure are
+// searched for experiencePattern.
+const experienceWindow = 60
+
+func minYears(text string) *int {
+	var best *int
+	for _, sentence := range sentenceSplit.Split(text, -1) {
+		if optionalPattern.MatchString(sentence) {
+			continue
+		}
+		for _, m := range yearsPattern.FindAllStringSubmatchIndex(sentence, -1) {
+			context := sentence[max(0, m[0]-experienceWindow):min(len(sentence), m[1]+experienceWindow)]
+			if !experiencePattern.MatchString(context) {
+				continue
+			}
+			word := strings.ToLower(sentence[m[2]:m[3]])
+			n, ok := numberWords[word]
+			if !ok {
+				n, _ = strconv.Atoi(word)
+			}
+			if n <= 0 || n > maxYears {
+				continue
+			}
+			if best == nil || n > *best {
+				best = &n
+			}
+		}
+	}
+	return best
+}
+
+// seniorityTitles are checked in order, so "Senior Staff Engineer" is staff
+// and "Lead Senior Developer" is lead.
+var seniorityTitles = []struct {
+	level   string
+	pattern *regexp.Regexp
+}{
+	{SeniorityIntern, regexp.MustCompile(`(?i)\b(?:intern|internship|co-op|trainee|apprentice)\b`)},
+	{SeniorityPrincipal, regexp.MustCompile(`(?i)\b(?:principal|distinguished|fellow)\b`)},
+	{SeniorityStaff, regexp.MustCompile(`(?i)\bstaff\b`)},
+	{SeniorityLead, regexp.MustCompile(`(?i)\b(?:lead|head of|team lead|tech lead|engineering manager)\b`)},
+	{SenioritySenior, regexp.MustCompile(`(?i)\b(?:senior|sr\.?|snr)\b|\b(?:iii|iv)\b`)},
+	{SeniorityJunior, regexp.MustCompile(`(?i)\b(?:junior|jr\.?|entry[- ]level|graduate|new grad|associate)\b|\bi\b`)},
+	{SeniorityMid, regexp.MustCompile(`(?i)\b(?:mid[- ]level|mid[- ]senior|intermediate)\b|\bii\b`)},
+}
+
+func seniority(title string) string {
+	for _, s := range seniorityTitles {
+		if s.pattern.MatchString(title) {
+			return s.level
+		}
+	}
+	return ""
+}
+
+// seniorityFromYears guesses the level of a posting whose title doesn't name
+// one from the experience it asks for.
+func seniorityFromYears(years *int) string {
+	switch {
+	case years == nil:
+		return ""
+	case *years < 2:
+		return SeniorityJunior
+	case *years < 5:
+		return SeniorityMid
+	default:
+		return SenioritySenior
+	}
+}
+
+var (
+	noSponsorshipPattern = regexp.MustCompile(`(?i)\b(?:no|not|unable to|cannot|can't|can not|won't|will not|do not|does not|don't|doesn't|are not able to|is not able to)\b[^.\n]{0,40}\b(?:sponsor|sponsorship|sponsoring|h-?1b)\b` +
+		`|\bwithout\b[^.\n]{0,40}\bsponsorship\b` +
+		`|\bsponsorship\b[^.\n]{0,30}\b(?:not|un)\s*(?:available|offered|provided|possible)\b`)
+	sponsorshipPattern = regexp.MustCompile(`(?i)\b(?:visa|h-?1b|work permit|immigration)\s+sponsorship\b[^.\n]{0,30}\b(?:available|offered|provided|possible)\b` +
+		`|\b(?:will|can|able to|happy to|we)\s+(?:also\s+)?sponsor\b` +
+		`|\bsponsorship\s+(?:is\s+)?(?:available|offered|provided|possible)\b` +
+		`|\b(?:offer|provide)s?\s+(?:visa\s+)?sponsorship\b`)
+)
+
+// sponsorship checks refusals first, since "we will not sponsor" also reads
+// as an offer to a looser pattern.
+func sponsorship(text string) string {
+	switch {
+	case noSponsorshipPattern.MatchString(text):
+		return SponsorshipUnavailable
+	case sponsorshipPattern.MatchString(text):
+		return SponsorshipAvailable
+	default:
+		return ""
+	}
+}
+
+var citizenshipPattern = regexp.MustCompile(`(?i)\b(?:u\.?\s?s\.?|united states|american)\s+(?:citizen|citizens|citizenship|nationals?)\b` +
+	`|\bcitizens?\s+only\b` +
+	`|\bmust\s+be\s+a\s+citizen\b` +
+	`|\b(?:u\.?\s?s\.?)\s+persons?\s+(?:only|status)\b` +
+	`|\bitar\b`)
+
+var clearancePatterns = []struct {
+	level   string
+	pattern *regexp.Regexp
+}{
+	{ClearanceTSSCI, regexp.MustCompile(`(?i)\bts\s*/\s*sci\b|\btop secret\s*/\s*sci\b|\bsci\s+(?:clearance|eligib)`)},
+	{ClearanceTopSecret, regexp.MustCompile(`(?i)\btop[- ]secret\b|\bTS\s+clearance\b`)},
+	{ClearanceSecret, regexp.MustCompile(`(?i)\bsecret\s+(?:security\s+)?clearance\b|\bclearance\s+(?:level\s+)?(?:of\s+)?secret\b`)},
+	{ClearancePublicTrust, regexp.MustCompile(`(?i)\bpublic\s+trust\b`)},
+	{ClearanceRequired, regexp.MustCompile(`(?i)\b(?:security|government|federal|active|current|dod)\s+clearance\b|\bclearance\s+(?:is\s+)?required\b|\b(?:obtain|maintain|hold)\s+(?:a\s+|an\s+)?clearance\b`)},
+}
+
+func clearance(text string) string {
+	for _, c := range clearancePatterns {
+		if c.pattern.MatchString(text) {
+			return c.level
+		}
+	}
+	return ""
+}
+
+// educationPatterns are listed from lowest to highest; the first match is
+// the minimum the posting accepts. Degree abbreviations are case-sensitive and
+// must be followed by "in", "degree", "or" or a slash, so "MS Office" and
+// "Boston, MA" don't count.
+var educationPatterns = []struct {
+	level   string
+	pattern *regexp.Regexp
+}{
+	{EducationHighSchool, regexp.MustCompile(`(?i)\bhigh school\b|\bGED\b|\bsecondary school\b`)},
+	{EducationAssociate, regexp.MustCompile(`(?i)\bassociate'?s?\s+degree\b|\bassociate of\b`)},
+	{EducationBachelor, regexp.MustCompile(`(?i)\bbachelor'?s?\b|\bundergraduate degree\b|\b(?:four|4)[- ]year degree\b|\b(?:college|university) degree\b|(?-i:\b(?:BS|BA|BSc|B\.S\.|B\.A\.|B\.Sc\.|BEng|BTech)(?:\s+(?:in|degree|or)\b|\s*/))`)},
+	{EducationMaster, regexp.MustCompile(`(?i)\bmaster'?s\s+(?:degree|in|of)\b|\bmasters?\s+degree\b|\bmaster of\b|\bgraduate degree\b|\bMBA\b|(?-i:\b(?:MS|MSc|M\.S\.|M\.Sc\.|MEng)(?:\s+(?:in|degree|or)\b|\s*/))`)},
+	{EducationDoctorate, regexp.MustCompile(`(?i)\bph\.?\s?d\b|\bdoctorate\b|\bdoctoral degree\b`)},
+}
+
+func education(text string) string {
+	for _, e := range educationPatterns {
+		if e.pattern.MatchString(text) {
+			return e.level
+		}
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package jobpage
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// LinkedIn titles pages "Acme hiring Go Developer in Berlin, Germany | LinkedIn".
+	linkedInTitlePattern = regexp.MustCompile(`^(.+?) hiring (.+?)(?: in (.+?))?(?: \| LinkedIn)?$`)
+	// Indeed titles pages "Go Developer - Berlin - Indeed.com", sometimes with
+	// the company after the title.
+	indeedTitleSuffix = regexp.MustCompile(`\s*[-|]\s*Indeed(?:\.[a-z.]+)?\s*$`)
+	// Lever titles pages "Acme - Go Developer".
+	leverTitlePattern = regexp.MustCompile(`^(.+?) - (.+)$`)
+	listItemPattern   = regexp.MustCompile(`(?is)<li\b.*?</li\s*>`)
+)
+
+// extractLinkedIn reads a public LinkedIn job view
+// (www.linkedin.com/jobs/view/...).
+func extractLinkedIn(p *page, _ *url.URL) *Posting {
+	posting := &Posting{
+		Title:    p.text("", "class", "top-card-layout__title"),
+		Company:  p.text("a", "class", "topcard__org-name-link"),
+		Location: p.text("span", "class", "topcard__flavor--bullet"),
+	}
+	posting.Description, _ = p.element("div", "class", "show-more-less-html__markup")
+	if m := linkedInTitlePattern.FindStringSubmatch(firstNonEmpty(p.meta("og:title"), p.title())); m != nil {
+		posting.Company = firstNonEmpty(posting.Company, m[1])
+		posting.Title = firstNonEmpty(posting.Title, m[2])
+		posting.Location = firstNonEmpty(posting.Location, m[3])
+	}
+	// The job criteria list pairs headers such as "Employment type" with
+	// their values.
+	for _, item := range listItemPattern.FindAllString(p.html, -1) {
+		criterion := newPage(item)
+		if strings.EqualFold(criterion.text("h3", "class", "description__job-criteria-subheader"), "Employment type") {
+			posting.JobType = criterion.text("span", "class", "description__job-criteria-text")
+		}
+	}
+	posting.IsRemote = isRemoteText(posting.Location)
+	return posting
+}
+
+// extractIndeed reads an Indeed job view (www.indeed.com/viewjob?jk=...).
+func extractIndeed(p *page, _ *url.URL) *Posting {
+	posting := &Posting{
+		Title:    strings.TrimSuffix(p.text("h1", "class", "jobsearch-JobInfoHeader-title"), " - job post"),
+		Company:  p.text("div", "data-company-name", "true"),
+		Location: p.text("div", "data-testid", "inlineHeader-companyLocation"),
+		Salary:   p.text("div", "id", "salaryInfoAndJobType"),
+	}
+	if posting.Company == "" {
+		posting.Company = p.text("div", "data-testid", "inlineHeader-companyName")
+	}
+	posting.Description, _ = p.element("div", "id", "jobDescriptionText")
+	if posting.Title == "" {
+		title := indeedTitleSuffix.ReplaceAllString(firstNonEmpty(p.meta("og:title"), p.title()), "")
+		if parts := strings.Split(title, " - "); len(parts) > 0 {
+			posting.Title = parts[0]
+			if len(parts) > 1 {
+				posting.Location = firstNonEmpty(posting.Location, parts[len(parts)-1])
+			}
+		}
+	}
+	posting.IsRemote = isRemoteText(posting.Location)
+	return posting
+}
+
+// extractGreenhouse reads a hosted Greenhouse posting
+// (boards.greenhouse.io/<board>/jobs/<id>); the board token stands in for
+// the company when the page doesn't name it.
+func extractGreenhouse(p *page, u *url.URL) *Posting {
+	posting := &Posting{
+		Title:    p.text("h1", "class", "app-title"),
+		Company:  strings.TrimPrefix(p.text("span", "class", "company-name"), "at "),
+		Location: p.text("div", "class", "location"),
+	}
+	posting.Description, _ = p.element("div", "id", "content")
+	if posting.Title == "" {
+		posting.Title = p.text("h1", "class", "section-header")
+	}
+	if posting.Company == "" {
+		if segments := pathSegments(u); len(segments) > 1 && segments[1] == "jobs" {
+			posting.Company = segments[0]
+		}
+	}
+	posting.IsRemote = isRemoteText(posting.Location)
+	return posting
+}
+
+// extractLever reads a hosted Lever posting (jobs.lever.co/<company>/<id>).
+func extractLever(p *page, u *url.URL) *Posting {
+	headline, _ := p.element("div", "class", "posting-headline")
+	head := newPage(headline)
+	posting := &Posting{
+		Title:    head.text("h2", "", ""),
+		Location: head.text("div", "class", "location"),
+		JobType:  strings.TrimSuffix(head.text("div", "class", "commitment"), " /"),
+	}
+	if posting.Title == "" {
+		posting.Title = textOf(headline)
+	}
+	if m := leverTitlePattern.FindStringSubmatch(firstNonEmpty(p.meta("og:title"), p.title())); m != nil {
+		posting.Company = m[1]
+		posting.Title = firstNonEmpty(posting.Title, m[2])
+	}
+	if posting.Company == "" {
+		if segments := pathSegments(u); len(segments) > 0 {
+			posting.Company = segments[0]
+		}
+	}
+	// The description comes in sibling sections.
+	var sections []string
+	for _, qa := range []string{"job-description", "job-requirements", "closing-description"} {
+		if section, ok := p.element("div", "data-qa", qa); ok {
+			sections = append(sections, section)
+		}
+	}
+	if len(sections) == 0 {
+		if section, ok := p.element("div", "class", "posting-page"); ok {
+			sections = append(sections, section)
+		}
+	}
+	posting.Description = strings.Join(sections, "\n")
+	posting.IsRemote = isRemoteText(posting.Location) || strings.EqualFold(head.text("div", "class", "workplaceTypes"), "remote")
+	return posting
+}
+
+func pathSegments(u *url.URL) []string {
+	var segments []string
+	for _, s := range strings.Split(u.Path, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
@@ -0,0 +1,134 @@
+package jobpage
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	attrPattern    = regexp.MustCompile(`([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+	metaPattern    = regexp.MustCompile(`(?i)<meta\b[^>]*>`)
+	titlePattern   = regexp.MustCompile(`(?is)<title\b[^>]*>(.*?)</title\s*>`)
+	jsonLDPattern  = regexp.MustCompile(`(?is)<script\b[^>]*type\s*=\s*["']?application/ld\+json["']?[^>]*>(.*?)</script\s*>`)
+	commentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+	tagPattern     = regexp.MustCompile(`<[^>]*>`)
+)
+
+// page is an HTML document searched with the few queries extractors need.
+// Matching is done on the markup directly rather than on a parsed tree,
+// which is enough for the server-rendered pages of job boards.
+type page struct {
+	html string
+}
+
+func newPage(body string) *page {
+	return &page{html: commentPattern.ReplaceAllString(body, "")}
+}
+
+func attributes(tag string) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range attrPattern.FindAllStringSubmatch(tag, -1) {
+		attrs[strings.ToLower(m[1])] = html.UnescapeString(m[2] + m[3] + m[4])
+	}
+	return attrs
+}
+
+// meta returns the content of the first <meta> tag whose property or name
+// is one of keys, trying keys in order.
+func (p *page) meta(keys ...string) string {
+	tags := metaPattern.FindAllString(p.html, -1)
+	for _, key := range keys {
+		for _, tag := range tags {
+			attrs := attributes(tag)
+			if strings.EqualFold(attrs["property"], key) || strings.EqualFold(attrs["name"], key) {
+				if content := strings.TrimSpace(attrs["content"]); content != "" {
+					return content
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func (p *page) title() string {
+	if m := titlePattern.FindStringSubmatch(p.html); m != nil {
+		return collapse(html.UnescapeString(m[1]))
+	}
+	return ""
+}
+
+// jsonLD returns the contents of the page's JSON-LD scripts.
+func (p *page) jsonLD() []string {
+	var scripts []string
+	for _, m := range jsonLDPattern.FindAllStringSubmatch(p.html, -1) {
+		scripts = append(scripts, m[1])
+	}
+	return scripts
+}
+
+// element returns the inner HTML of the first tag element whose attribute
+// attr has value; for "class" value is one of the element's classes. An
+// empty tag matches any element, and an empty attr any attributes.
+func (p *page) element(tag, attr, value string) (string, bool) {
+	name := `[a-zA-Z][a-zA-Z0-9]*`
+	if tag != "" {
+		name = regexp.QuoteMeta(tag)
+	}
+	start := regexp.MustCompile(`(?i)<(` + name + `)\b[^>]*>`)
+	for _, loc := range start.FindAllStringSubmatchIndex(p.html, -1) {
+		attrs := attributes(p.html[loc[0]:loc[1]])
+		got, ok := attrs[attr]
+		switch {
+		case attr == "":
+			ok = true
+		case !ok:
+			continue
+		case attr == "class":
+			ok = false
+			for _, class := range strings.Fields(got) {
+				ok = ok || class == value
+			}
+		default:
+			ok = got == value
+		}
+		if ok {
+			return innerHTML(p.html[loc[1]:], p.html[loc[2]:loc[3]]), true
+		}
+	}
+	return "", false
+}
+
+// text is the text of the first element found as by element, with
+// whitespace collapsed.
+func (p *page) text(tag, attr, value string) string {
+	inner, _ := p.element(tag, attr, value)
+	return textOf(inner)
+}
+
+// innerHTML returns rest up to the end tag closing an element named tag
+// whose start tag precedes rest, or all of rest if it isn't closed.
+func innerHTML(rest, tag string) string {
+	nested := regexp.MustCompile(`(?i)<(/?)` + regexp.QuoteMeta(tag) + `\b[^>]*>`)
+	depth := 1
+	for _, loc := range nested.FindAllStringSubmatchIndex(rest, -1) {
+		if loc[3] > loc[2] {
+			depth--
+		} else if !strings.HasSuffix(rest[loc[0]:loc[1]], "/>") {
+			depth++
+		}
+		if depth == 0 {
+			return rest[:loc[0]]
+		}
+	}
+	return rest
+}
+
+// textOf strips the tags from an HTML fragment.
+func textOf(fragment string) string {
+	return collapse(html.UnescapeString(tagPattern.ReplaceAllString(fragment, " ")))
+}
+
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,134 @@
+// Package jobpage extracts a job posting's fields from its web page. The
+// schema.org JobPosting JSON-LD that most boards embed for search engines is
+// read first; extractors for particular boards fill what it lacks, and the
+// page's OpenGraph tags and title what remains.
+package jobpage
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Extractor names, as reported in Posting.Extractors.
+const (
+	ExtractorJSONLD     = "json-ld"
+	ExtractorOpenGraph  = "opengraph"
+	ExtractorLinkedIn   = "linkedin"
+	ExtractorIndeed     = "indeed"
+	ExtractorGreenhouse = "greenhouse"
+	ExtractorLever      = "lever"
+)
+
+// Posting is what could be read from a job page. Description is HTML, or
+// plain text when the page only had a summary in its meta tags.
+type Posting struct {
+	Title       string
+	Company     string
+	Location    string
+	Description string
+	Salary      string
+	JobType     string
+	IsRemote    bool
+	PostedAt    *time.Time
+	// ValidThrough is when the posting closes, if the page says
+	ValidThrough *time.Time
+	// Board is the job board the page belongs to, if it is a known one
+	Board string
+	// Extractors lists the extractors that contributed a field, in the
+	// order they ran
+	Extractors []string
+}
+
+// boardExtractors read the markup of particular job boards, keyed by the
+// host's registered domain.
+var boardExtractors = map[string]struct {
+	name    string
+	extract func(p *page, u *url.URL) *Posting
+}{
+	"linkedin.com":  {ExtractorLinkedIn, extractLinkedIn},
+	"indeed.com":    {ExtractorIndeed, extractIndeed},
+	"greenhouse.io": {ExtractorGreenhouse, extractGreenhouse},
+	"lever.co":      {ExtractorLever, extractLever},
+}
+
+// Extract reads the posting on the page at pageURL.
+func Extract(pageURL *url.URL, body []byte) *Posting {
+	p := newPage(string(body))
+	posting := &Posting{}
+
+	if found := extractJSONLD(p); found != nil {
+		posting.merge(found, ExtractorJSONLD)
+	}
+	host := strings.ToLower(pageURL.Hostname())
+	for domain, board := range boardExtractors {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			posting.Board = board.name
+			posting.merge(board.extract(p, pageURL), board.name)
+		}
+	}
+	og := extractOpenGraph(p)
+	if posting.Board != "" {
+		// The site name of a board page is the board's.
+		og.Company = ""
+	}
+	posting.merge(og, ExtractorOpenGraph)
+	return posting
+}
+
+// merge fills the fields of p that are still empty from other, recording
+// extractor if it contributed any.
+func (p *Posting) merge(other *Posting, extractor string) {
+	if other == nil {
+		return
+	}
+	used := false
+	fill := func(dst *string, src string) {
+		if src = strings.TrimSpace(src); *dst == "" && src != "" {
+			*dst = src
+			used = true
+		}
+	}
+	fill(&p.Title, other.Title)
+	fill(&p.Company, other.Company)
+	fill(&p.Location, other.Location)
+	fill(&p.Description, other.Description)
+	fill(&p.Salary, other.Salary)
+	fill(&p.JobType, other.JobType)
+	if !p.IsRemote && other.IsRemote {
+		p.IsRemote, used = true, true
+	}
+	if p.PostedAt == nil && other.PostedAt != nil {
+		p.PostedAt, used = other.PostedAt, true
+	}
+	if p.ValidThrough == nil && other.ValidThrough != nil {
+		p.ValidThrough, used = other.ValidThrough, true
+	}
+	if used {
+		p.Extractors = append(p.Extractors, extractor)
+	}
+}
+
+// extractOpenGraph reads the OpenGraph tags, falling back to the standard
+// description meta tag and the document title.
+func extractOpenGraph(p *page) *Posting {
+	return &Posting{
+		Title:       firstNonEmpty(p.meta("og:title", "twitter:title"), p.title()),
+		Company:     p.meta("og:site_name"),
+		Description: p.meta("og:description", "twitter:description", "description"),
+	}
+}
+
+func isRemoteText(s string) bool {
+	s = strings.ToLower(s)
+	return strings.Contains(s, "remote") || strings.Contains(s, "anywhere")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
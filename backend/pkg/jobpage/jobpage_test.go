@@ -0,0 +1,210 @@
+package jobpage
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		url     string
+		want    Posting
+		// description holds substrings the description must contain; it is
+		// compared no further, being the board's markup
+		description []string
+	}{
+		{
+			name:    "linkedin",
+			fixture: "linkedin.html",
+			url:     "https://www.linkedin.com/jobs/view/senior-go-developer-at-acme-corp-3912345678",
+			want: Posting{
+				Title:      "Senior Go Developer",
+				Company:    "Acme Corp",
+				Location:   "Berlin, Berlin, Germany",
+				JobType:    "Full-time",
+				Board:      ExtractorLinkedIn,
+				Extractors: []string{ExtractorLinkedIn},
+			},
+			description: []string{"<strong>Go developer</strong>", "<li>PostgreSQL</li>"},
+		},
+		{
+			name:    "linkedin title only",
+			fixture: "linkedin_minimal.html",
+			url:     "https://de.linkedin.com/jobs/view/3912345679",
+			want: Posting{
+				Title:      "Go Engineer",
+				Company:    "Globex",
+				Location:   "Remote",
+				IsRemote:   true,
+				Board:      ExtractorLinkedIn,
+				Extractors: []string{ExtractorLinkedIn, ExtractorOpenGraph},
+			},
+			description: []string{"Globex is hiring a Go Engineer"},
+		},
+		{
+			name:    "indeed",
+			fixture: "indeed.html",
+			url:     "https://www.indeed.com/viewjob?jk=0123456789abcdef",
+			want: Posting{
+				Title:      "Backend Engineer",
+				Company:    "Initech",
+				Location:   "Austin, TX 78701",
+				Salary:     "$120,000 - $150,000 a year - Full-time",
+				Board:      ExtractorIndeed,
+				Extractors: []string{ExtractorIndeed},
+			},
+			description: []string{"TPS report pipeline", "<li>Go or Java</li>"},
+		},
+		{
+			name:    "indeed title only",
+			fixture: "indeed_minimal.html",
+			url:     "https://uk.indeed.com/viewjob?jk=fedcba9876543210",
+			want: Posting{
+				Title:      "Data Analyst",
+				Location:   "Remote",
+				IsRemote:   true,
+				Board:      ExtractorIndeed,
+				Extractors: []string{ExtractorIndeed, ExtractorOpenGraph},
+			},
+			description: []string{"Analyze data for a growing team."},
+		},
+		{
+			name:    "greenhouse",
+			fixture: "greenhouse.html",
+			url:     "https://boards.greenhouse.io/hooli/jobs/4012345",
+			want: Posting{
+				Title:      "Site Reliability Engineer",
+				Company:    "Hooli",
+				Location:   "Remote - US",
+				IsRemote:   true,
+				Board:      ExtractorGreenhouse,
+				Extractors: []string{ExtractorGreenhouse},
+			},
+			description: []string{"looking for an SRE", "<li>Terraform</li>"},
+		},
+		{
+			name:    "greenhouse company from board token",
+			fixture: "greenhouse_minimal.html",
+			url:     "https://boards.greenhouse.io/piedpiper/jobs/5550123",
+			want: Posting{
+				Title:      "Data Engineer",
+				Company:    "piedpiper",
+				Location:   "San Francisco, CA",
+				Board:      ExtractorGreenhouse,
+				Extractors: []string{ExtractorGreenhouse},
+			},
+			description: []string{"Build our data platform."},
+		},
+		{
+			name:    "lever",
+			fixture: "lever.html",
+			url:     "https://jobs.lever.co/umbrella/5f2c1a9e-0000-4b6e-9a77-1c2d3e4f5a6b",
+			want: Posting{
+				Title:      "Platform Engineer",
+				Company:    "Umbrella",
+				Location:   "London, United Kingdom",
+				JobType:    "Full Time",
+				Board:      ExtractorLever,
+				Extractors: []string{ExtractorLever},
+			},
+			description: []string{"builds internal platforms", "<li>Terraform</li>", "Apply now."},
+		},
+		{
+			name:    "json-ld",
+			fixture: "jsonld.html",
+			url:     "https://careers.stark.example/jobs/42",
+			want: Posting{
+				Title:        "Staff Engineer",
+				Company:      "Stark Industries",
+				Location:     "New York, NY, US",
+				Salary:       "USD 180000-220000 yearly",
+				JobType:      "fulltime, contract",
+				IsRemote:     true,
+				PostedAt:     timeOf(t, "2026-09-01T00:00:00Z"),
+				ValidThrough: timeOf(t, "2026-12-31T23:59:59Z"),
+				Extractors:   []string{ExtractorJSONLD},
+			},
+			description: []string{"<p>Build the <b>suits</b>.</p>"},
+		},
+		{
+			name:    "opengraph",
+			fixture: "opengraph.html",
+			url:     "https://wayne.example/careers/junior-designer",
+			want: Posting{
+				Title:      "Junior Designer",
+				Company:    "Wayne Enterprises",
+				Extractors: []string{ExtractorOpenGraph},
+			},
+			description: []string{"Join our design team in Gotham."},
+		},
+		{
+			name:    "document title",
+			fixture: "title_only.html",
+			url:     "https://clinic.example/jobs/7",
+			want: Posting{
+				Title:      "Night Shift Nurse & Carer",
+				Extractors: []string{ExtractorOpenGraph},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := Extract(u, body)
+			for _, want := range tt.description {
+				if !strings.Contains(got.Description, want) {
+					t.Errorf("Description = %q, want it to contain %q", got.Description, want)
+				}
+			}
+			if len(tt.description) == 0 && got.Description != "" {
+				t.Errorf("Description = %q, want none", got.Description)
+			}
+			if !sameTime(got.PostedAt, tt.want.PostedAt) {
+				t.Errorf("PostedAt = %v, want %v", got.PostedAt, tt.want.PostedAt)
+			}
+			if !sameTime(got.ValidThrough, tt.want.ValidThrough) {
+				t.Errorf("ValidThrough = %v, want %v", got.ValidThrough, tt.want.ValidThrough)
+			}
+
+			rest := *got
+			rest.Description, rest.PostedAt, rest.ValidThrough = "", nil, nil
+			want := tt.want
+			want.PostedAt, want.ValidThrough = nil, nil
+			if !reflect.DeepEqual(rest, want) {
+				t.Errorf("Extract() =\n%+v\nwant\n%+v", rest, want)
+			}
+		})
+	}
+}
+
+func timeOf(t *testing.T, s string) *time.Time {
+	t.Helper()
+	v, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &v
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
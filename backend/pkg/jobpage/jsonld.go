@@ -0,0 +1,242 @@
+package jobpage
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// extractJSONLD reads the first schema.org JobPosting among the page's
+// JSON-LD scripts, which may hold it at the top level, in an array or in an
+// @graph.
+func extractJSONLD(p *page) *Posting {
+	for _, script := range p.jsonLD() {
+		var doc any
+		if err := json.Unmarshal([]byte(strings.TrimSpace(script)), &doc); err != nil {
+			continue
+		}
+		if posting := findJobPosting(doc); posting != nil {
+			return jobPostingFields(posting)
+		}
+	}
+	return nil
+}
+
+func findJobPosting(v any) map[string]any {
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			if found := findJobPosting(item); found != nil {
+				return found
+			}
+		}
+	case map[string]any:
+		for _, t := range strings.Fields(strings.Join(stringsOf(v["@type"]), " ")) {
+			if t == "JobPosting" || strings.HasSuffix(t, "/JobPosting") {
+				return v
+			}
+		}
+		return findJobPosting(v["@graph"])
+	}
+	return nil
+}
+
+func jobPostingFields(v map[string]any) *Posting {
+	posting := &Posting{
+		Title:        str(v["title"]),
+		Company:      name(v["hiringOrganization"]),
+		Location:     jobLocation(v["jobLocation"]),
+		Description:  html.UnescapeString(str(v["description"])),
+		Salary:       salary(v["baseSalary"]),
+		JobType:      employmentType(v["employmentType"]),
+		PostedAt:     date(v["datePosted"]),
+		ValidThrough: date(v["validThrough"]),
+	}
+	if posting.Title == "" {
+		posting.Title = str(v["name"])
+	}
+	for _, t := range stringsOf(v["jobLocationType"]) {
+		if strings.EqualFold(t, "TELECOMMUTE") {
+			posting.IsRemote = true
+		}
+	}
+	if posting.Location == "" && posting.IsRemote {
+		posting.Location = "Remote"
+	}
+	posting.IsRemote = posting.IsRemote || isRemoteText(posting.Location)
+	return posting
+}
+
+// str returns v if it is a string, with whitespace collapsed.
+func str(v any) string {
+	s, _ := v.(string)
+	return collapse(s)
+}
+
+// stringsOf returns v as a list of strings: JSON-LD allows a single value
+// wherever a list is.
+func stringsOf(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// name reads an Organization or Place, given either as an object or by
+// name alone.
+func name(v any) string {
+	switch v := v.(type) {
+	case string:
+		return collapse(v)
+	case map[string]any:
+		return str(v["name"])
+	case []any:
+		if len(v) > 0 {
+			return name(v[0])
+		}
+	}
+	return ""
+}
+
+// jobLocation joins the addresses of one or more Places.
+func jobLocation(v any) string {
+	var places []any
+	switch v := v.(type) {
+	case []any:
+		places = v
+	case nil:
+	default:
+		places = []any{v}
+	}
+	var locations []string
+	seen := make(map[string]bool)
+	for _, place := range places {
+		location := ""
+		if m, ok := place.(map[string]any); ok {
+			location = address(m["address"])
+			if location == "" {
+				location = str(m["name"])
+			}
+		} else {
+			location = str(place)
+		}
+		if location != "" && !seen[location] {
+			seen[location] = true
+			locations = append(locations, location)
+		}
+	}
+	return strings.Join(locations, "; ")
+}
+
+func address(v any) string {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return str(v)
+	}
+	var parts []string
+	for _, key := range []string{"addressLocality", "addressRegion", "addressCountry"} {
+		if part := name(m[key]); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// schemaEmploymentTypes maps schema.org employment types to the job types
+// the job boards searched use.
+var schemaEmploymentTypes = map[string]string{
+	"FULL_TIME":  "fulltime",
+	"PART_TIME":  "parttime",
+	"CONTRACTOR": "contract",
+	"TEMPORARY":  "temporary",
+	"INTERN":     "internship",
+	"PER_DIEM":   "perdiem",
+	"VOLUNTEER":  "volunteer",
+}
+
+func employmentType(v any) string {
+	var types []string
+	for _, t := range stringsOf(v) {
+		if mapped, ok := schemaEmploymentTypes[strings.ToUpper(strings.TrimSpace(t))]; ok {
+			t = mapped
+		}
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	return strings.Join(types, ", ")
+}
+
+// schemaUnits maps the unitText of a salary to the periods used elsewhere.
+var schemaUnits = map[string]string{
+	"HOUR":  "hourly",
+	"DAY":   "daily",
+	"WEEK":  "weekly",
+	"MONTH": "monthly",
+	"YEAR":  "yearly",
+}
+
+// salary formats a MonetaryAmount such as
+// {"currency": "USD", "value": {"minValue": 100000, "maxValue": 150000, "unitText": "YEAR"}}
+// as "USD 100000-150000 yearly".
+func salary(v any) string {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return str(v)
+	}
+	currency := str(m["currency"])
+	value, ok := m["value"].(map[string]any)
+	if !ok {
+		if amount := number(m["value"]); amount != "" {
+			return strings.TrimSpace(currency + " " + amount)
+		}
+		return ""
+	}
+	amount := number(value["value"])
+	if low, high := number(value["minValue"]), number(value["maxValue"]); low != "" && high != "" && low != high {
+		amount = low + "-" + high
+	} else if amount == "" {
+		amount = firstNonEmpty(low, high)
+	}
+	if amount == "" {
+		return ""
+	}
+	unit := str(value["unitText"])
+	if period, ok := schemaUnits[strings.ToUpper(unit)]; ok {
+		unit = period
+	}
+	return strings.TrimSpace(strings.Join([]string{currency, amount, strings.ToLower(unit)}, " "))
+}
+
+// number formats a JSON number, or a numeric string, without decimals
+// unless it has some.
+func number(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", v), "0"), ".")
+	case string:
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+func date(v any) *time.Time {
+	s := strings.TrimSpace(str(v))
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
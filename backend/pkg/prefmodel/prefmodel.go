@@ -0,0 +1,273 @@
+// Package prefmodel learns which jobs a user likes from the jobs they have
+// already decided on. It is a logistic regression over the words of a job's
+// title and description and its company, small enough to train in-process
+// and to explain: every prediction lists the terms that moved it most.
+package prefmodel
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+
+	"job-tracker-backend/pkg/textmatch"
+)
+
+// MinExamples is the fewest liked and the fewest disliked jobs a model is
+// trained from; with less, its predictions would be noise.
+const MinExamples = 3
+
+// ErrNotEnoughData is returned by Train when there are fewer than
+// MinExamples liked or disliked jobs.
+var ErrNotEnoughData = errors.New("not enough decisions to learn from")
+
+// Training settings. Regularization keeps the weights of words seen in a
+// handful of jobs small, so a single rejected posting can't condemn a word.
+const (
+	epochs         = 300
+	learningRate   = 0.5
+	regularization = 0.01
+	// maxFeatures caps the vocabulary, keeping the most frequent features.
+	maxFeatures = 5000
+	// minWeight drops near-zero weights from the trained model.
+	minWeight = 0.001
+)
+
+// Feature prefixes. Description words are unprefixed.
+const (
+	titlePrefix   = "title:"
+	companyPrefix = "company:"
+)
+
+// Document is the part of a job the model looks at. Description is plain
+// text.
+type Document struct {
+	Title       string
+	Company     string
+	Description string
+}
+
+// Example is a job the user decided on.
+type Example struct {
+	Document
+	Liked bool
+}
+
+// Model is a trained preference model. It is serialized to JSON for storage.
+type Model struct {
+	Bias    float64            `json:"bias"`
+	Weights map[string]float64 `json:"weights"`
+}
+
+// Contribution is how much a term pushed a prediction towards liked
+// (positive) or disliked (negative).
+type Contribution struct {
+	Term   string  `json:"term"`
+	Weight float64 `json:"weight"`
+}
+
+// Prediction is the model's estimate that the user will like a job.
+type Prediction struct {
+	// Probability is between 0 and 1
+	Probability float64
+	// Terms are the largest contributions, strongest first
+	Terms []Contribution
+}
+
+// features returns the distinct features of doc, each weighted so the
+// document's vector has unit length. Long descriptions then count as much as
+// short ones.
+func features(doc Document) map[string]float64 {
+	set := make(map[string]bool)
+	for _, t := range textmatch.Terms(doc.Title) {
+		set[titlePrefix+t] = true
+	}
+	if company := strings.Join(strings.Fields(strings.ToLower(doc.Company)), " "); company != "" {
+		set[companyPrefix+company] = true
+	}
+	for _, t := range textmatch.Terms(doc.Description) {
+		set[t] = true
+	}
+	vec := make(map[string]float64, len(set))
+	if len(set) == 0 {
+		return vec
+	}
+	value := 1 / math.Sqrt(float64(len(set)))
+	for f := range set {
+		vec[f] = value
+	}
+	return vec
+}
+
+// Train fits a model to examples. Liked and disliked examples are weighted
+// to count equally, so a user who rejects rarely still gets a useful model.
+func Train(examples []Example) (*Model, error) {
+	liked := 0
+	for _, e := range examples {
+		if e.Liked {
+			liked++
+		}
+	}
+	disliked := len(examples) - liked
+	if liked < MinExamples || disliked < MinExamples {
+		return nil, ErrNotEnoughData
+	}
+
+	docs := make([]map[string]float64, len(examples))
+	docFreq := make(map[string]int)
+	for i, e := range examples {
+		docs[i] = features(e.Document)
+		for f := range docs[i] {
+			docFreq[f]++
+		}
+	}
+	vocab := vocabulary(docFreq)
+
+	// Sparse vectors over the vocabulary.
+	type entry struct {
+		index int
+		value float64
+	}
+	vectors := make([][]entry, len(docs))
+	for i, doc := range docs {
+		for f, v := range doc {
+			if idx, ok := vocab[f]; ok {
+				vectors[i] = append(vectors[i], entry{idx, v})
+			}
+		}
+	}
+	labels := make([]float64, len(examples))
+	sampleWeights := make([]float64, len(examples))
+	for i, e := range examples {
+		if e.Liked {
+			labels[i] = 1
+			sampleWeights[i] = float64(len(examples)) / (2 * float64(liked))
+		} else {
+			sampleWeights[i] = float64(len(examples)) / (2 * float64(disliked))
+		}
+	}
+
+	// Full-batch gradient descent: deterministic, and fast enough for the
+	// few hundred jobs a user decides on.
+	weights := make([]float64, len(vocab))
+	gradient := make([]float64, len(vocab))
+	bias := 0.0
+	n := float64(len(examples))
+	for epoch := 0; epoch < epochs; epoch++ {
+		for j := range gradient {
+			gradient[j] = regularization * weights[j]
+		}
+		biasGradient := 0.0
+		for i, vec := range vectors {
+			z := bias
+			for _, e := range vec {
+				z += weights[e.index] * e.value
+			}
+			diff := sampleWeights[i] * (sigmoid(z) - labels[i]) / n
+			for _, e := range vec {
+				gradient[e.index] += diff * e.value
+			}
+			biasGradient += diff
+		}
+		for j := range weights {
+			weights[j] -= learningRate * gradient[j]
+		}
+		bias -= learningRate * biasGradient
+	}
+
+	model := &Model{Bias: bias, Weights: make(map[string]float64)}
+	for f, idx := range vocab {
+		if math.Abs(weights[idx]) >= minWeight {
+			model.Weights[f] = weights[idx]
+		}
+	}
+	return model, nil
+}
+
+// vocabulary numbers the features kept for training: those found in at
+// least two jobs, plus companies, which rarely repeat but matter when they
+// do. At most maxFeatures are kept, most frequent first.
+func vocabulary(docFreq map[string]int) map[string]int {
+	var kept []string
+	for f, n := range docFreq {
+		if n >= 2 || strings.HasPrefix(f, companyPrefix) {
+			kept = append(kept, f)
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool {
+		if docFreq[kept[i]] != docFreq[kept[j]] {
+			return docFreq[kept[i]] > docFreq[kept[j]]
+		}
+		return kept[i] < kept[j]
+	})
+	if len(kept) > maxFeatures {
+		kept = kept[:maxFeatures]
+	}
+	vocab := make(map[string]int, len(kept))
+	for i, f := range kept {
+		vocab[f] = i
+	}
+	return vocab
+}
+
+// Predict scores doc, explaining the score with up to topTerms terms.
+func (m *Model) Predict(doc Document, topTerms int) *Prediction {
+	z := m.Bias
+	var terms []Contribution
+	for f, v := range features(doc) {
+		w, ok := m.Weights[f]
+		if !ok {
+			continue
+		}
+		z += w * v
+		terms = append(terms, Contribution{Term: displayTerm(f), Weight: w * v})
+	}
+	return &Prediction{Probability: sigmoid(z), Terms: strongest(terms, topTerms)}
+}
+
+// TopTerms returns the n terms the model likes most and the n it dislikes
+// most.
+func (m *Model) TopTerms(n int) (liked, disliked []Contribution) {
+	for f, w := range m.Weights {
+		c := Contribution{Term: displayTerm(f), Weight: w}
+		if w > 0 {
+			liked = append(liked, c)
+		} else {
+			disliked = append(disliked, c)
+		}
+	}
+	return strongest(liked, n), strongest(disliked, n)
+}
+
+// strongest sorts terms by the size of their weight and keeps the first n,
+// rounding weights for display.
+func strongest(terms []Contribution, n int) []Contribution {
+	sort.Slice(terms, func(i, j int) bool {
+		a, b := math.Abs(terms[i].Weight), math.Abs(terms[j].Weight)
+		if a != b {
+			return a > b
+		}
+		return terms[i].Term < terms[j].Term
+	})
+	if len(terms) > n {
+		terms = terms[:n]
+	}
+	for i := range terms {
+		terms[i].Weight = math.Round(terms[i].Weight*1000) / 1000
+	}
+	return terms
+}
+
+// displayTerm turns "title:golang" into "title: golang".
+func displayTerm(feature string) string {
+	for _, prefix := range []string{titlePrefix, companyPrefix} {
+		if strings.HasPrefix(feature, prefix) {
+			return prefix + " " + feature[len(prefix):]
+		}
+	}
+	return feature
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
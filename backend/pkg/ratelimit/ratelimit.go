@@ -0,0 +1,98 @@
+// Package ratelimit throttles requests with token buckets. Each key, such as
+// a client address, has a bucket holding up to Burst tokens that refills at
+// Rate tokens per second; a request takes a token and is refused when the
+// bucket is empty.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is the capacity and refill rate of a bucket. A zero Rate means no
+// limit.
+type Limit struct {
+	Rate  float64 // tokens per second
+	Burst int
+}
+
+// PerMinute allows n requests a minute, all of which may come at once.
+func PerMinute(n int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: n}
+}
+
+// Unlimited reports whether the limit lets everything through.
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Store keeps the buckets. Memory keeps them in the process; a shared store
+// lets several server instances enforce one limit.
+type Store interface {
+	// Take takes a token from the bucket of key at now. When the bucket is
+	// empty it returns false and how long until a token is available.
+	Take(key string, limit Limit, now time.Time) (bool, time.Duration)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// refill adds the tokens earned since the bucket was last updated.
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+elapsed*b.limit.Rate)
+		b.updated = now
+	}
+}
+
+// Memory is an in-process Store. Buckets that have refilled are dropped, so
+// it only holds the keys that were throttled recently.
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: map[string]*bucket{}}
+}
+
+func (m *Memory) Take(key string, limit Limit, now time.Time) (bool, time.Duration) {
+	if limit.Unlimited() {
+		return true, 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.swept) > time.Minute {
+		m.sweep(now)
+	}
+	b := m.buckets[key]
+	if b == nil {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		m.buckets[key] = b
+	}
+	b.limit = limit
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep drops full buckets, which are the same as no bucket.
+func (m *Memory) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(m.buckets, key)
+		}
+	}
+	m.swept = now
+}
@@ -0,0 +1,277 @@
+package richtext
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	atxHeadingPattern    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s+(.*?))?(?:\s+#+)?\s*$`)
+	setextHeadingPattern = regexp.MustCompile(`^ {0,3}(=+|-+)\s*$`)
+	rulePattern          = regexp.MustCompile(`^ {0,3}(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,})$`)
+	fencePattern         = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+	quotePattern         = regexp.MustCompile(`^ {0,3}> ?`)
+	listItemPattern      = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])(?:\s+(.*))?$`)
+)
+
+// MarkdownToHTML renders Markdown as HTML: ATX and setext headings,
+// paragraphs, emphasis, code, links, block quotes, rules and nested lists.
+// HTML in the input is escaped rather than passed through, and links are
+// kept only for http, https and mailto URLs, so the output is safe as is.
+func MarkdownToHTML(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\x00", "")
+	s = strings.ReplaceAll(s, "\t", "    ")
+	return strings.TrimSpace(renderMarkdownBlocks(strings.Split(s, "\n")))
+}
+
+func renderMarkdownBlocks(lines []string) string {
+	var b strings.Builder
+	var para []string
+
+	flush := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + renderParagraph(para) + "</p>\n")
+			para = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case len(para) > 0 && setextHeadingPattern.MatchString(line):
+			level := 1
+			if strings.HasPrefix(trimmed, "-") {
+				level = 2
+			}
+			fmt.Fprintf(&b, "<h%d>%s</h%d>\n", level, renderParagraph(para), level)
+			para = nil
+
+		case fencePattern.MatchString(line):
+			flush()
+			fence := fencePattern.FindStringSubmatch(line)[1]
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			b.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+
+		case atxHeadingPattern.MatchString(line):
+			flush()
+			m := atxHeadingPattern.FindStringSubmatch(line)
+			level := len(m[1])
+			fmt.Fprintf(&b, "<h%d>%s</h%d>\n", level, renderInline(m[2]), level)
+
+		case rulePattern.MatchString(line):
+			flush()
+			b.WriteString("<hr>\n")
+
+		case quotePattern.MatchString(line):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				quoted = append(quoted, quotePattern.ReplaceAllString(lines[i], ""))
+			}
+			i--
+			b.WriteString("<blockquote>\n" + renderMarkdownBlocks(quoted) + "</blockquote>\n")
+
+		case listItemPattern.MatchString(line):
+			flush()
+			var rendered string
+			rendered, i = renderList(lines, i)
+			b.WriteString(rendered)
+
+		default:
+			para = append(para, line)
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// renderList renders the list starting at lines[start] and returns the index
+// of its last line. An item runs until the next marker at the list's indent
+// or a line that is neither indented nor a lazy paragraph continuation.
+func renderList(lines []string, start int) (string, int) {
+	m := listItemPattern.FindStringSubmatch(lines[start])
+	indent := len(m[1])
+	ordered := m[2][0] >= '0' && m[2][0] <= '9'
+
+	var b strings.Builder
+	if ordered {
+		n, _ := strconv.Atoi(strings.TrimRight(m[2], ".)"))
+		if n != 1 {
+			fmt.Fprintf(&b, "<ol start=\"%d\">\n", n)
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+
+	i := start
+	for i < len(lines) {
+		m := listItemPattern.FindStringSubmatch(lines[i])
+		if m == nil || len(m[1]) != indent || (m[2][0] >= '0' && m[2][0] <= '9') != ordered {
+			break
+		}
+		contentIndent := len(m[1]) + len(m[2]) + 1
+		body := []string{m[3]}
+		blank := false
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				blank = true
+				body = append(body, "")
+				continue
+			}
+			lead := len(line) - len(strings.TrimLeft(line, " "))
+			if lead >= min(contentIndent, indent+2) {
+				body = append(body, line[min(lead, contentIndent):])
+				blank = false
+				continue
+			}
+			if !blank && !listItemPattern.MatchString(line) && !quotePattern.MatchString(line) &&
+				!atxHeadingPattern.MatchString(line) && !fencePattern.MatchString(line) && !rulePattern.MatchString(line) {
+				body = append(body, strings.TrimSpace(line))
+				continue
+			}
+			break
+		}
+		for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+			body = body[:len(body)-1]
+		}
+		b.WriteString("<li>" + unwrapParagraph(renderMarkdownBlocks(body)) + "</li>\n")
+		if blank && i < len(lines) {
+			// A blank line followed by something other than an item ends the list.
+			if m := listItemPattern.FindStringSubmatch(lines[i]); m == nil || len(m[1]) != indent {
+				break
+			}
+		}
+	}
+
+	if ordered {
+		b.WriteString("</ol>\n")
+	} else {
+		b.WriteString("</ul>\n")
+	}
+	return b.String(), i - 1
+}
+
+// unwrapParagraph renders a list item holding a single paragraph without
+// the <p>, as a tight list would be.
+func unwrapParagraph(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "<p>") && strings.Count(s, "<p>") == 1 {
+		if end := strings.Index(s, "</p>"); end >= 0 {
+			return s[3:end] + strings.TrimRight(s[end+4:], "\n")
+		}
+	}
+	return s
+}
+
+// renderParagraph renders the lines of a paragraph. A line ending in two
+// spaces or a backslash is a hard line break.
+func renderParagraph(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		hard := strings.HasSuffix(line, "  ") || strings.HasSuffix(strings.TrimRight(line, " "), `\`)
+		line = strings.TrimSpace(line)
+		if hard {
+			line = strings.TrimSuffix(line, `\`)
+		}
+		b.WriteString(renderInline(line))
+		if i < len(lines)-1 {
+			if hard {
+				b.WriteString("<br>")
+			}
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+var (
+	escapePattern     = regexp.MustCompile("\\\\([!\"#$%&'()*+,\\-./:;<=>?@\\[\\\\\\]^_`{|}~])")
+	codeSpanPattern   = regexp.MustCompile("(`+)(.+?)(`+)")
+	linkPattern       = regexp.MustCompile(`\[([^\[\]]*)\]\(\s*<?([^\s()<>]*)>?(?:\s+(?:"[^"]*"|'[^']*'))?\s*\)`)
+	autolinkPattern   = regexp.MustCompile(`<((?:https?|mailto):[^\s<>]+)>`)
+	placeholderRegexp = regexp.MustCompile("\x00(\\d+)\x00")
+	strongPattern     = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	emPattern         = regexp.MustCompile(`\*(\S(?:[^*]*?\S)?)\*|(^|[^\pL\pN_])_(\S(?:[^_]*?\S)?)_([^\pL\pN_]|$)`)
+	strikePattern     = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+)
+
+// renderInline renders emphasis, code spans and links. Pieces that must not
+// be processed further are swapped for NUL-delimited placeholders, which
+// MarkdownToHTML has already removed from the input.
+func renderInline(s string) string {
+	var held []string
+	hold := func(rendered string) string {
+		held = append(held, rendered)
+		return "\x00" + strconv.Itoa(len(held)-1) + "\x00"
+	}
+	// raw restores placeholders as the text they stand for, for contexts
+	// that escape on their own.
+	raw := func(s string) string {
+		return placeholderRegexp.ReplaceAllStringFunc(s, func(m string) string {
+			i, _ := strconv.Atoi(m[1 : len(m)-1])
+			return html.UnescapeString(held[i])
+		})
+	}
+
+	s = escapePattern.ReplaceAllStringFunc(s, func(m string) string {
+		return hold(html.EscapeString(m[1:]))
+	})
+	s = codeSpanPattern.ReplaceAllStringFunc(s, func(m string) string {
+		parts := codeSpanPattern.FindStringSubmatch(m)
+		if len(parts[1]) != len(parts[3]) {
+			return m
+		}
+		return hold("<code>" + html.EscapeString(strings.TrimSpace(raw(parts[2]))) + "</code>")
+	})
+	s = linkPattern.ReplaceAllStringFunc(s, func(m string) string {
+		parts := linkPattern.FindStringSubmatch(m)
+		text := formatInline(parts[1])
+		href, ok := safeURL(raw(parts[2]))
+		if !ok {
+			return hold(text)
+		}
+		return hold(`<a href="` + html.EscapeString(href) + `" rel="` + linkRel + `">` + text + "</a>")
+	})
+	s = autolinkPattern.ReplaceAllStringFunc(s, func(m string) string {
+		href, ok := safeURL(raw(m[1 : len(m)-1]))
+		if !ok {
+			return m
+		}
+		return hold(`<a href="` + html.EscapeString(href) + `" rel="` + linkRel + `">` + html.EscapeString(href) + "</a>")
+	})
+
+	s = formatInline(s)
+	// Placeholders can nest (a code span inside link text), so restore until
+	// none are left.
+	for strings.Contains(s, "\x00") {
+		s = placeholderRegexp.ReplaceAllStringFunc(s, func(m string) string {
+			i, _ := strconv.Atoi(m[1 : len(m)-1])
+			return held[i]
+		})
+	}
+	return s
+}
+
+// formatInline escapes text and renders its emphasis. Placeholders pass
+// through untouched.
+func formatInline(s string) string {
+	s = html.EscapeString(s)
+	s = strongPattern.ReplaceAllString(s, "<strong>$1$2</strong>")
+	s = emPattern.ReplaceAllString(s, "${2}<em>$1$3</em>$4")
+	return strikePattern.ReplaceAllString(s, "<s>$1</s>")
+}
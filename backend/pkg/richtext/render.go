@@ -0,0 +1,300 @@
+package richtext
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// node is an element or text of sanitized HTML.
+type node struct {
+	tag      string // empty for text
+	text     string
+	attrs    []attribute
+	children []*node
+}
+
+// parseSanitized builds a tree from SanitizeHTML output, which is
+// well-formed, so any stray end tag is simply ignored.
+func parseSanitized(s string) *node {
+	root := &node{tag: "#root"}
+	stack := []*node{root}
+	z := newTokenizer(s)
+	for tok, ok := z.next(); ok; tok, ok = z.next() {
+		parent := stack[len(stack)-1]
+		switch tok.typ {
+		case textToken:
+			parent.children = append(parent.children, &node{text: tok.data})
+		case startTagToken:
+			n := &node{tag: tok.data, attrs: tok.attrs}
+			parent.children = append(parent.children, n)
+			if !voidTags[n.tag] {
+				stack = append(stack, n)
+			}
+		case endTagToken:
+			if len(stack) > 1 && stack[len(stack)-1].tag == tok.data {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	return root
+}
+
+func (n *node) attr(name string) string {
+	for _, a := range n.attrs {
+		if a.name == name {
+			return a.value
+		}
+	}
+	return ""
+}
+
+var inlineTags = map[string]bool{
+	"strong": true, "em": true, "u": true, "s": true, "sub": true, "sup": true, "code": true, "a": true, "br": true,
+}
+
+func (n *node) isInline() bool {
+	return n.tag == "" || inlineTags[n.tag]
+}
+
+// textRenderer turns a tree into Markdown or plain text, which differ only
+// in how inline markup and a few blocks are written.
+type textRenderer struct {
+	markdown bool
+}
+
+var (
+	spacePattern    = regexp.MustCompile(`\s+`)
+	blankRunPattern = regexp.MustCompile(`\n{3,}`)
+	mdSpecialChars  = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`)
+)
+
+// blocks renders children as blocks separated by blank lines, gathering runs
+// of inline content into paragraphs.
+func (r *textRenderer) blocks(children []*node) string {
+	return r.joinBlocks(children, "\n\n")
+}
+
+// joinBlocks is blocks with a custom separator; list items use a single
+// newline so a nested list stays part of a tight item.
+func (r *textRenderer) joinBlocks(children []*node, sep string) string {
+	var out []string
+	var run []*node
+	flush := func() {
+		// Collapsed whitespace after a <br> would indent the next line.
+		if text := strings.TrimSpace(strings.ReplaceAll(r.inline(run), "\n ", "\n")); text != "" {
+			out = append(out, text)
+		}
+		run = nil
+	}
+	for _, c := range children {
+		if c.isInline() {
+			run = append(run, c)
+			continue
+		}
+		flush()
+		if text := r.block(c); strings.TrimSpace(text) != "" {
+			out = append(out, text)
+		}
+	}
+	flush()
+	return strings.Join(out, sep)
+}
+
+func (r *textRenderer) block(n *node) string {
+	switch n.tag {
+	case "p", "dd":
+		return r.blocks(n.children)
+	case "dt":
+		if r.markdown {
+			return "**" + strings.TrimSpace(r.inline(n.children)) + "**"
+		}
+		return r.blocks(n.children)
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := strings.TrimSpace(spacePattern.ReplaceAllString(r.inline(n.children), " "))
+		if r.markdown {
+			level, _ := strconv.Atoi(n.tag[1:])
+			return strings.Repeat("#", level) + " " + text
+		}
+		return text
+	case "hr":
+		return "---"
+	case "pre":
+		code := strings.Trim(n.rawText(), "\n")
+		if r.markdown {
+			return "```\n" + code + "\n```"
+		}
+		return code
+	case "blockquote":
+		return prefixLines(r.blocks(n.children), "> ")
+	case "ul", "ol":
+		return r.list(n)
+	case "table":
+		return r.table(n)
+	default:
+		return r.blocks(n.children)
+	}
+}
+
+func (r *textRenderer) list(n *node) string {
+	number := 1
+	if start, err := strconv.Atoi(n.attr("start")); err == nil {
+		number = start
+	}
+	var items []string
+	for _, li := range n.children {
+		if li.tag != "li" {
+			continue
+		}
+		marker := "- "
+		if n.tag == "ol" {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		body := r.joinBlocks(li.children, "\n")
+		items = append(items, marker+indentContinuation(body, len(marker)))
+	}
+	return strings.Join(items, "\n")
+}
+
+func (r *textRenderer) table(n *node) string {
+	var rows [][]string
+	var walk func(*node)
+	walk = func(n *node) {
+		for _, c := range n.children {
+			switch c.tag {
+			case "tr":
+				var cells []string
+				for _, cell := range c.children {
+					if cell.tag == "td" || cell.tag == "th" {
+						text := spacePattern.ReplaceAllString(r.blocks(cell.children), " ")
+						cells = append(cells, strings.ReplaceAll(text, "|", `\|`))
+					}
+				}
+				rows = append(rows, cells)
+			case "thead", "tbody":
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+
+	var lines []string
+	for i, cells := range rows {
+		if !r.markdown {
+			lines = append(lines, strings.Join(cells, "\t"))
+			continue
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", max(1, len(cells))))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *textRenderer) inline(nodes []*node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		switch n.tag {
+		case "":
+			text := spacePattern.ReplaceAllString(n.text, " ")
+			if r.markdown {
+				text = mdSpecialChars.Replace(text)
+			}
+			b.WriteString(text)
+		case "br":
+			if r.markdown {
+				b.WriteString("  ")
+			}
+			b.WriteByte('\n')
+		case "strong":
+			r.wrap(&b, n, "**")
+		case "em":
+			r.wrap(&b, n, "_")
+		case "s":
+			r.wrap(&b, n, "~~")
+		case "code":
+			code := n.rawText()
+			if r.markdown {
+				code = "`" + code + "`"
+			}
+			b.WriteString(code)
+		case "a":
+			text := r.inline(n.children)
+			href := n.attr("href")
+			switch {
+			case r.markdown:
+				b.WriteString("[" + text + "](" + href + ")")
+			case strings.TrimSpace(text) == href || strings.TrimPrefix(href, "mailto:") == strings.TrimSpace(text):
+				b.WriteString(text)
+			default:
+				b.WriteString(text + " (" + href + ")")
+			}
+		default:
+			if n.isInline() {
+				b.WriteString(r.inline(n.children))
+			} else {
+				b.WriteString("\n" + r.block(n) + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// wrap writes an inline element between markers, moving surrounding spaces
+// outside them since "** bold **" isn't emphasis in Markdown.
+func (r *textRenderer) wrap(b *strings.Builder, n *node, marker string) {
+	text := r.inline(n.children)
+	if !r.markdown || strings.TrimSpace(text) == "" {
+		b.WriteString(text)
+		return
+	}
+	trimmed := strings.TrimSpace(text)
+	lead := text[:strings.Index(text, trimmed)]
+	trail := text[len(lead)+len(trimmed):]
+	b.WriteString(lead + marker + trimmed + marker + trail)
+}
+
+// rawText is the text of n and its descendants, unformatted.
+func (n *node) rawText() string {
+	if n.tag == "" {
+		return n.text
+	}
+	var b strings.Builder
+	for _, c := range n.children {
+		if c.tag == "br" {
+			b.WriteByte('\n')
+			continue
+		}
+		b.WriteString(c.rawText())
+	}
+	return b.String()
+}
+
+func prefixLines(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(prefix+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func indentContinuation(s string, width int) string {
+	return strings.ReplaceAll(s, "\n", "\n"+strings.Repeat(" ", width))
+}
+
+func render(sanitized string, markdown bool) string {
+	r := &textRenderer{markdown: markdown}
+	out := r.blocks(parseSanitized(sanitized).children)
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		// Keep Markdown hard breaks, drop any other trailing space.
+		if markdown && strings.HasSuffix(line, "  ") && strings.TrimSpace(line) != "" {
+			lines[i] = strings.TrimRight(line, " ") + "  "
+		} else {
+			lines[i] = strings.TrimRight(line, " ")
+		}
+	}
+	return strings.TrimSpace(blankRunPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
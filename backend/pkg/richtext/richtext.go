@@ -0,0 +1,109 @@
+// Package richtext normalizes job descriptions, which arrive as HTML,
+// Markdown or plain text depending on the board they were scraped from, into
+// a single safe form: HTML restricted to a whitelist of formatting elements.
+// That form can be rendered back as Markdown or plain text.
+package richtext
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Formats of a description.
+const (
+	FormatHTML     = "html"
+	FormatMarkdown = "markdown"
+	FormatText     = "text"
+)
+
+var (
+	htmlBlockPattern  = regexp.MustCompile(`(?i)<(?:p|div|br|ul|ol|li|h[1-6]|table|tr|td|section|article|blockquote|pre|hr)\b[^>]*>`)
+	htmlTagPattern    = regexp.MustCompile(`(?i)<[a-z][a-z0-9]*\b[^>]*>|</[a-z][a-z0-9]*\s*>`)
+	escapedTagPattern = regexp.MustCompile(`(?i)&lt;/?(?:p|div|br|ul|ol|li|b|strong|i|em|h[1-6]|span)\b`)
+	paragraphBreak    = regexp.MustCompile(`\n\s*\n`)
+	markdownPatterns  = []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^ {0,3}#{1,6}\s+\S`),
+		regexp.MustCompile(`(?m)^ {0,3}[-*+]\s+\S`),
+		regexp.MustCompile(`(?m)^ {0,3}\d{1,3}[.)]\s+\S`),
+		regexp.MustCompile(`(?m)^ {0,3}>\s?\S`),
+		regexp.MustCompile(`(?m)^ {0,3}(?:=+|-{3,})\s*$`),
+		regexp.MustCompile(`\*\*\S[^*\n]*\*\*|__\S[^_\n]*__`),
+		regexp.MustCompile(`\[[^\]\n]+\]\([^)\s]+\)`),
+		regexp.MustCompile("(?m)^```"),
+	}
+)
+
+// Detect guesses the format of s. Block-level tags make it HTML; otherwise
+// Markdown syntax wins over stray inline tags, which Markdown allows. Some
+// boards entity-escape their HTML ("&lt;p&gt;"), which is reported as HTML
+// too; Normalize unescapes it.
+func Detect(s string) string {
+	if htmlBlockPattern.MatchString(s) || escapedTagPattern.MatchString(s) {
+		return FormatHTML
+	}
+	for _, p := range markdownPatterns {
+		if p.MatchString(s) {
+			return FormatMarkdown
+		}
+	}
+	if htmlTagPattern.MatchString(s) {
+		return FormatHTML
+	}
+	return FormatText
+}
+
+// Normalize converts a description in any format to sanitized HTML and
+// reports the format it was detected as.
+func Normalize(s string) (string, string) {
+	format := Detect(s)
+	switch format {
+	case FormatHTML:
+		if !htmlTagPattern.MatchString(s) {
+			s = html.UnescapeString(s)
+		}
+		if !htmlBlockPattern.MatchString(s) {
+			// Text with a few inline tags: its line breaks are meant to show.
+			s = paragraphs(s, func(line string) string { return line })
+		}
+		return SanitizeHTML(s), format
+	case FormatMarkdown:
+		return MarkdownToHTML(s), format
+	default:
+		return TextToHTML(s), format
+	}
+}
+
+// TextToHTML escapes plain text, turning blank-line separated blocks into
+// paragraphs and other line breaks into <br>.
+func TextToHTML(s string) string {
+	return paragraphs(s, html.EscapeString)
+}
+
+func paragraphs(s string, line func(string) string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	var b strings.Builder
+	for _, para := range paragraphBreak.Split(s, -1) {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		lines := strings.Split(para, "\n")
+		for i, l := range lines {
+			lines[i] = line(strings.TrimSpace(l))
+		}
+		b.WriteString("<p>" + strings.Join(lines, "<br>\n") + "</p>\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// ToMarkdown renders sanitized HTML as Markdown.
+func ToMarkdown(sanitized string) string {
+	return render(sanitized, true)
+}
+
+// ToText renders sanitized HTML as plain text, keeping list markers and
+// link targets.
+func ToText(sanitized string) string {
+	return render(sanitized, false)
+}
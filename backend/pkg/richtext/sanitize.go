@@ -0,0 +1,241 @@
+package richtext
+
+import (
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// allowedTags are the elements kept by SanitizeHTML. Everything else is
+// dropped, keeping its text unless it is in droppedTags.
+var allowedTags = map[string]bool{
+	"p": true, "br": true, "hr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"strong": true, "em": true, "u": true, "s": true, "sub": true, "sup": true,
+	"blockquote": true, "pre": true, "code": true, "a": true,
+	"table": true, "thead": true, "tbody": true, "tr": true, "th": true, "td": true,
+}
+
+// renamedTags are presentational synonyms of allowed tags.
+var renamedTags = map[string]string{
+	"b": "strong", "i": "em", "strike": "s", "del": "s", "tt": "code",
+}
+
+// droppedTags are removed together with everything inside them.
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "title": true, "head": true,
+	"iframe": true, "frame": true, "frameset": true, "object": true, "embed": true, "applet": true,
+	"svg": true, "math": true, "textarea": true, "select": true, "button": true, "xmp": true,
+}
+
+var voidTags = map[string]bool{
+	"br": true, "hr": true,
+	// dropped void elements, so they never open a dropped range
+	"img": true, "input": true, "meta": true, "link": true, "base": true, "source": true,
+	"area": true, "col": true, "wbr": true, "param": true, "track": true, "embed": true,
+}
+
+// blockTags separate text. Those not allowed become a line break in the
+// output so their contents don't run together.
+var blockTags = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "header": true, "footer": true,
+	"main": true, "aside": true, "nav": true, "figure": true, "center": true, "address": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "dl": true, "blockquote": true, "pre": true, "table": true, "hr": true,
+}
+
+// allowedAttributes are the attributes kept per element, with a check of
+// their value.
+var allowedAttributes = map[string]map[string]func(string) (string, bool){
+	"a":  {"href": safeURL, "title": anyValue},
+	"ol": {"start": number},
+	"td": {"colspan": number, "rowspan": number},
+	"th": {"colspan": number, "rowspan": number},
+}
+
+// linkRel is added to every link, since descriptions come from third parties.
+const linkRel = "nofollow noopener noreferrer"
+
+// SanitizeHTML returns the whitelisted subset of an HTML fragment: a fixed
+// set of formatting elements, links with http, https or mailto URLs, and no
+// scripts, styles, event handlers or embedded content. The result is
+// well-formed, with every element closed.
+func SanitizeHTML(s string) string {
+	var b strings.Builder
+	var open []string
+	var skipTag string
+	skipDepth := 0
+
+	closeTo := func(i int) {
+		for j := len(open) - 1; j >= i; j-- {
+			b.WriteString("</" + open[j] + ">")
+		}
+		open = open[:i]
+	}
+
+	z := newTokenizer(s)
+	for tok, ok := z.next(); ok; tok, ok = z.next() {
+		if skipDepth > 0 {
+			switch {
+			case tok.typ == startTagToken && tok.data == skipTag:
+				skipDepth++
+			case tok.typ == endTagToken && tok.data == skipTag:
+				skipDepth--
+			}
+			continue
+		}
+
+		switch tok.typ {
+		case textToken:
+			b.WriteString(html.EscapeString(tok.data))
+
+		case startTagToken:
+			name := tok.data
+			if droppedTags[name] {
+				if !voidTags[name] {
+					skipTag, skipDepth = name, 1
+				}
+				continue
+			}
+			if r, ok := renamedTags[name]; ok {
+				name = r
+			}
+			if blockTags[name] {
+				// A block ends an open paragraph, as it would in a browser.
+				if i := lastOpen(open, "p"); i >= 0 && !containsAny(open[i+1:], "li", "td", "th", "blockquote", "dd") {
+					closeTo(i)
+				}
+			}
+			if !allowedTags[name] {
+				if blockTags[name] {
+					b.WriteByte('\n')
+				}
+				continue
+			}
+			switch name {
+			case "li":
+				if i := lastOpen(open, "li"); i >= 0 && !containsAny(open[i+1:], "ul", "ol") {
+					closeTo(i)
+				}
+			case "dt", "dd":
+				if i := max(lastOpen(open, "dt"), lastOpen(open, "dd")); i >= 0 && !containsAny(open[i+1:], "dl") {
+					closeTo(i)
+				}
+			case "tr":
+				if i := lastOpen(open, "tr"); i >= 0 && !containsAny(open[i+1:], "table") {
+					closeTo(i)
+				}
+			case "td", "th":
+				if i := max(lastOpen(open, "td"), lastOpen(open, "th")); i >= 0 && !containsAny(open[i+1:], "table") {
+					closeTo(i)
+				}
+			case "a":
+				if tok.attr("href") == "" {
+					continue
+				}
+				if _, ok := safeURL(tok.attr("href")); !ok {
+					continue
+				}
+				// Links don't nest.
+				if i := lastOpen(open, "a"); i >= 0 {
+					closeTo(i)
+				}
+			}
+			b.WriteString("<" + name)
+			writeAttributes(&b, name, tok.attrs)
+			b.WriteByte('>')
+			if !voidTags[name] {
+				open = append(open, name)
+			}
+
+		case endTagToken:
+			name := tok.data
+			if r, ok := renamedTags[name]; ok {
+				name = r
+			}
+			if i := lastOpen(open, name); i >= 0 {
+				closeTo(i)
+			} else if blockTags[name] && !allowedTags[name] {
+				b.WriteByte('\n')
+			}
+		}
+	}
+	closeTo(0)
+	return strings.TrimSpace(b.String())
+}
+
+func writeAttributes(b *strings.Builder, tag string, attrs []attribute) {
+	allowed := allowedAttributes[tag]
+	seen := make(map[string]bool)
+	for _, a := range attrs {
+		check, ok := allowed[a.name]
+		if !ok || seen[a.name] {
+			continue
+		}
+		v, ok := check(a.value)
+		if !ok {
+			continue
+		}
+		seen[a.name] = true
+		b.WriteString(" " + a.name + `="` + html.EscapeString(v) + `"`)
+	}
+	if tag == "a" {
+		b.WriteString(` rel="` + linkRel + `"`)
+	}
+}
+
+var safeSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// safeURL accepts absolute http, https and mailto URLs. Browsers ignore
+// whitespace and control characters inside a URL, so they are removed before
+// the scheme is checked ("java\tscript:").
+func safeURL(raw string) (string, bool) {
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, raw)
+	u, err := url.Parse(cleaned)
+	if err != nil || !safeSchemes[strings.ToLower(u.Scheme)] {
+		return "", false
+	}
+	if u.Scheme != "mailto" && u.Host == "" {
+		return "", false
+	}
+	return u.String(), true
+}
+
+func anyValue(v string) (string, bool) {
+	return v, true
+}
+
+func number(v string) (string, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || n < 0 || n > 1000 {
+		return "", false
+	}
+	return strconv.Itoa(n), true
+}
+
+func lastOpen(open []string, name string) int {
+	for i := len(open) - 1; i >= 0; i-- {
+		if open[i] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsAny(open []string, names ...string) bool {
+	for _, o := range open {
+		for _, n := range names {
+			if o == n {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package richtext
+
+import (
+	"html"
+	"strings"
+)
+
+type tokenType int
+
+const (
+	textToken tokenType = iota
+	startTagToken
+	endTagToken
+	commentToken
+)
+
+type attribute struct {
+	name  string
+	value string
+}
+
+// htmlToken is a piece of an HTML document. For tags, data is the lower-case
+// element name; for text it is the unescaped text.
+type htmlToken struct {
+	typ   tokenType
+	data  string
+	attrs []attribute
+}
+
+func (t *htmlToken) attr(name string) string {
+	for _, a := range t.attrs {
+		if a.name == name {
+			return a.value
+		}
+	}
+	return ""
+}
+
+// rawTextTags hold text that isn't parsed as markup, up to their end tag.
+var rawTextTags = map[string]bool{
+	"script": true, "style": true, "textarea": true, "title": true, "xmp": true, "noscript": true,
+}
+
+// tokenizer splits HTML into tags and text. It is forgiving in the way
+// browsers are: a '<' that doesn't start a tag is text, and an unterminated
+// tag or comment swallows the rest of the input.
+type tokenizer struct {
+	s       string
+	pos     int
+	rawText string
+}
+
+func newTokenizer(s string) *tokenizer {
+	return &tokenizer{s: s}
+}
+
+func (z *tokenizer) next() (htmlToken, bool) {
+	if z.pos >= len(z.s) {
+		return htmlToken{}, false
+	}
+	if z.rawText != "" {
+		tag := z.rawText
+		z.rawText = ""
+		end := indexFold(z.s[z.pos:], "</"+tag)
+		start := z.pos
+		if end < 0 {
+			z.pos = len(z.s)
+		} else {
+			z.pos += end
+		}
+		if z.pos > start {
+			return htmlToken{typ: textToken, data: z.s[start:z.pos]}, true
+		}
+		return z.next()
+	}
+	if z.s[z.pos] == '<' {
+		if tok, ok := z.readMarkup(); ok {
+			return tok, true
+		}
+	}
+	start := z.pos
+	z.pos++ // a '<' that doesn't start a tag is text
+	for z.pos < len(z.s) && z.s[z.pos] != '<' {
+		z.pos++
+	}
+	return htmlToken{typ: textToken, data: html.UnescapeString(z.s[start:z.pos])}, true
+}
+
+// readMarkup reads the tag, comment or declaration at z.pos, reporting false
+// when the '<' there starts none of them.
+func (z *tokenizer) readMarkup() (htmlToken, bool) {
+	rest := z.s[z.pos:]
+	switch {
+	case strings.HasPrefix(rest, "<!--"):
+		end := strings.Index(rest[4:], "-->")
+		if end < 0 {
+			z.pos = len(z.s)
+		} else {
+			z.pos += 4 + end + 3
+		}
+		return htmlToken{typ: commentToken}, true
+	case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+		z.skipPast('>')
+		return htmlToken{typ: commentToken}, true
+	case len(rest) > 2 && rest[1] == '/' && isASCIILetter(rest[2]):
+		z.pos += 2
+		name := z.readName()
+		z.skipPast('>')
+		return htmlToken{typ: endTagToken, data: name}, true
+	case len(rest) > 1 && isASCIILetter(rest[1]):
+		z.pos++
+		tok := htmlToken{typ: startTagToken, data: z.readName()}
+		tok.attrs = z.readAttributes()
+		if rawTextTags[tok.data] {
+			z.rawText = tok.data
+		}
+		return tok, true
+	}
+	return htmlToken{}, false
+}
+
+func (z *tokenizer) readName() string {
+	start := z.pos
+	for z.pos < len(z.s) && !isSpace(z.s[z.pos]) && z.s[z.pos] != '>' && z.s[z.pos] != '/' {
+		z.pos++
+	}
+	return strings.ToLower(z.s[start:z.pos])
+}
+
+func (z *tokenizer) readAttributes() []attribute {
+	var attrs []attribute
+	for {
+		for z.pos < len(z.s) && (isSpace(z.s[z.pos]) || z.s[z.pos] == '/') {
+			z.pos++
+		}
+		if z.pos >= len(z.s) {
+			return attrs
+		}
+		if z.s[z.pos] == '>' {
+			z.pos++
+			return attrs
+		}
+		start := z.pos
+		for z.pos < len(z.s) && !isSpace(z.s[z.pos]) && z.s[z.pos] != '=' && z.s[z.pos] != '>' && z.s[z.pos] != '/' {
+			z.pos++
+		}
+		if z.pos == start {
+			z.pos++ // a stray '=' with no name
+			continue
+		}
+		a := attribute{name: strings.ToLower(z.s[start:z.pos])}
+		for z.pos < len(z.s) && isSpace(z.s[z.pos]) {
+			z.pos++
+		}
+		if z.pos < len(z.s) && z.s[z.pos] == '=' {
+			z.pos++
+			for z.pos < len(z.s) && isSpace(z.s[z.pos]) {
+				z.pos++
+			}
+			a.value = html.UnescapeString(z.readValue())
+		}
+		attrs = append(attrs, a)
+	}
+}
+
+func (z *tokenizer) readValue() string {
+	if z.pos >= len(z.s) {
+		return ""
+	}
+	if q := z.s[z.pos]; q == '"' || q == '\'' {
+		end := strings.IndexByte(z.s[z.pos+1:], q)
+		if end < 0 {
+			v := z.s[z.pos+1:]
+			z.pos = len(z.s)
+			return v
+		}
+		v := z.s[z.pos+1 : z.pos+1+end]
+		z.pos += end + 2
+		return v
+	}
+	start := z.pos
+	for z.pos < len(z.s) && !isSpace(z.s[z.pos]) && z.s[z.pos] != '>' {
+		z.pos++
+	}
+	return z.s[start:z.pos]
+}
+
+func (z *tokenizer) skipPast(c byte) {
+	end := strings.IndexByte(z.s[z.pos:], c)
+	if end < 0 {
+		z.pos = len(z.s)
+		return
+	}
+	z.pos += end + 1
+}
+
+func isASCIILetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// indexFold is strings.Index ignoring ASCII case. Unlike lower-casing the
+// input first, it keeps byte offsets valid for any UTF-8.
+func indexFold(s, substr string) int {
+	n := len(substr)
+	for i := 0; i+n <= len(s); i++ {
+		if strings.EqualFold(s[i:i+n], substr) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,165 @@
+// Package robots parses robots.txt files (RFC 9309) and answers whether a
+// crawler may fetch a path, including the common crawl-delay extension.
+package robots
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxCrawlDelay caps the crawl-delay a robots.txt can ask for, so a site
+// can't stall a crawler indefinitely.
+const MaxCrawlDelay = time.Minute
+
+type rule struct {
+	allow   bool
+	pattern string
+}
+
+// Rules are the rules of a robots.txt that apply to one user agent.
+type Rules struct {
+	rules      []rule
+	crawlDelay time.Duration
+}
+
+// AllowAll is the rule set of a site without a robots.txt.
+var AllowAll = &Rules{}
+
+// DisallowAll is the rule set of a site whose robots.txt couldn't be read
+// because of a server error, which RFC 9309 says to treat as a complete
+// disallow.
+var DisallowAll = &Rules{rules: []rule{{allow: false, pattern: "/"}}}
+
+type group struct {
+	agents     []string
+	rules      []rule
+	crawlDelay time.Duration
+}
+
+// Parse reads a robots.txt and returns the rules for userAgent. The group
+// naming the agent's product token ("mybot" in "MyBot/1.0") applies; failing
+// that, the "*" group does. Several groups for the same agent are merged.
+func Parse(body []byte, userAgent string) *Rules {
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	var groups []*group
+	var current *group
+	inAgents := false
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = &group{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			// An empty disallow allows everything, which is also what having
+			// no rule means.
+			if current != nil && value != "" {
+				current.rules = append(current.rules, rule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			inAgents = false
+			if current != nil {
+				// Negative, NaN and infinite delays are ignored; the rest
+				// are capped before conversion, which would overflow
+				if secs, err := strconv.ParseFloat(value, 64); err == nil && secs >= 0 && !math.IsInf(secs, 1) {
+					current.crawlDelay = time.Duration(min(secs, MaxCrawlDelay.Seconds()) * float64(time.Second))
+				}
+			}
+		}
+	}
+
+	rules := &Rules{}
+	for _, wildcard := range []bool{false, true} {
+		matched := false
+		for _, g := range groups {
+			for _, agent := range g.agents {
+				if (wildcard && agent == "*") || (!wildcard && token != "" && agent == token) {
+					rules.rules = append(rules.rules, g.rules...)
+					rules.crawlDelay = max(rules.crawlDelay, g.crawlDelay)
+					matched = true
+					break
+				}
+			}
+		}
+		if matched {
+			break
+		}
+	}
+	return rules
+}
+
+// Allowed reports whether path, which may include a query, may be fetched.
+// The longest matching rule decides; on a tie allow wins.
+func (r *Rules) Allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+	best, allowed := -1, true
+	for _, rl := range r.rules {
+		if !match(rl.pattern, path) {
+			continue
+		}
+		if n := len(rl.pattern); n > best || (n == best && rl.allow) {
+			best, allowed = n, rl.allow
+		}
+	}
+	return allowed
+}
+
+// CrawlDelay is the delay the site asks for between requests, zero if none,
+// and at most MaxCrawlDelay.
+func (r *Rules) CrawlDelay() time.Duration {
+	return r.crawlDelay
+}
+
+// match reports whether path matches pattern, a path prefix in which "*"
+// matches any sequence of characters and a trailing "$" anchors the end.
+func match(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = pattern[:len(pattern)-1]
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		last := i == len(parts)-2
+		if last && anchored {
+			return strings.HasSuffix(rest, part)
+		}
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(part):]
+	}
+	return !anchored || rest == ""
+}
@@ -0,0 +1,30 @@
+package robots
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCrawlDelay(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "2", want: 2 * time.Second},
+		{value: "0.5", want: 500 * time.Millisecond},
+		{value: "60", want: time.Minute},
+		{value: "3600", want: MaxCrawlDelay},
+		{value: "1e12", want: MaxCrawlDelay},
+		{value: "1e400", want: 0},
+		{value: "+Inf", want: 0},
+		{value: "NaN", want: 0},
+		{value: "-5", want: 0},
+		{value: "soon", want: 0},
+	}
+	for _, tt := range tests {
+		body := []byte("User-agent: *\nCrawl-delay: " + tt.value + "\nDisallow: /private\n")
+		if got := Parse(body, "JobTrackerBot/1.0").CrawlDelay(); got != tt.want {
+			t.Errorf("Crawl-delay: %s gives %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
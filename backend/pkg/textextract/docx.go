@@ -0,0 +1,88 @@
+package textextract
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// docxParts are the package parts read, in output order.
+var docxParts = []string{"word/document.xml", "word/footnotes.xml", "word/endnotes.xml"}
+
+func extractDOCX(r io.ReaderAt, size int64) (string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", err
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	if files["word/document.xml"] == nil {
+		return "", errors.New("docx: missing word/document.xml")
+	}
+
+	var b strings.Builder
+	for _, name := range docxParts {
+		f := files[name]
+		if f == nil {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+		// Bound decompression so a zip bomb can't exhaust memory.
+		err = writeWordML(&b, io.LimitReader(rc, 8*MaxTextLength))
+		rc.Close()
+		if err != nil {
+			return "", err
+		}
+		if b.Len() > MaxTextLength {
+			break
+		}
+	}
+	return b.String(), nil
+}
+
+// writeWordML writes the text runs of a WordprocessingML part, turning
+// paragraphs, breaks and tabs into whitespace.
+func writeWordML(b *strings.Builder, r io.Reader) error {
+	dec := xml.NewDecoder(r)
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			case "tc":
+				b.WriteByte('\t')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+}
@@ -0,0 +1,909 @@
+package textextract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// The PDF reader below understands just enough of the format to find the
+// pages, decompress their content streams and interpret the text operators.
+// It reads objects by scanning the file rather than trusting the xref table,
+// which also copes with the slightly broken files many generators produce.
+
+type (
+	pdfName    string
+	pdfKeyword string
+	pdfDict    map[string]any
+	pdfRef     struct{ num, gen int }
+	pdfStream  struct {
+		dict pdfDict
+		raw  []byte
+	}
+)
+
+const (
+	maxFormDepth = 4
+	// maxNesting bounds how deeply arrays and dictionaries may nest
+	maxNesting = 100
+	// maxDecompressed bounds the decompressed size of all of a document's
+	// streams together
+	maxDecompressed = 64 * MaxTextLength
+)
+
+var (
+	errPDFNesting  = errors.New("pdf: arrays or dictionaries nested too deeply")
+	errPDFTooLarge = errors.New("pdf: decompressed streams exceed the size limit")
+)
+
+var objHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+type pdfReader struct {
+	data    []byte
+	objects map[int]any
+	fonts   map[pdfRef]*pdfFont
+	decoded map[*pdfStream][]byte
+	// budget is how many more bytes streams may decompress to
+	budget int64
+	// err is the first error that stopped parsing part of the document
+	err error
+}
+
+func extractPDF(r io.ReaderAt, size int64) (string, error) {
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, 0); err != nil && err != io.EOF {
+		return "", err
+	}
+	p := &pdfReader{
+		data:    data,
+		objects: make(map[int]any),
+		fonts:   make(map[pdfRef]*pdfFont),
+		decoded: make(map[*pdfStream][]byte),
+		budget:  maxDecompressed,
+	}
+	p.loadObjects()
+	if p.err != nil {
+		return "", p.err
+	}
+	if len(p.objects) == 0 {
+		return "", errors.New("pdf: no objects found")
+	}
+
+	var b strings.Builder
+	for _, page := range p.pages() {
+		resources := p.inherited(page, "Resources")
+		for _, content := range p.pageContents(page) {
+			p.showContent(&b, content, p.dict(resources), 0)
+		}
+		b.WriteString("\n\n")
+		if p.err != nil {
+			return "", p.err
+		}
+		if b.Len() > MaxTextLength {
+			break
+		}
+	}
+	return b.String(), nil
+}
+
+// fail records err unless an earlier error was recorded already.
+func (p *pdfReader) fail(err error) {
+	if err != nil && p.err == nil {
+		p.err = err
+	}
+}
+
+// loadObjects finds every "N G obj" in the file, including objects packed
+// into object streams. Later definitions win, matching incremental updates.
+func (p *pdfReader) loadObjects() {
+	pos := 0
+	for {
+		loc := objHeader.FindSubmatchIndex(p.data[pos:])
+		if loc == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(p.data[pos+loc[2] : pos+loc[3]]))
+		lex := &pdfLexer{data: p.data, pos: pos + loc[1]}
+		value := lex.value()
+		if lex.err != nil {
+			p.fail(lex.err)
+			return
+		}
+		if dict, ok := value.(pdfDict); ok && lex.skipKeyword("stream") {
+			value = &pdfStream{dict: dict, raw: lex.streamData(dict)}
+		}
+		p.objects[num] = value
+		if lex.pos <= pos+loc[1] {
+			lex.pos = pos + loc[1]
+		}
+		pos = lex.pos
+	}
+
+	for _, obj := range p.objects {
+		if s, ok := obj.(*pdfStream); ok && s.dict["Type"] == pdfName("ObjStm") {
+			p.loadObjectStream(s)
+			if p.err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (p *pdfReader) loadObjectStream(s *pdfStream) {
+	data := p.decode(s)
+	n, _ := s.dict["N"].(float64)
+	first, _ := s.dict["First"].(float64)
+	if data == nil || first < 0 || int(first) > len(data) {
+		return
+	}
+	header := &pdfLexer{data: data[:int(first)]}
+	for i := 0; i < int(n); i++ {
+		num, ok1 := header.value().(float64)
+		offset, ok2 := header.value().(float64)
+		if !ok1 || !ok2 || offset < 0 || int(first+offset) >= len(data) {
+			return
+		}
+		if _, exists := p.objects[int(num)]; exists {
+			continue
+		}
+		lex := &pdfLexer{data: data, pos: int(first + offset)}
+		value := lex.value()
+		if lex.err != nil {
+			p.fail(lex.err)
+			return
+		}
+		p.objects[int(num)] = value
+	}
+}
+
+func (p *pdfReader) resolve(v any) any {
+	for i := 0; i < 32; i++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = p.objects[ref.num]
+	}
+	return nil
+}
+
+func (p *pdfReader) dict(v any) pdfDict {
+	switch d := p.resolve(v).(type) {
+	case pdfDict:
+		return d
+	case *pdfStream:
+		return d.dict
+	}
+	return nil
+}
+
+// decode returns the decompressed contents of a stream, or nil for filters
+// other than FlateDecode. Truncated deflate data yields what could be read.
+// Every stream is decompressed once, within the document's budget.
+func (p *pdfReader) decode(s *pdfStream) []byte {
+	if data, ok := p.decoded[s]; ok {
+		return data
+	}
+	data := p.inflate(s)
+	p.decoded[s] = data
+	return data
+}
+
+func (p *pdfReader) inflate(s *pdfStream) []byte {
+	var filters []any
+	switch f := p.resolve(s.dict["Filter"]).(type) {
+	case nil:
+		return s.raw
+	case pdfName:
+		filters = []any{f}
+	case []any:
+		filters = f
+	}
+	data := s.raw
+	for _, f := range filters {
+		if p.resolve(f) != pdfName("FlateDecode") {
+			return nil
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil
+		}
+		data, _ = io.ReadAll(io.LimitReader(zr, p.budget+1))
+		zr.Close()
+		if int64(len(data)) > p.budget {
+			p.budget = 0
+			p.fail(errPDFTooLarge)
+			return nil
+		}
+		p.budget -= int64(len(data))
+	}
+	return data
+}
+
+// pages walks the page tree from the catalog, falling back to every page
+// object in object-number order when the tree can't be followed.
+func (p *pdfReader) pages() []pdfDict {
+	var pages []pdfDict
+	seen := make(map[int]bool)
+	var walk func(v any)
+	walk = func(v any) {
+		if ref, ok := v.(pdfRef); ok {
+			if seen[ref.num] {
+				return
+			}
+			seen[ref.num] = true
+		}
+		node := p.dict(v)
+		switch node["Type"] {
+		case pdfName("Pages"):
+			kids, _ := p.resolve(node["Kids"]).([]any)
+			for _, kid := range kids {
+				walk(kid)
+			}
+		case pdfName("Page"):
+			pages = append(pages, node)
+		}
+	}
+	for _, obj := range p.objects {
+		if d, ok := obj.(pdfDict); ok && d["Type"] == pdfName("Catalog") {
+			walk(d["Pages"])
+			break
+		}
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	nums := make([]int, 0, len(p.objects))
+	for num, obj := range p.objects {
+		if d, ok := obj.(pdfDict); ok && d["Type"] == pdfName("Page") {
+			nums = append(nums, num)
+		}
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		pages = append(pages, p.objects[num].(pdfDict))
+	}
+	return pages
+}
+
+// inherited looks key up on the page and then up its Parent chain.
+func (p *pdfReader) inherited(page pdfDict, key string) any {
+	for i := 0; page != nil && i < 32; i++ {
+		if v, ok := page[key]; ok {
+			return v
+		}
+		page = p.dict(page["Parent"])
+	}
+	return nil
+}
+
+func (p *pdfReader) pageContents(page pdfDict) [][]byte {
+	var refs []any
+	switch c := p.resolve(page["Contents"]).(type) {
+	case *pdfStream:
+		refs = []any{c}
+	case []any:
+		refs = c
+	}
+	// A page's content may be split across streams at arbitrary token
+	// boundaries, so the pieces are joined before interpreting them.
+	var joined []byte
+	for _, ref := range refs {
+		if s, ok := p.resolve(ref).(*pdfStream); ok {
+			joined = append(joined, p.decode(s)...)
+			joined = append(joined, '\n')
+		}
+	}
+	return [][]byte{joined}
+}
+
+// showContent interprets a content stream, writing the strings drawn by the
+// text operators. Moves to a new line become newlines and wide horizontal
+// gaps become spaces.
+func (p *pdfReader) showContent(b *strings.Builder, content []byte, resources pdfDict, depth int) {
+	fonts := p.dict(resources["Font"])
+	xobjects := p.dict(resources["XObject"])
+	var font *pdfFont
+	var operands []any
+	lastY := math.NaN()
+
+	lex := &pdfLexer{data: content}
+	for b.Len() <= MaxTextLength {
+		tok, ok := lex.token()
+		if !ok {
+			p.fail(lex.err)
+			break
+		}
+		op, isOp := tok.(pdfKeyword)
+		if !isOp {
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch op {
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[len(operands)-2].(pdfName); ok {
+					font = p.font(fonts[string(name)])
+				}
+			}
+		case "Tj":
+			if len(operands) >= 1 {
+				b.WriteString(font.decode(operands[len(operands)-1]))
+			}
+		case "'", `"`:
+			b.WriteByte('\n')
+			if len(operands) >= 1 {
+				b.WriteString(font.decode(operands[len(operands)-1]))
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				items, _ := operands[len(operands)-1].([]any)
+				for _, item := range items {
+					if adj, ok := item.(float64); ok {
+						if adj < -200 {
+							b.WriteByte(' ')
+						}
+						continue
+					}
+					b.WriteString(font.decode(item))
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				tx, _ := operands[len(operands)-2].(float64)
+				ty, _ := operands[len(operands)-1].(float64)
+				if ty != 0 {
+					b.WriteByte('\n')
+				} else if tx > 0 {
+					b.WriteByte(' ')
+				}
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				y, _ := operands[len(operands)-1].(float64)
+				if !math.IsNaN(lastY) && y != lastY {
+					b.WriteByte('\n')
+				} else {
+					b.WriteByte(' ')
+				}
+				lastY = y
+			}
+		case "T*":
+			b.WriteByte('\n')
+		case "ET":
+			b.WriteByte(' ')
+		case "Do":
+			if len(operands) >= 1 && depth < maxFormDepth {
+				if name, ok := operands[len(operands)-1].(pdfName); ok {
+					if form, ok := p.resolve(xobjects[string(name)]).(*pdfStream); ok && form.dict["Subtype"] == pdfName("Form") {
+						formResources := p.dict(form.dict["Resources"])
+						if formResources == nil {
+							formResources = resources
+						}
+						p.showContent(b, p.decode(form), formResources, depth+1)
+					}
+				}
+			}
+		case "ID":
+			lex.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+}
+
+// Fonts
+
+type pdfFont struct {
+	cmap      map[uint32]string
+	codeWidth int
+	// simple fonts without a ToUnicode map are decoded as WinAnsi
+	simple bool
+}
+
+func (p *pdfReader) font(v any) *pdfFont {
+	ref, isRef := v.(pdfRef)
+	if isRef {
+		if f, ok := p.fonts[ref]; ok {
+			return f
+		}
+	}
+	d := p.dict(v)
+	f := &pdfFont{simple: d["Subtype"] != pdfName("Type0"), codeWidth: 1}
+	if !f.simple {
+		f.codeWidth = 2
+	}
+	if s, ok := p.resolve(d["ToUnicode"]).(*pdfStream); ok {
+		var err error
+		f.cmap, f.codeWidth, err = parseToUnicode(p.decode(s), f.codeWidth)
+		p.fail(err)
+	}
+	if isRef {
+		p.fonts[ref] = f
+	}
+	return f
+}
+
+func (f *pdfFont) decode(v any) string {
+	s, ok := v.(string)
+	if !ok {
+		return ""
+	}
+	if f == nil {
+		return winAnsi(s)
+	}
+	if f.cmap == nil {
+		if f.simple {
+			return winAnsi(s)
+		}
+		// Composite font without a ToUnicode map: the codes are glyph IDs
+		// and can't be turned back into text.
+		return ""
+	}
+
+	var b strings.Builder
+	for i := 0; i+f.codeWidth <= len(s); i += f.codeWidth {
+		var code uint32
+		for j := 0; j < f.codeWidth; j++ {
+			code = code<<8 | uint32(s[i+j])
+		}
+		if text, ok := f.cmap[code]; ok {
+			b.WriteString(text)
+		} else if f.simple {
+			b.WriteString(winAnsi(s[i : i+1]))
+		}
+	}
+	return b.String()
+}
+
+// parseToUnicode reads the bfchar and bfrange sections of a ToUnicode CMap.
+// The code width is taken from the source codes themselves.
+func parseToUnicode(data []byte, defaultWidth int) (map[uint32]string, int, error) {
+	cmap := make(map[uint32]string)
+	width := 0
+	lex := &pdfLexer{data: data}
+	var operands []any
+	mode := ""
+	for {
+		tok, ok := lex.token()
+		if !ok {
+			break
+		}
+		kw, isKw := tok.(pdfKeyword)
+		if !isKw {
+			operands = append(operands, tok)
+			if mode == "bfchar" && len(operands) == 2 {
+				src, _ := operands[0].(string)
+				dst, _ := operands[1].(string)
+				if width == 0 {
+					width = len(src)
+				}
+				cmap[codeOf(src)] = utf16BE(dst)
+				operands = operands[:0]
+			} else if mode == "bfrange" && len(operands) == 3 {
+				lo, _ := operands[0].(string)
+				hi, _ := operands[1].(string)
+				if width == 0 {
+					width = len(lo)
+				}
+				start, end := codeOf(lo), codeOf(hi)
+				if end-start > 0xFFFF {
+					end = start + 0xFFFF
+				}
+				switch dst := operands[2].(type) {
+				case string:
+					base := []rune(utf16BE(dst))
+					for code := start; code <= end && len(base) > 0; code++ {
+						r := append([]rune(nil), base...)
+						r[len(r)-1] += rune(code - start)
+						cmap[code] = string(r)
+					}
+				case []any:
+					for i, item := range dst {
+						if s, ok := item.(string); ok && start+uint32(i) <= end {
+							cmap[start+uint32(i)] = utf16BE(s)
+						}
+					}
+				}
+				operands = operands[:0]
+			}
+			continue
+		}
+		switch kw {
+		case "beginbfchar":
+			mode = "bfchar"
+		case "beginbfrange":
+			mode = "bfrange"
+		case "endbfchar", "endbfrange":
+			mode = ""
+		}
+		operands = operands[:0]
+	}
+	if width == 0 || width > 4 {
+		width = defaultWidth
+	}
+	return cmap, width, lex.err
+}
+
+func codeOf(s string) uint32 {
+	var code uint32
+	for i := 0; i < len(s) && i < 4; i++ {
+		code = code<<8 | uint32(s[i])
+	}
+	return code
+}
+
+func utf16BE(s string) string {
+	if len(s)%2 != 0 {
+		return winAnsi(s)
+	}
+	u := make([]uint16, len(s)/2)
+	for i := range u {
+		u[i] = uint16(s[2*i])<<8 | uint16(s[2*i+1])
+	}
+	return string(utf16.Decode(u))
+}
+
+// winAnsiHigh maps the 0x80–0x9F range where WinAnsiEncoding differs from
+// Latin-1; every other byte is its Latin-1 code point.
+var winAnsiHigh = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+	0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž',
+	0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+	0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+func winAnsi(s string) string {
+	r := make([]rune, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if c, ok := winAnsiHigh[s[i]]; ok {
+			r = append(r, c)
+		} else {
+			r = append(r, rune(s[i]))
+		}
+	}
+	return string(r)
+}
+
+// Lexer
+
+type pdfLexer struct {
+	data []byte
+	pos  int
+	// depth counts the arrays and dictionaries being parsed
+	depth int
+	// err is set, and the input abandoned, when they nest too deeply
+	err error
+}
+
+// enter is called on opening an array or dictionary. It reports false,
+// giving up on the input, once nesting exceeds maxNesting.
+func (l *pdfLexer) enter() bool {
+	if l.depth >= maxNesting {
+		l.err = errPDFNesting
+		l.pos = len(l.data)
+		return false
+	}
+	l.depth++
+	return true
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelim(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFSpace(c) {
+			l.pos++
+		} else if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		} else {
+			return
+		}
+	}
+}
+
+// skipKeyword consumes kw if it is the next token.
+func (l *pdfLexer) skipKeyword(kw string) bool {
+	l.skipSpace()
+	if bytes.HasPrefix(l.data[l.pos:], []byte(kw)) {
+		l.pos += len(kw)
+		return true
+	}
+	return false
+}
+
+// streamData returns the bytes between "stream" and "endstream", using a
+// direct Length when it checks out and searching for endstream otherwise.
+func (l *pdfLexer) streamData(dict pdfDict) []byte {
+	if l.pos < len(l.data) && l.data[l.pos] == '\r' {
+		l.pos++
+	}
+	if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+		l.pos++
+	}
+	start := l.pos
+	if n, ok := dict["Length"].(float64); ok && n >= 0 && start+int(n) <= len(l.data) {
+		end := start + int(n)
+		rest := bytes.TrimLeft(l.data[end:min(end+16, len(l.data))], " \r\n")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			l.pos = end
+			l.skipKeyword("endstream")
+			return l.data[start:end]
+		}
+	}
+	idx := bytes.Index(l.data[start:], []byte("endstream"))
+	if idx < 0 {
+		l.pos = len(l.data)
+		return l.data[start:]
+	}
+	l.pos = start + idx + len("endstream")
+	return bytes.TrimRight(l.data[start:start+idx], "\r\n")
+}
+
+// skipInlineImage moves past the binary data of an inline image, which runs
+// from after ID up to a whitespace-delimited EI.
+func (l *pdfLexer) skipInlineImage() {
+	for i := l.pos; i+2 < len(l.data); i++ {
+		if isPDFSpace(l.data[i]) && l.data[i+1] == 'E' && l.data[i+2] == 'I' &&
+			(i+3 == len(l.data) || isPDFSpace(l.data[i+3]) || isPDFDelim(l.data[i+3])) {
+			l.pos = i + 3
+			return
+		}
+	}
+	l.pos = len(l.data)
+}
+
+// value parses one object, turning "N G R" into a reference.
+func (l *pdfLexer) value() any {
+	tok, ok := l.token()
+	if !ok {
+		return nil
+	}
+	num, isNum := tok.(float64)
+	if !isNum || num != math.Trunc(num) || num < 0 {
+		return tok
+	}
+	save := l.pos
+	if gen, ok := l.token(); ok {
+		if g, ok := gen.(float64); ok {
+			if r, ok := l.token(); ok && r == pdfKeyword("R") {
+				return pdfRef{num: int(num), gen: int(g)}
+			}
+		}
+	}
+	l.pos = save
+	return tok
+}
+
+// token returns the next object or keyword. Arrays and dictionaries are
+// parsed whole; strings are returned as their raw bytes.
+func (l *pdfLexer) token() (any, bool) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, false
+	}
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+			l.pos++
+		}
+		return pdfName(unescapeName(l.data[start:l.pos])), true
+	case c == '(':
+		return l.literalString(), true
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		if !l.enter() {
+			return nil, false
+		}
+		defer func() { l.depth-- }()
+		l.pos += 2
+		d := pdfDict{}
+		for {
+			l.skipSpace()
+			if l.pos >= len(l.data) {
+				return d, true
+			}
+			if bytes.HasPrefix(l.data[l.pos:], []byte(">>")) {
+				l.pos += 2
+				return d, true
+			}
+			key, ok := l.token()
+			if !ok {
+				return d, true
+			}
+			name, isName := key.(pdfName)
+			if !isName {
+				continue
+			}
+			d[string(name)] = l.value()
+		}
+	case c == '<':
+		return l.hexString(), true
+	case c == '[':
+		if !l.enter() {
+			return nil, false
+		}
+		defer func() { l.depth-- }()
+		l.pos++
+		var arr []any
+		for {
+			l.skipSpace()
+			if l.pos >= len(l.data) {
+				return arr, true
+			}
+			if l.data[l.pos] == ']' {
+				l.pos++
+				return arr, true
+			}
+			arr = append(arr, l.value())
+		}
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		l.pos++
+		return pdfKeyword(string(c)), true
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		start := l.pos
+		l.pos++
+		for l.pos < len(l.data) && (l.data[l.pos] == '.' || (l.data[l.pos] >= '0' && l.data[l.pos] <= '9')) {
+			l.pos++
+		}
+		f, err := strconv.ParseFloat(string(l.data[start:l.pos]), 64)
+		if err != nil {
+			return pdfKeyword(l.data[start:l.pos]), true
+		}
+		return f, true
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+		l.pos++
+	}
+	if l.pos == start {
+		l.pos++
+	}
+	switch kw := string(l.data[start:l.pos]); kw {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	case "null":
+		return nil, true
+	default:
+		return pdfKeyword(kw), true
+	}
+}
+
+func (l *pdfLexer) literalString() string {
+	l.pos++ // (
+	var b []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return string(b)
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return string(b)
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
+
+func (l *pdfLexer) hexString() string {
+	l.pos++ // <
+	var b []byte
+	var hi byte
+	half := false
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		if c == '>' {
+			break
+		}
+		v, ok := hexValue(c)
+		if !ok {
+			continue
+		}
+		if half {
+			b = append(b, hi<<4|v)
+		} else {
+			hi = v
+		}
+		half = !half
+	}
+	if half {
+		b = append(b, hi<<4)
+	}
+	return string(b)
+}
+
+func hexValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func unescapeName(raw []byte) string {
+	if bytes.IndexByte(raw, '#') < 0 {
+		return string(raw)
+	}
+	var b []byte
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i+2 < len(raw) {
+			h, ok1 := hexValue(raw[i+1])
+			lo, ok2 := hexValue(raw[i+2])
+			if ok1 && ok2 {
+				b = append(b, h<<4|lo)
+				i += 2
+				continue
+			}
+		}
+		b = append(b, raw[i])
+	}
+	return string(b)
+}
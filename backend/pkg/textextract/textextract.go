@@ -0,0 +1,73 @@
+// Package textextract pulls plain text out of PDF and DOCX documents so
+// their contents can be searched. It aims for readable text from the résumés
+// and cover letters people actually upload, not for a faithful layout.
+package textextract
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"job-tracker-backend/pkg/filetype"
+)
+
+// MaxTextLength caps the extracted text; anything beyond it is dropped.
+const MaxTextLength = 1 << 20
+
+var ErrUnsupported = errors.New("text extraction is not supported for this file type")
+
+// Extract returns the text of a document of the given MIME type. A panic
+// while parsing a malformed document is returned as an error.
+func Extract(mimeType string, r io.ReaderAt, size int64) (text string, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			text, err = "", fmt.Errorf("textextract: %s: %v", mimeType, v)
+		}
+	}()
+	switch mimeType {
+	case filetype.PDF:
+		text, err = extractPDF(r, size)
+	case filetype.DOCX:
+		text, err = extractDOCX(r, size)
+	default:
+		return "", ErrUnsupported
+	}
+	if err != nil {
+		return "", err
+	}
+	return normalize(text), nil
+}
+
+var (
+	spaceRun   = regexp.MustCompile(`[ \t\f\v\x{00a0}]+`)
+	newlineRun = regexp.MustCompile(`\n{3,}`)
+)
+
+// normalize collapses runs of whitespace, trims every line and truncates the
+// result to MaxTextLength on a rune boundary.
+func normalize(text string) string {
+	text = strings.ToValidUTF8(text, "")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.ReplaceAll(text, "\x00", "")
+	text = spaceRun.ReplaceAllString(text, " ")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text = newlineRun.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	text = strings.TrimSpace(text)
+
+	if len(text) > MaxTextLength {
+		cut := MaxTextLength
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut]
+	}
+	return text
+}
@@ -0,0 +1,160 @@
+package textmatch
+
+// Skill is an entry of the skills dictionary. Aliases are matched as whole
+// token sequences, case-insensitively; CaseSensitive aliases are for names
+// that are also ordinary words ("Go", "R") and only match as written.
+type Skill struct {
+	Name          string
+	Category      string
+	Aliases       []string
+	CaseSensitive []string
+}
+
+// Skills is the curated dictionary used by ExtractSkills. Every skill
+// matches its own name unless that name is listed as case-sensitive.
+var Skills = []Skill{
+	// Languages
+	{Name: "Go", Category: "language", Aliases: []string{"golang"}, CaseSensitive: []string{"Go"}},
+	{Name: "Python", Category: "language"},
+	{Name: "Java", Category: "language"},
+	{Name: "JavaScript", Category: "language", Aliases: []string{"js", "ecmascript", "es6"}},
+	{Name: "TypeScript", Category: "language", Aliases: []string{"ts"}},
+	{Name: "Ruby", Category: "language"},
+	{Name: "PHP", Category: "language"},
+	{Name: "C", Category: "language", CaseSensitive: []string{"C"}},
+	{Name: "C++", Category: "language", Aliases: []string{"cpp"}},
+	{Name: "C#", Category: "language", Aliases: []string{"csharp"}},
+	{Name: "Rust", Category: "language"},
+	{Name: "Kotlin", Category: "language"},
+	{Name: "Swift", Category: "language"},
+	{Name: "Objective-C", Category: "language", Aliases: []string{"objective c", "objc"}},
+	{Name: "Scala", Category: "language"},
+	{Name: "Elixir", Category: "language"},
+	{Name: "Erlang", Category: "language"},
+	{Name: "Haskell", Category: "language"},
+	{Name: "Clojure", Category: "language"},
+	{Name: "Dart", Category: "language"},
+	{Name: "R", Category: "language", CaseSensitive: []string{"R"}},
+	{Name: "Perl", Category: "language"},
+	{Name: "Lua", Category: "language"},
+	{Name: "Bash", Category: "language", Aliases: []string{"shell scripting", "shell"}},
+	{Name: "SQL", Category: "language"},
+	{Name: "HTML", Category: "language", Aliases: []string{"html5"}},
+	{Name: "CSS", Category: "language", Aliases: []string{"css3"}},
+	{Name: "Solidity", Category: "language"},
+
+	// Frameworks and libraries
+	{Name: "React", Category: "framework", Aliases: []string{"react.js", "reactjs"}},
+	{Name: "React Native", Category: "framework"},
+	{Name: "Next.js", Category: "framework", Aliases: []string{"nextjs"}},
+	{Name: "Vue", Category: "framework", Aliases: []string{"vue.js", "vuejs"}},
+	{Name: "Nuxt", Category: "framework", Aliases: []string{"nuxt.js"}},
+	{Name: "Angular", Category: "framework", Aliases: []string{"angularjs"}},
+	{Name: "Svelte", Category: "framework", Aliases: []string{"sveltekit"}},
+	{Name: "Redux", Category: "framework"},
+	{Name: "Tailwind CSS", Category: "framework", Aliases: []string{"tailwind"}},
+	{Name: "Node.js", Category: "framework", Aliases: []string{"node", "nodejs"}},
+	{Name: "Express", Category: "framework", Aliases: []string{"express.js", "expressjs"}},
+	{Name: "NestJS", Category: "framework", Aliases: []string{"nest.js"}},
+	{Name: "Django", Category: "framework"},
+	{Name: "Flask", Category: "framework"},
+	{Name: "FastAPI", Category: "framework"},
+	{Name: "Spring", Category: "framework", Aliases: []string{"spring boot", "springboot"}},
+	{Name: "Ruby on Rails", Category: "framework", Aliases: []string{"rails", "ror"}},
+	{Name: "Laravel", Category: "framework"},
+	{Name: ".NET", Category: "framework", Aliases: []string{"dotnet", "asp.net", ".net core"}},
+	{Name: "gRPC", Category: "framework"},
+	{Name: "GraphQL", Category: "framework"},
+	{Name: "REST", Category: "framework", Aliases: []string{"rest api", "restful", "rest apis"}},
+	{Name: "Flutter", Category: "framework"},
+	{Name: "SwiftUI", Category: "framework"},
+	{Name: "Jetpack Compose", Category: "framework"},
+	{Name: "Pandas", Category: "framework"},
+	{Name: "NumPy", Category: "framework"},
+	{Name: "scikit-learn", Category: "framework", Aliases: []string{"sklearn", "scikit learn"}},
+	{Name: "TensorFlow", Category: "framework"},
+	{Name: "PyTorch", Category: "framework"},
+	{Name: "Keras", Category: "framework"},
+	{Name: "Spark", Category: "framework", Aliases: []string{"apache spark", "pyspark"}},
+	{Name: "Hadoop", Category: "framework"},
+	{Name: "Airflow", Category: "framework", Aliases: []string{"apache airflow"}},
+	{Name: "dbt", Category: "framework"},
+	{Name: "LangChain", Category: "framework"},
+
+	// Data stores and messaging
+	{Name: "PostgreSQL", Category: "data", Aliases: []string{"postgres", "psql"}},
+	{Name: "MySQL", Category: "data", Aliases: []string{"mariadb"}},
+	{Name: "SQLite", Category: "data"},
+	{Name: "SQL Server", Category: "data", Aliases: []string{"mssql"}},
+	{Name: "Oracle", Category: "data"},
+	{Name: "MongoDB", Category: "data", Aliases: []string{"mongo"}},
+	{Name: "Redis", Category: "data"},
+	{Name: "Cassandra", Category: "data"},
+	{Name: "DynamoDB", Category: "data"},
+	{Name: "Elasticsearch", Category: "data", Aliases: []string{"elastic search", "opensearch"}},
+	{Name: "Snowflake", Category: "data"},
+	{Name: "BigQuery", Category: "data"},
+	{Name: "Redshift", Category: "data"},
+	{Name: "ClickHouse", Category: "data"},
+	{Name: "Kafka", Category: "data", Aliases: []string{"apache kafka"}},
+	{Name: "RabbitMQ", Category: "data"},
+	{Name: "NATS", Category: "data"},
+
+	// Cloud and infrastructure
+	{Name: "AWS", Category: "cloud", Aliases: []string{"amazon web services"}},
+	{Name: "GCP", Category: "cloud", Aliases: []string{"google cloud", "google cloud platform"}},
+	{Name: "Azure", Category: "cloud", Aliases: []string{"microsoft azure"}},
+	{Name: "Docker", Category: "cloud", Aliases: []string{"containers"}},
+	{Name: "Kubernetes", Category: "cloud", Aliases: []string{"k8s"}},
+	{Name: "Helm", Category: "cloud"},
+	{Name: "Terraform", Category: "cloud"},
+	{Name: "Ansible", Category: "cloud"},
+	{Name: "Pulumi", Category: "cloud"},
+	{Name: "Serverless", Category: "cloud", Aliases: []string{"aws lambda", "lambda"}},
+	{Name: "Linux", Category: "cloud"},
+	{Name: "Nginx", Category: "cloud"},
+	{Name: "CI/CD", Category: "cloud", Aliases: []string{"continuous integration", "continuous delivery", "continuous deployment"}},
+	{Name: "GitHub Actions", Category: "cloud"},
+	{Name: "GitLab CI", Category: "cloud"},
+	{Name: "Jenkins", Category: "cloud"},
+	{Name: "Git", Category: "cloud"},
+	{Name: "Prometheus", Category: "cloud"},
+	{Name: "Grafana", Category: "cloud"},
+	{Name: "Datadog", Category: "cloud"},
+	{Name: "OpenTelemetry", Category: "cloud"},
+
+	// Practices and domains
+	{Name: "Microservices", Category: "practice", Aliases: []string{"microservice", "micro services"}},
+	{Name: "Distributed Systems", Category: "practice", Aliases: []string{"distributed system"}},
+	{Name: "System Design", Category: "practice"},
+	{Name: "Event-Driven Architecture", Category: "practice", Aliases: []string{"event driven", "event-driven"}},
+	{Name: "Domain-Driven Design", Category: "practice", Aliases: []string{"ddd", "domain driven design"}},
+	{Name: "Test-Driven Development", Category: "practice", Aliases: []string{"tdd", "test driven development"}},
+	{Name: "Unit Testing", Category: "practice", Aliases: []string{"unit tests", "automated testing"}},
+	{Name: "Agile", Category: "practice", Aliases: []string{"scrum", "kanban"}},
+	{Name: "DevOps", Category: "practice"},
+	{Name: "SRE", Category: "practice", Aliases: []string{"site reliability engineering", "site reliability"}},
+	{Name: "Security", Category: "practice", Aliases: []string{"application security", "appsec", "owasp"}},
+	{Name: "OAuth", Category: "practice", Aliases: []string{"oauth2", "openid connect", "oidc"}},
+	{Name: "Machine Learning", Category: "practice", Aliases: []string{"ml"}},
+	{Name: "Deep Learning", Category: "practice"},
+	{Name: "NLP", Category: "practice", Aliases: []string{"natural language processing"}},
+	{Name: "LLM", Category: "practice", Aliases: []string{"llms", "large language models", "generative ai", "genai"}},
+	{Name: "Computer Vision", Category: "practice"},
+	{Name: "Data Engineering", Category: "practice", Aliases: []string{"data pipelines", "etl"}},
+	{Name: "Data Analysis", Category: "practice", Aliases: []string{"data analytics", "analytics"}},
+	{Name: "Statistics", Category: "practice"},
+	{Name: "Mobile Development", Category: "practice", Aliases: []string{"ios", "android"}},
+	{Name: "Frontend", Category: "practice", Aliases: []string{"front-end", "front end"}},
+	{Name: "Backend", Category: "practice", Aliases: []string{"back-end", "back end"}},
+	{Name: "Full Stack", Category: "practice", Aliases: []string{"full-stack", "fullstack"}},
+	{Name: "Accessibility", Category: "practice", Aliases: []string{"a11y", "wcag"}},
+	{Name: "Performance Optimization", Category: "practice", Aliases: []string{"performance tuning"}},
+	{Name: "API Design", Category: "practice"},
+	{Name: "Technical Leadership", Category: "practice", Aliases: []string{"tech lead", "technical lead"}},
+	{Name: "Mentoring", Category: "practice", Aliases: []string{"mentorship"}},
+	{Name: "Product Management", Category: "practice"},
+	{Name: "UX Design", Category: "practice", Aliases: []string{"ux", "user experience"}},
+	{Name: "Figma", Category: "practice"},
+	{Name: "Jira", Category: "practice"},
+}
@@ -0,0 +1,28 @@
+package textmatch
+
+// stopWords are English function words plus words that appear in nearly
+// every posting or résumé and say nothing about fit.
+var stopWords = toSet(
+	"a", "about", "above", "after", "again", "against", "all", "also", "am", "an", "and", "any", "are", "as",
+	"at", "be", "because", "been", "before", "being", "below", "between", "both", "but", "by", "can", "could",
+	"did", "do", "does", "doing", "down", "during", "each", "etc", "few", "for", "from", "further", "had", "has",
+	"have", "having", "he", "her", "here", "hers", "him", "his", "how", "i", "if", "in", "into", "is", "it",
+	"its", "itself", "just", "may", "me", "might", "more", "most", "must", "my", "no", "nor", "not", "now",
+	"of", "off", "on", "once", "only", "or", "other", "our", "ours", "out", "over", "own", "per", "same",
+	"shall", "she", "should", "so", "some", "such", "than", "that", "the", "their", "theirs", "them", "then",
+	"there", "these", "they", "this", "those", "through", "to", "too", "under", "until", "up", "upon", "us",
+	"very", "via", "was", "we", "well", "were", "what", "when", "where", "which", "while", "who", "whom",
+	"why", "will", "with", "within", "without", "would", "you", "your", "yours", "yourself",
+	// posting and résumé boilerplate
+	"ability", "able", "candidate", "company", "experience", "experienced", "including", "job", "looking",
+	"new", "opportunity", "plus", "position", "preferred", "required", "requirements", "responsibilities", "role", "strong",
+	"team", "teams", "work", "working", "year", "years", "e.g", "i.e",
+)
+
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
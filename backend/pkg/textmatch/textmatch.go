@@ -0,0 +1,314 @@
+// Package textmatch compares a résumé with a job description locally: it
+// finds known skills in both texts and measures their overall similarity
+// with TF-IDF weighted cosine similarity.
+package textmatch
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Weights of the two components of Report.Score.
+const (
+	skillWeight      = 0.6
+	similarityWeight = 0.4
+)
+
+// maxPhraseTokens is the longest alias, in tokens, of any skill.
+const maxPhraseTokens = 4
+
+var tokenPattern = regexp.MustCompile(`\.?[\pL\pN][\pL\pN+#.\-]*`)
+
+type token struct {
+	raw   string
+	lower string
+}
+
+// tokenize splits text into words, keeping the characters that matter in
+// technology names ("c++", "c#", ".net", "node.js") and dropping trailing
+// punctuation.
+func tokenize(text string) []token {
+	words := tokenPattern.FindAllString(text, -1)
+	tokens := make([]token, 0, len(words))
+	for _, w := range words {
+		w = strings.TrimRight(w, ".-")
+		if w == "" {
+			continue
+		}
+		tokens = append(tokens, token{raw: w, lower: strings.ToLower(w)})
+	}
+	return tokens
+}
+
+type phraseKey struct {
+	phrase        string
+	caseSensitive bool
+}
+
+// skillIndex maps alias token sequences to skill names.
+var skillIndex = buildSkillIndex()
+
+// skillTerms maps every lower-cased alias, case-sensitive ones included, to
+// its skill, so keywords can be recognised as a skill already accounted for.
+var skillTerms = func() map[string]string {
+	terms := make(map[string]string, len(skillIndex))
+	for key, name := range skillIndex {
+		terms[strings.ToLower(key.phrase)] = name
+	}
+	return terms
+}()
+
+func buildSkillIndex() map[phraseKey]string {
+	index := make(map[phraseKey]string)
+	add := func(alias string, caseSensitive bool, name string) {
+		var parts []string
+		for _, t := range tokenize(alias) {
+			if caseSensitive {
+				parts = append(parts, t.raw)
+			} else {
+				parts = append(parts, t.lower)
+			}
+		}
+		if len(parts) > 0 && len(parts) <= maxPhraseTokens {
+			index[phraseKey{strings.Join(parts, " "), caseSensitive}] = name
+		}
+	}
+	for _, s := range Skills {
+		sensitive := make(map[string]bool)
+		for _, a := range s.CaseSensitive {
+			sensitive[a] = true
+			add(a, true, s.Name)
+		}
+		if !sensitive[s.Name] {
+			add(s.Name, false, s.Name)
+		}
+		for _, a := range s.Aliases {
+			add(a, false, s.Name)
+		}
+	}
+	return index
+}
+
+// ExtractSkills returns the dictionary skills mentioned in text, sorted by
+// name. Longer phrases win over the words they contain, so "React Native"
+// is not also counted as "React".
+func ExtractSkills(text string) []string {
+	tokens := tokenize(text)
+	found := make(map[string]bool)
+	for i := 0; i < len(tokens); {
+		matched := 0
+		for n := min(maxPhraseTokens, len(tokens)-i); n > 0 && matched == 0; n-- {
+			raw := make([]string, n)
+			lower := make([]string, n)
+			for j := 0; j < n; j++ {
+				raw[j] = tokens[i+j].raw
+				lower[j] = tokens[i+j].lower
+			}
+			if name, ok := skillIndex[phraseKey{strings.Join(lower, " "), false}]; ok {
+				found[name] = true
+				matched = n
+			} else if name, ok := skillIndex[phraseKey{strings.Join(raw, " "), true}]; ok {
+				found[name] = true
+				matched = n
+			}
+		}
+		if matched == 0 {
+			matched = 1
+		}
+		i += matched
+	}
+	return sortedKeys(found)
+}
+
+// Terms returns the lower-cased words of text that carry meaning: stop
+// words, numbers and single letters are dropped.
+func Terms(text string) []string {
+	var terms []string
+	for _, t := range tokenize(text) {
+		if len(t.lower) < 2 || stopWords[t.lower] || isNumeric(t.lower) {
+			continue
+		}
+		terms = append(terms, t.lower)
+	}
+	return terms
+}
+
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && r != '.' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// Corpus holds document frequencies for IDF weighting. Build it from the
+// texts a user's postings have in common so boilerplate such as "team" or
+// "experience" counts for little.
+type Corpus struct {
+	docs int
+	df   map[string]int
+}
+
+func NewCorpus(texts []string) *Corpus {
+	c := &Corpus{df: make(map[string]int)}
+	for _, text := range texts {
+		c.Add(text)
+	}
+	return c
+}
+
+func (c *Corpus) Add(text string) {
+	c.docs++
+	seen := make(map[string]bool)
+	for _, term := range Terms(text) {
+		if !seen[term] {
+			seen[term] = true
+			c.df[term]++
+		}
+	}
+}
+
+// idf is the smoothed inverse document frequency, which stays positive for
+// terms that occur everywhere and for terms the corpus has never seen.
+func (c *Corpus) idf(term string) float64 {
+	return math.Log(float64(1+c.docs)/float64(1+c.df[term])) + 1
+}
+
+// Vector returns the TF-IDF weights of text, using sublinear term frequency.
+func (c *Corpus) Vector(text string) map[string]float64 {
+	tf := make(map[string]int)
+	for _, term := range Terms(text) {
+		tf[term]++
+	}
+	v := make(map[string]float64, len(tf))
+	for term, n := range tf {
+		v[term] = (1 + math.Log(float64(n))) * c.idf(term)
+	}
+	return v
+}
+
+// Cosine is the cosine similarity of two weight vectors, in [0, 1].
+func Cosine(a, b map[string]float64) float64 {
+	var dot, na, nb float64
+	for term, wa := range a {
+		na += wa * wa
+		if wb, ok := b[term]; ok {
+			dot += wa * wb
+		}
+	}
+	for _, wb := range b {
+		nb += wb * wb
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// Report describes how well a résumé matches a job description.
+type Report struct {
+	// Score combines skill coverage and text similarity, from 0 to 100.
+	Score int `json:"score"`
+	// SkillCoverage is the share of the job's skills found in the résumé.
+	SkillCoverage float64 `json:"skill_coverage"`
+	// Similarity is the TF-IDF cosine similarity of the two texts.
+	Similarity      float64  `json:"similarity"`
+	MatchedSkills   []string `json:"matched_skills"`
+	MissingSkills   []string `json:"missing_skills"`
+	ExtraSkills     []string `json:"extra_skills"`
+	MissingKeywords []string `json:"missing_keywords"`
+}
+
+// maxMissingKeywords bounds Report.MissingKeywords.
+const maxMissingKeywords = 15
+
+// Match compares a résumé with a job description. A nil corpus weights all
+// terms equally.
+func Match(resume, job string, corpus *Corpus) *Report {
+	if corpus == nil {
+		corpus = NewCorpus(nil)
+	}
+	resumeSkills := ExtractSkills(resume)
+	jobSkills := ExtractSkills(job)
+
+	have := make(map[string]bool, len(resumeSkills))
+	for _, s := range resumeSkills {
+		have[s] = true
+	}
+	wanted := make(map[string]bool, len(jobSkills))
+	report := &Report{MatchedSkills: []string{}, MissingSkills: []string{}, ExtraSkills: []string{}}
+	for _, s := range jobSkills {
+		wanted[s] = true
+		if have[s] {
+			report.MatchedSkills = append(report.MatchedSkills, s)
+		} else {
+			report.MissingSkills = append(report.MissingSkills, s)
+		}
+	}
+	for _, s := range resumeSkills {
+		if !wanted[s] {
+			report.ExtraSkills = append(report.ExtraSkills, s)
+		}
+	}
+
+	resumeVec := corpus.Vector(resume)
+	jobVec := corpus.Vector(job)
+	report.Similarity = round(Cosine(resumeVec, jobVec))
+	report.MissingKeywords = missingKeywords(jobVec, resumeVec, have)
+
+	// Without any recognised skills in the posting, similarity is all there
+	// is to go on.
+	var score float64
+	if len(jobSkills) > 0 {
+		report.SkillCoverage = round(float64(len(report.MatchedSkills)) / float64(len(jobSkills)))
+		score = skillWeight*report.SkillCoverage + similarityWeight*report.Similarity
+	} else {
+		score = report.Similarity
+	}
+	report.Score = int(math.Round(score * 100))
+	return report
+}
+
+// missingKeywords returns the job's highest-weighted terms the résumé
+// doesn't contain, leaving out those naming a skill it has under another
+// alias ("go" when the résumé says "golang").
+func missingKeywords(job, resume map[string]float64, skills map[string]bool) []string {
+	type weighted struct {
+		term   string
+		weight float64
+	}
+	var missing []weighted
+	for term, w := range job {
+		if _, ok := resume[term]; ok || skills[skillTerms[term]] {
+			continue
+		}
+		missing = append(missing, weighted{term, w})
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		if missing[i].weight != missing[j].weight {
+			return missing[i].weight > missing[j].weight
+		}
+		return missing[i].term < missing[j].term
+	})
+	keywords := make([]string, 0, maxMissingKeywords)
+	for i := 0; i < len(missing) && i < maxMissingKeywords; i++ {
+		keywords = append(keywords, missing[i].term)
+	}
+	return keywords
+}
+
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -50,7 +50,7 @@ function JobTrackerApp() {
       setSearchResults(result.jobs || []);
       showMessage(`Found ${result.count} jobs`, 'success');
     } catch (error) {
-      showMessage('Search failed: ' + error.message, 'error');
+      showMessage('Search failed: ' + (error.response?.data?.error || error.message), 'error');
     } finally {
       setSearching(false);
     }
@@ -1,4 +1,5 @@
 import { createContext, useContext, useState, useCallback } from 'react';
+import { clearSession, logoutUser } from '../services/api';
 
 const AuthContext = createContext(null);
 
@@ -9,16 +10,21 @@ export function AuthProvider({ children }) {
     return u ? JSON.parse(u) : null;
   });
 
-  const login = useCallback((newToken, newUser) => {
+  const login = useCallback((newToken, newUser, refreshToken) => {
     localStorage.setItem('token', newToken);
+    localStorage.setItem('refreshToken', refreshToken);
     localStorage.setItem('user', JSON.stringify(newUser));
     setToken(newToken);
     setUser(newUser);
   }, []);
 
-  const logout = useCallback(() => {
-    localStorage.removeItem('token');
-    localStorage.removeItem('user');
+  const logout = useCallback(async () => {
+    try {
+      await logoutUser();
+    } catch {
+      // The session is forgotten locally even if the server can't be told.
+    }
+    clearSession();
     setToken(null);
     setUser(null);
   }, []);
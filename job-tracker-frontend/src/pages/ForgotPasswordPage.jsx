@@ -0,0 +1,129 @@
+import { useState } from 'react';
+import { Link } from 'react-router-dom';
+import { forgotPassword } from '../services/api';
+
+export default function ForgotPasswordPage() {
+  const [email, setEmail] = useState('');
+  const [error, setError] = useState(null);
+  const [notice, setNotice] = useState(null);
+  const [loading, setLoading] = useState(false);
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    setError(null);
+    setLoading(true);
+    try {
+      const res = await forgotPassword(email);
+      setNotice(res.message);
+    } catch (err) {
+      setError(err.response?.data?.error || 'Could not send the reset email');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <div style={styles.container}>
+      <div style={styles.card}>
+        <h2 style={styles.title}>Job Tracker</h2>
+        <p style={styles.subtitle}>Reset your password</p>
+        {error && <div style={styles.error}>{error}</div>}
+        {notice ? (
+          <div style={styles.notice}>{notice}</div>
+        ) : (
+          <form onSubmit={handleSubmit}>
+            <input
+              style={styles.input}
+              type="email"
+              placeholder="Email"
+              value={email}
+              onChange={(e) => setEmail(e.target.value)}
+              required
+            />
+            <button style={styles.button} type="submit" disabled={loading}>
+              {loading ? 'Sending...' : 'Send Reset Link'}
+            </button>
+          </form>
+        )}
+        <p style={styles.link}>
+          <Link to="/login">Back to sign in</Link>
+        </p>
+      </div>
+    </div>
+  );
+}
+
+const styles = {
+  container: {
+    minHeight: '100vh',
+    backgroundColor: '#f5f5f5',
+    display: 'flex',
+    alignItems: 'center',
+    justifyContent: 'center',
+  },
+  card: {
+    backgroundColor: 'white',
+    borderRadius: '8px',
+    padding: '40px',
+    boxShadow: '0 2px 8px rgba(0,0,0,0.1)',
+    width: '100%',
+    maxWidth: '400px',
+  },
+  title: {
+    margin: '0 0 4px 0',
+    fontSize: '24px',
+    fontWeight: '600',
+    color: '#0d6efd',
+    textAlign: 'center',
+  },
+  subtitle: {
+    margin: '0 0 24px 0',
+    fontSize: '14px',
+    color: '#6c757d',
+    textAlign: 'center',
+  },
+  error: {
+    backgroundColor: '#f8d7da',
+    color: '#721c24',
+    padding: '10px 12px',
+    borderRadius: '4px',
+    marginBottom: '16px',
+    fontSize: '14px',
+  },
+  notice: {
+    backgroundColor: '#d1e7dd',
+    color: '#0f5132',
+    padding: '10px 12px',
+    borderRadius: '4px',
+    marginBottom: '16px',
+    fontSize: '14px',
+  },
+  input: {
+    display: 'block',
+    width: '100%',
+    padding: '10px 12px',
+    marginBottom: '12px',
+    border: '1px solid #dee2e6',
+    borderRadius: '4px',
+    fontSize: '14px',
+    boxSizing: 'border-box',
+  },
+  button: {
+    display: 'block',
+    width: '100%',
+    padding: '10px',
+    backgroundColor: '#0d6efd',
+    color: 'white',
+    border: 'none',
+    borderRadius: '4px',
+    fontSize: '14px',
+    cursor: 'pointer',
+    marginTop: '4px',
+  },
+  link: {
+    marginTop: '20px',
+    textAlign: 'center',
+    fontSize: '14px',
+    color: '#6c757d',
+  },
+};
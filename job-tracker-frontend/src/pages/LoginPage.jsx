@@ -1,6 +1,6 @@
-import { useState } from 'react';
-import { useNavigate, Link } from 'react-router-dom';
-import { loginUser } from '../services/api';
+import { useEffect, useState } from 'react';
+import { useNavigate, useLocation, Link } from 'react-router-dom';
+import { loginUser, completeLogin, resendVerification, getSSOProviders, ssoLoginURL } from '../services/api';
 import { useAuth } from '../contexts/AuthContext';
 
 export default function LoginPage() {
@@ -8,30 +8,122 @@ export default function LoginPage() {
   const [password, setPassword] = useState('');
   const [error, setError] = useState(null);
   const [loading, setLoading] = useState(false);
+  const [unverified, setUnverified] = useState(false);
+  const [notice, setNotice] = useState(null);
+  const location = useLocation();
+  // A sign-in through an identity provider may arrive here for its second
+  // factor
+  const [challenge, setChallenge] = useState(location.state?.challenge || null);
+  const [providers, setProviders] = useState([]);
+  const [code, setCode] = useState('');
   const { login } = useAuth();
   const navigate = useNavigate();
 
   const handleSubmit = async (e) => {
     e.preventDefault();
     setError(null);
+    setNotice(null);
+    setUnverified(false);
     setLoading(true);
     try {
       const data = await loginUser({ email, password });
-      login(data.token, data.user);
+      if (data.two_factor_required) {
+        setChallenge(data.challenge_token);
+        return;
+      }
+      login(data.token, data.user, data.refresh_token);
       navigate('/');
     } catch (err) {
+      setUnverified(err.response?.status === 403);
       setError(err.response?.data?.error || 'Login failed');
     } finally {
       setLoading(false);
     }
   };
 
+  useEffect(() => {
+    getSSOProviders()
+      .then(setProviders)
+      .catch(() => setProviders([]));
+  }, []);
+
+  const handleCode = async (e) => {
+    e.preventDefault();
+    setError(null);
+    setLoading(true);
+    try {
+      const data = await completeLogin({ challenge_token: challenge, code });
+      login(data.token, data.user, data.refresh_token);
+      navigate('/');
+    } catch (err) {
+      setError(err.response?.data?.error || 'Verification failed');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleCancelCode = () => {
+    setChallenge(null);
+    setCode('');
+    setError(null);
+  };
+
+  const handleResend = async () => {
+    try {
+      const res = await resendVerification(email);
+      setError(null);
+      setUnverified(false);
+      setNotice(res.message);
+    } catch (err) {
+      setError(err.response?.data?.error || 'Could not resend the email');
+    }
+  };
+
+  if (challenge) {
+    return (
+      <div style={styles.container}>
+        <div style={styles.card}>
+          <h2 style={styles.title}>Job Tracker</h2>
+          <p style={styles.subtitle}>Enter the code from your authenticator app, or a recovery code</p>
+          {error && <div style={styles.error}>{error}</div>}
+          <form onSubmit={handleCode}>
+            <input
+              style={styles.input}
+              type="text"
+              inputMode="text"
+              autoComplete="one-time-code"
+              placeholder="123456"
+              value={code}
+              onChange={(e) => setCode(e.target.value)}
+              autoFocus
+              required
+            />
+            <button style={styles.button} type="submit" disabled={loading}>
+              {loading ? 'Verifying...' : 'Verify'}
+            </button>
+          </form>
+          <p style={styles.link}>
+            <button style={styles.linkButton} type="button" onClick={handleCancelCode}>
+              Back to sign in
+            </button>
+          </p>
+        </div>
+      </div>
+    );
+  }
+
   return (
     <div style={styles.container}>
       <div style={styles.card}>
         <h2 style={styles.title}>Job Tracker</h2>
         <p style={styles.subtitle}>Sign in to your account</p>
         {error && <div style={styles.error}>{error}</div>}
+        {notice && <div style={styles.notice}>{notice}</div>}
+        {unverified && (
+          <button style={styles.linkButton} type="button" onClick={handleResend}>
+            Resend verification email
+          </button>
+        )}
         <form onSubmit={handleSubmit}>
           <input
             style={styles.input}
@@ -53,6 +145,19 @@ export default function LoginPage() {
             {loading ? 'Signing in...' : 'Sign In'}
           </button>
         </form>
+        {providers.length > 0 && (
+          <>
+            <div style={styles.divider}>or</div>
+            {providers.map((p) => (
+              <a key={p.name} style={styles.ssoButton} href={ssoLoginURL(p.name)}>
+                Sign in with {p.display_name}
+              </a>
+            ))}
+          </>
+        )}
+        <p style={styles.link}>
+          <Link to="/forgot-password">Forgot password?</Link>
+        </p>
         <p style={styles.link}>
           Don&apos;t have an account? <Link to="/signup">Sign up</Link>
         </p>
@@ -98,6 +203,42 @@ const styles = {
     marginBottom: '16px',
     fontSize: '14px',
   },
+  notice: {
+    backgroundColor: '#d1e7dd',
+    color: '#0f5132',
+    padding: '10px 12px',
+    borderRadius: '4px',
+    marginBottom: '16px',
+    fontSize: '14px',
+  },
+  divider: {
+    margin: '16px 0 12px',
+    textAlign: 'center',
+    fontSize: '13px',
+    color: '#6c757d',
+  },
+  ssoButton: {
+    display: 'block',
+    padding: '10px',
+    marginBottom: '8px',
+    border: '1px solid #dee2e6',
+    borderRadius: '4px',
+    color: '#212529',
+    fontSize: '14px',
+    textAlign: 'center',
+    textDecoration: 'none',
+    boxSizing: 'border-box',
+  },
+  linkButton: {
+    background: 'none',
+    border: 'none',
+    padding: 0,
+    marginBottom: '16px',
+    color: '#0d6efd',
+    fontSize: '14px',
+    cursor: 'pointer',
+    textDecoration: 'underline',
+  },
   input: {
     display: 'block',
     width: '100%',
@@ -0,0 +1,92 @@
+import { useEffect, useRef, useState } from 'react';
+import { Link, useNavigate, useSearchParams } from 'react-router-dom';
+import { exchangeSSOLogin } from '../services/api';
+import { useAuth } from '../contexts/AuthContext';
+
+// SSOCallbackPage finishes a sign-in through an identity provider, which the
+// backend sends here with a one-time code or an error.
+export default function SSOCallbackPage() {
+  const [searchParams] = useSearchParams();
+  const code = searchParams.get('code') || '';
+  const [error, setError] = useState(searchParams.get('error') || (code ? null : 'Sign-in failed.'));
+  const { login } = useAuth();
+  const navigate = useNavigate();
+  // A code works once; StrictMode's double effect would spend it twice
+  const submitted = useRef(false);
+
+  useEffect(() => {
+    if (!code || error || submitted.current) return;
+    submitted.current = true;
+    exchangeSSOLogin(code)
+      .then((data) => {
+        if (data.two_factor_required) {
+          navigate('/login', { replace: true, state: { challenge: data.challenge_token } });
+          return;
+        }
+        login(data.token, data.user, data.refresh_token);
+        navigate('/', { replace: true });
+      })
+      .catch((err) => setError(err.response?.data?.error || 'Sign-in failed.'));
+  }, [code, error, login, navigate]);
+
+  return (
+    <div style={styles.container}>
+      <div style={styles.card}>
+        <h2 style={styles.title}>Job Tracker</h2>
+        {error ? (
+          <div style={styles.error}>{error}</div>
+        ) : (
+          <p style={styles.subtitle}>Signing you in...</p>
+        )}
+        <p style={styles.link}>
+          <Link to="/login">Back to sign in</Link>
+        </p>
+      </div>
+    </div>
+  );
+}
+
+const styles = {
+  container: {
+    minHeight: '100vh',
+    backgroundColor: '#f5f5f5',
+    display: 'flex',
+    alignItems: 'center',
+    justifyContent: 'center',
+  },
+  card: {
+    backgroundColor: 'white',
+    borderRadius: '8px',
+    padding: '40px',
+    boxShadow: '0 2px 8px rgba(0,0,0,0.1)',
+    width: '100%',
+    maxWidth: '400px',
+  },
+  title: {
+    margin: '0 0 4px 0',
+    fontSize: '24px',
+    fontWeight: '600',
+    color: '#0d6efd',
+    textAlign: 'center',
+  },
+  subtitle: {
+    margin: '0 0 24px 0',
+    fontSize: '14px',
+    color: '#6c757d',
+    textAlign: 'center',
+  },
+  error: {
+    backgroundColor: '#f8d7da',
+    color: '#721c24',
+    padding: '10px 12px',
+    borderRadius: '4px',
+    margin: '16px 0',
+    fontSize: '14px',
+  },
+  link: {
+    marginTop: '20px',
+    textAlign: 'center',
+    fontSize: '14px',
+    color: '#6c757d',
+  },
+};
@@ -9,6 +9,7 @@ export default function SignupPage() {
   const [confirmPassword, setConfirmPassword] = useState('');
   const [error, setError] = useState(null);
   const [loading, setLoading] = useState(false);
+  const [awaitingVerification, setAwaitingVerification] = useState(false);
   const { login } = useAuth();
   const navigate = useNavigate();
 
@@ -24,7 +25,12 @@ export default function SignupPage() {
     setLoading(true);
     try {
       const data = await registerUser({ email, password });
-      login(data.token, data.user);
+      // No tokens means the email must be verified before signing in
+      if (!data.token) {
+        setAwaitingVerification(true);
+        return;
+      }
+      login(data.token, data.user, data.refresh_token);
       navigate('/');
     } catch (err) {
       setError(err.response?.data?.error || 'Registration failed');
@@ -33,6 +39,23 @@ export default function SignupPage() {
     }
   };
 
+  if (awaitingVerification) {
+    return (
+      <div style={styles.container}>
+        <div style={styles.card}>
+          <h2 style={styles.title}>Job Tracker</h2>
+          <p style={styles.subtitle}>Check your email</p>
+          <p style={styles.text}>
+            We sent a verification link to <strong>{email}</strong>. Open it to activate your account, then sign in.
+          </p>
+          <p style={styles.link}>
+            <Link to="/login">Back to sign in</Link>
+          </p>
+        </div>
+      </div>
+    );
+  }
+
   return (
     <div style={styles.container}>
       <div style={styles.card}>
@@ -106,6 +129,11 @@ const styles = {
     color: '#6c757d',
     textAlign: 'center',
   },
+  text: {
+    fontSize: '14px',
+    color: '#212529',
+    lineHeight: '1.5',
+  },
   error: {
     backgroundColor: '#f8d7da',
     color: '#721c24',
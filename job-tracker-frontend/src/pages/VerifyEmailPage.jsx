@@ -0,0 +1,115 @@
+import { useEffect, useRef, useState } from 'react';
+import { Link, useSearchParams } from 'react-router-dom';
+import { verifyEmail } from '../services/api';
+
+export default function VerifyEmailPage() {
+  const [searchParams] = useSearchParams();
+  const token = searchParams.get('token') || '';
+  const [status, setStatus] = useState(token ? 'verifying' : 'failed');
+  const [error, setError] = useState(token ? null : 'This link is missing its token.');
+  // A token works once; StrictMode's double effect would spend it twice
+  const submitted = useRef(false);
+
+  useEffect(() => {
+    if (!token || submitted.current) return;
+    submitted.current = true;
+    verifyEmail(token)
+      .then(() => setStatus('verified'))
+      .catch((err) => {
+        setError(err.response?.data?.error || 'Could not verify the email');
+        setStatus('failed');
+      });
+  }, [token]);
+
+  return (
+    <div style={styles.container}>
+      <div style={styles.card}>
+        <h2 style={styles.title}>Job Tracker</h2>
+        <p style={styles.subtitle}>Email verification</p>
+        {status === 'verifying' && <p style={styles.link}>Verifying your email...</p>}
+        {status === 'verified' && <div style={styles.notice}>Your email is verified. You can now sign in.</div>}
+        {status === 'failed' && (
+          <div style={styles.error}>{error} Sign in to request a new verification email.</div>
+        )}
+        <p style={styles.link}>
+          <Link to="/login">Go to sign in</Link>
+        </p>
+      </div>
+    </div>
+  );
+}
+
+const styles = {
+  container: {
+    minHeight: '100vh',
+    backgroundColor: '#f5f5f5',
+    display: 'flex',
+    alignItems: 'center',
+    justifyContent: 'center',
+  },
+  card: {
+    backgroundColor: 'white',
+    borderRadius: '8px',
+    padding: '40px',
+    boxShadow: '0 2px 8px rgba(0,0,0,0.1)',
+    width: '100%',
+    maxWidth: '400px',
+  },
+  title: {
+    margin: '0 0 4px 0',
+    fontSize: '24px',
+    fontWeight: '600',
+    color: '#0d6efd',
+    textAlign: 'center',
+  },
+  subtitle: {
+    margin: '0 0 24px 0',
+    fontSize: '14px',
+    color: '#6c757d',
+    textAlign: 'center',
+  },
+  error: {
+    backgroundColor: '#f8d7da',
+    color: '#721c24',
+    padding: '10px 12px',
+    borderRadius: '4px',
+    marginBottom: '16px',
+    fontSize: '14px',
+  },
+  notice: {
+    backgroundColor: '#d1e7dd',
+    color: '#0f5132',
+    padding: '10px 12px',
+    borderRadius: '4px',
+    marginBottom: '16px',
+    fontSize: '14px',
+  },
+  input: {
+    display: 'block',
+    width: '100%',
+    padding: '10px 12px',
+    marginBottom: '12px',
+    border: '1px solid #dee2e6',
+    borderRadius: '4px',
+    fontSize: '14px',
+    boxSizing: 'border-box',
+  },
+  button: {
+    display: 'block',
+    width: '100%',
+    padding: '10px',
+    backgroundColor: '#0d6efd',
+    color: 'white',
+    border: 'none',
+    borderRadius: '4px',
+    fontSize: '14px',
+    cursor: 'pointer',
+    marginTop: '4px',
+  },
+  link: {
+    marginTop: '20px',
+    textAlign: 'center',
+    fontSize: '14px',
+    color: '#6c757d',
+  },
+};
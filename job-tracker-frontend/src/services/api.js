@@ -17,14 +17,70 @@ api.interceptors.request.use((config) => {
   return config;
 });
 
+export const clearSession = () => {
+  localStorage.removeItem('token');
+  localStorage.removeItem('refreshToken');
+  localStorage.removeItem('user');
+};
+
+// Access tokens are short-lived. A request rejected with 401 is retried once
+// after exchanging the refresh token for new tokens. Concurrent requests
+// share one refresh: a refresh token works only once, and presenting it
+// twice ends the session.
+let refreshing = null;
+
+// Endpoints that answer 401 for bad credentials rather than an expired token.
+const publicAuthPaths = [
+  '/api/auth/login',
+  '/api/auth/login/2fa',
+  '/api/auth/register',
+  '/api/auth/refresh',
+  '/api/auth/logout',
+  '/api/auth/sso/exchange',
+];
+
+const refreshTokens = () => {
+  if (!refreshing) {
+    const refreshToken = localStorage.getItem('refreshToken');
+    refreshing = (refreshToken
+      ? axios.post(`${API_BASE}/api/auth/refresh`, { refresh_token: refreshToken })
+      : Promise.reject(new Error('no refresh token'))
+    )
+      .then((res) => {
+        const { token, refresh_token } = res.data.data;
+        localStorage.setItem('token', token);
+        localStorage.setItem('refreshToken', refresh_token);
+        return token;
+      })
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
+};
+
 api.interceptors.response.use(
   (response) => response,
-  (error) => {
-    if (error.response?.status === 401) {
-      localStorage.removeItem('token');
-      localStorage.removeItem('user');
-      window.location.href = '/login';
+  async (error) => {
+    const { config, response } = error;
+    if (response?.status !== 401) {
+      return Promise.reject(error);
+    }
+    if (publicAuthPaths.includes(config?.url)) {
+      return Promise.reject(error);
     }
+    if (config && !config._retried) {
+      try {
+        const token = await refreshTokens();
+        config._retried = true;
+        config.headers.Authorization = `Bearer ${token}`;
+        return api(config);
+      } catch {
+        // fall through to signing out
+      }
+    }
+    clearSession();
+    window.location.href = '/login';
     return Promise.reject(error);
   }
 );
@@ -59,8 +115,12 @@ export const updateJobStatus = async (id, status) => {
   return response.data.data;
 };
 
+// Search results are shown as text, so ask for Markdown descriptions, which
+// read cleanly and are normalized again if the job is saved.
 export const searchJobs = async (searchParams) => {
-  const response = await api.post('/api/jobs/search', searchParams);
+  const response = await api.post('/api/jobs/search', searchParams, {
+    params: { description_format: 'markdown' },
+  });
   return response.data.data;
 };
 
@@ -137,6 +197,69 @@ export const loginUser = async ({ email, password }) => {
   return res.data.data;
 };
 
+// completeLogin sends the second factor of a sign-in that loginUser answered
+// with a challenge: an authenticator code or a recovery code.
+export const completeLogin = async ({ challenge_token, code }) => {
+  const res = await api.post('/api/auth/login/2fa', { challenge_token, code });
+  return res.data.data;
+};
+
+export const getSSOProviders = async () => {
+  const res = await api.get('/api/auth/sso/providers');
+  return res.data.data || [];
+};
+
+// ssoLoginURL is where the browser goes to sign in with an identity
+// provider; it comes back to /sso/callback.
+export const ssoLoginURL = (provider) => `${API_BASE}/api/auth/sso/${encodeURIComponent(provider)}/login`;
+
+export const exchangeSSOLogin = async (code) => {
+  const res = await api.post('/api/auth/sso/exchange', { code });
+  return res.data.data;
+};
+
+// logoutUser ends this device's session on the server. It needs only the
+// refresh token, so it works even after the access token has expired.
+export const logoutUser = async () => {
+  const refreshToken = localStorage.getItem('refreshToken');
+  if (refreshToken) {
+    await api.post('/api/auth/logout', { refresh_token: refreshToken });
+  }
+};
+
+export const logoutEverywhere = async () => {
+  await api.post('/api/auth/logout-all');
+};
+
+export const getSessions = async () => {
+  const res = await api.get('/api/auth/sessions');
+  return res.data.data || [];
+};
+
+export const revokeSession = async (id) => {
+  await api.delete(`/api/auth/sessions/${id}`);
+};
+
+export const forgotPassword = async (email) => {
+  const res = await api.post('/api/auth/forgot-password', { email });
+  return res.data;
+};
+
+export const resetPassword = async ({ token, password }) => {
+  const res = await api.post('/api/auth/reset-password', { token, password });
+  return res.data;
+};
+
+export const verifyEmail = async (token) => {
+  const res = await api.post('/api/auth/verify-email', { token });
+  return res.data;
+};
+
+export const resendVerification = async (email) => {
+  const res = await api.post('/api/auth/resend-verification', { email });
+  return res.data;
+};
+
 export const changePassword = async ({ current_password, new_password }) => {
   const res = await api.post('/api/auth/change-password', { current_password, new_password });
   return res.data;